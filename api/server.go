@@ -0,0 +1,358 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"binance-trader-bot/config"
+	"binance-trader-bot/database"
+	"binance-trader-bot/metrics"
+	"binance-trader-bot/models"
+	"binance-trader-bot/services"
+	"binance-trader-bot/utils"
+)
+
+const (
+	defaultRecentTrades = 20
+	maxRecentTrades     = 500
+	defaultEvents       = 50
+	maxEvents           = 1000
+)
+
+// Server exposes a small authenticated HTTP API for manual intervention.
+type Server struct {
+	binanceService  *services.BinanceService
+	stateManager    *services.StateManager
+	tradingStrategy *services.TradingStrategy
+	config          *config.Config
+	metrics         *metrics.Registry
+	logger          *utils.Logger
+	httpServer      *http.Server
+}
+
+// NewServer creates and returns a new Server listening on cfg.HTTPAddr.
+func NewServer(
+	binanceService *services.BinanceService,
+	stateManager *services.StateManager,
+	tradingStrategy *services.TradingStrategy,
+	cfg *config.Config,
+	metricsRegistry *metrics.Registry,
+	logger *utils.Logger,
+) *Server {
+	s := &Server{
+		binanceService:  binanceService,
+		stateManager:    stateManager,
+		tradingStrategy: tradingStrategy,
+		config:          cfg,
+		metrics:         metricsRegistry,
+		logger:          logger,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.requireToken(s.handleHealth))
+	mux.HandleFunc("GET /metrics", s.requireToken(s.handleMetrics))
+	mux.HandleFunc("GET /status", s.requireToken(s.handleStatus))
+	mux.HandleFunc("GET /trades", s.requireToken(s.handleListTrades))
+	mux.HandleFunc("GET /trades/recent", s.requireToken(s.handleRecentTrades))
+	mux.HandleFunc("GET /events", s.requireToken(s.handleListEvents))
+	mux.HandleFunc("POST /cycle", s.requireToken(s.handleRunCycle))
+	mux.HandleFunc("POST /pause", s.requireToken(s.handlePause))
+	mux.HandleFunc("POST /resume", s.requireToken(s.handleResume))
+	mux.HandleFunc("POST /orders/{binanceID}/cancel", s.requireToken(s.handleCancelOrder))
+
+	s.httpServer = &http.Server{
+		Addr:              cfg.HTTPAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Start serves the API until ctx is cancelled.
+func (s *Server) Start(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+			s.logger.Errorf("Failed to shut down HTTP API: %v", err)
+		}
+	}()
+
+	s.logger.Infof("HTTP API listening on %s", s.config.HTTPAddr)
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.logger.Errorf("HTTP API stopped: %v", err)
+	}
+}
+
+// requireToken rejects requests that do not carry "Authorization: Bearer <API_TOKEN>".
+func (s *Server) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.APIToken)) != 1 {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleHealth reports whether the database is reachable and which schema migration it is at. It reads
+// schema_migrations over the bot's own pool, so a probe never opens a connection of its own.
+// A dirty migration state answers 503, since the schema needs manual repair.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	version, dirty, err := s.stateManager.GetMigrationVersion(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "unhealthy", "error": err.Error()})
+		return
+	}
+	migration := database.MigrationState{Version: version, Dirty: dirty}
+	status, code := "ok", http.StatusOK
+	if migration.Dirty {
+		status, code = "unhealthy", http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, map[string]any{"status": status, "migration": migration})
+}
+
+// statusResponse is the body returned by GET /status.
+type statusResponse struct {
+	Symbol            string           `json:"symbol"`
+	CurrentPrice      float64          `json:"current_price"`
+	OpenTrades        int              `json:"open_trades"`
+	ErrorTrades       int              `json:"error_trades"`
+	RealizedProfit    float64          `json:"realized_profit_usdt"` // As last saved to the database
+	ActiveSymbols     []string         `json:"active_symbols"`       // Symbols with OPEN trades in the database
+	UnrealizedPnLUSDT float64          `json:"unrealized_pnl_usdt"`
+	EquityUSDT        float64          `json:"equity_usdt"`
+	PositionPnLUSDT   float64          `json:"position_pnl_usdt"`   // Open position value minus its cost basis
+	LadderCapitalUSDT float64          `json:"ladder_capital_usdt"` // USDT needed to fill every rung of the configured strategy
+	BotState          *models.BotState `json:"bot_state"`
+}
+
+// handleStatus reports the bot state together with the unrealized P&L of open trades.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	cfg := s.tradingStrategy.Config() // Reflects SIGHUP reloads
+	currentPrice, err := s.binanceService.GetCurrentPrice(r.Context(), cfg.Symbol)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	pnl, _, err := s.tradingStrategy.ComputeUnrealizedPnL(r.Context(), currentPrice)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	openTrades, err := s.stateManager.GetOpenTradeCount(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	realizedProfit, err := s.stateManager.GetTotalProfit(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	errorTrades, err := s.stateManager.CountTradesByStatus(r.Context(), models.TradeStatusError)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	activeSymbols, err := s.stateManager.GetSymbolsWithOpenTrades(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Encode a copy: the live state keeps changing under the cycle while the response is written
+	botState := s.tradingStrategy.Snapshot()
+	if botState == nil {
+		writeError(w, http.StatusServiceUnavailable, "bot state not loaded")
+		return
+	}
+	writeJSON(w, http.StatusOK, statusResponse{
+		Symbol:            cfg.Symbol,
+		CurrentPrice:      currentPrice,
+		OpenTrades:        openTrades,
+		ErrorTrades:       errorTrades,
+		RealizedProfit:    realizedProfit,
+		ActiveSymbols:     activeSymbols,
+		UnrealizedPnLUSDT: pnl,
+		EquityUSDT:        botState.Equity(currentPrice),
+		PositionPnLUSDT:   botState.PositionPnL(currentPrice),
+		LadderCapitalUSDT: s.tradingStrategy.EstimateLadderCapital(currentPrice).TotalUSDT,
+		BotState:          botState,
+	})
+}
+
+// handleListTrades lists trades in the status given by ?status= (OPEN by default), including
+// the error_reason of trades marked ERROR. ?tag= narrows them to one strategy tag.
+func (s *Server) handleListTrades(w http.ResponseWriter, r *http.Request) {
+	status := models.TradeStatus(strings.ToUpper(r.URL.Query().Get("status")))
+	if status == "" {
+		status = models.TradeStatusOpen
+	}
+	switch status {
+	case models.TradeStatusOpen, models.TradeStatusSold, models.TradeStatusCanceled, models.TradeStatusError:
+	default:
+		writeError(w, http.StatusBadRequest, "invalid status: "+string(status))
+		return
+	}
+
+	var trades []*models.Trade
+	var err error
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		trades, err = s.stateManager.GetTradesByStatusAndTag(r.Context(), status, tag)
+	} else {
+		trades, err = s.stateManager.GetTradesByStatus(r.Context(), status)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if trades == nil {
+		trades = []*models.Trade{}
+	}
+	writeJSON(w, http.StatusOK, trades)
+}
+
+// handleRecentTrades lists the most recently updated trades across all statuses, newest first.
+// ?limit= defaults to defaultRecentTrades and is capped at maxRecentTrades.
+func (s *Server) handleRecentTrades(w http.ResponseWriter, r *http.Request) {
+	limit := defaultRecentTrades
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit: "+raw)
+			return
+		}
+		limit = min(n, maxRecentTrades)
+	}
+
+	trades, err := s.stateManager.GetRecentTrades(r.Context(), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if trades == nil {
+		trades = []*models.Trade{}
+	}
+	writeJSON(w, http.StatusOK, trades)
+}
+
+// handleListEvents lists the most recent lifecycle events (starts, stops, halts, reloads,
+// liquidations), newest first. ?limit= defaults to defaultEvents and is capped at maxEvents.
+func (s *Server) handleListEvents(w http.ResponseWriter, r *http.Request) {
+	limit := defaultEvents
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit: "+raw)
+			return
+		}
+		limit = min(n, maxEvents)
+	}
+
+	events, err := s.stateManager.GetRecentEvents(r.Context(), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if events == nil {
+		events = []*models.Event{}
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+// handleMetrics serves the metrics in the Prometheus text format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := s.metrics.WriteText(w); err != nil {
+		s.logger.Errorf("Failed to write metrics: %v", err)
+	}
+}
+
+// handleRunCycle runs one trading cycle on demand. It waits for a background cycle in progress to
+// finish, and the cycle is not cancelled if the client disconnects.
+func (s *Server) handleRunCycle(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Manual trading cycle requested via API.")
+	result, err := s.tradingStrategy.RunCycleNow(context.WithoutCancel(r.Context()))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error(), "result": result})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handlePause stops the bot from placing new orders; cycles keep managing existing ones and
+// stop-losses still close losing trades.
+// The flag is stored with the bot state, so the bot stays paused across restarts.
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	s.setPaused(w, r, true)
+}
+
+// handleResume lets the bot place new orders again after POST /pause.
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	s.setPaused(w, r, false)
+}
+
+func (s *Server) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	ctx := context.WithoutCancel(r.Context()) // May wait for a running cycle
+	if err := s.tradingStrategy.SetPaused(ctx, paused); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	eventType, action := models.EventResume, "resumed"
+	if paused {
+		eventType, action = models.EventPause, "paused"
+	}
+	s.logger.Infof("Order placement %s via HTTP API.", action)
+	s.stateManager.RecordEvent(ctx, eventType, "order placement "+action+" via HTTP API")
+	writeJSON(w, http.StatusOK, map[string]bool{"paused": paused})
+}
+
+// handleCancelOrder cancels an order on Binance and settles it locally through the trading strategy.
+func (s *Server) handleCancelOrder(w http.ResponseWriter, r *http.Request) {
+	binanceID, err := strconv.ParseInt(r.PathValue("binanceID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid order id")
+		return
+	}
+
+	ctx := context.WithoutCancel(r.Context()) // May wait for a running cycle
+	order, err := s.tradingStrategy.CancelOrder(ctx, binanceID)
+	if errors.Is(err, services.ErrOrderNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	s.logger.Infof("Order %d cancelled via HTTP API.", binanceID)
+	writeJSON(w, http.StatusOK, order)
+}
+
+// writeJSON helper function to encode a JSON response body.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError helper function to encode a JSON error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
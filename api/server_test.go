@@ -0,0 +1,555 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"binance-trader-bot/config"
+	"binance-trader-bot/metrics"
+	"binance-trader-bot/models"
+	"binance-trader-bot/repositories"
+	"binance-trader-bot/services"
+	"binance-trader-bot/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const testToken = "test-token"
+
+// newTestServer returns a Server whose state lives in a sqlmock database and whose Binance requests go
+// to binance. A nil binance fails the test on any Binance request.
+func newTestServer(t *testing.T, cfg *config.Config, binance http.Handler) (*Server, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	mock.MatchExpectationsInOrder(false)
+	t.Cleanup(func() { db.Close() })
+
+	if binance == nil {
+		binance = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected Binance request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		})
+	}
+	binanceServer := httptest.NewServer(binance)
+	t.Cleanup(binanceServer.Close)
+
+	if cfg.Symbol == "" {
+		cfg.Symbol = "BTCUSDT"
+	}
+	cfg.APIToken = testToken
+	logger := utils.NewLogger()
+	binanceService := services.NewBinanceService("test-key", "test-secret", false, false, logger)
+	binanceService.SetBaseURL(binanceServer.URL)
+	stateManager := services.NewStateManager(repositories.NewTradeRepository(db), logger)
+	stateManager.SetBotState(models.NewBotState(1000))
+	registry := metrics.NewRegistry()
+	strategy := services.NewTradingStrategy(binanceService, stateManager, cfg, registry, logger)
+	return NewServer(binanceService, stateManager, strategy, cfg, registry, logger), mock
+}
+
+// do sends an authenticated request to the server and returns the recorded response.
+func do(s *Server, method, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequireToken(t *testing.T) {
+	s, _ := newTestServer(t, &config.Config{}, nil)
+
+	for _, header := range []string{"", "Bearer wrong", testToken} {
+		req := httptest.NewRequest(http.MethodPost, "/pause", nil)
+		req.Header.Set("Authorization", header)
+		rec := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status = %d, want 401", header, rec.Code)
+		}
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	s, mock := newTestServer(t, &config.Config{}, nil)
+	botState := s.stateManager.GetBotState()
+
+	tests := []struct {
+		target string
+		paused bool
+		event  models.EventType
+	}{
+		{"/pause", true, models.EventPause},
+		{"/resume", false, models.EventResume},
+	}
+	for _, tt := range tests {
+		mock.ExpectExec("INSERT INTO bot_states").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO events").WithArgs(tt.event, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		rec := do(s, http.MethodPost, tt.target)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST %s: status = %d, want 200 (%s)", tt.target, rec.Code, rec.Body)
+		}
+		var body map[string]bool
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["paused"] != tt.paused {
+			t.Errorf("POST %s: body = %s, want paused %t", tt.target, rec.Body, tt.paused)
+		}
+		if botState.Paused != tt.paused {
+			t.Errorf("POST %s: Paused = %t, want %t", tt.target, botState.Paused, tt.paused)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPauseNotPersisted(t *testing.T) {
+	s, mock := newTestServer(t, &config.Config{}, nil)
+	mock.ExpectExec("INSERT INTO bot_states").WillReturnError(errors.New("connection refused"))
+
+	rec := do(s, http.MethodPost, "/pause")
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500 when the flag cannot be saved", rec.Code)
+	}
+	if s.stateManager.GetBotState().Paused {
+		t.Error("bot paused although the flag was not persisted")
+	}
+}
+
+func TestHealth(t *testing.T) {
+	migrationRows := func(version int64, dirty bool) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"version", "dirty"}).AddRow(version, dirty)
+	}
+	tests := []struct {
+		name        string
+		rows        *sqlmock.Rows
+		err         error
+		wantCode    int
+		wantVersion float64
+	}{
+		{"migrated", migrationRows(12, false), nil, http.StatusOK, 12},
+		{"fresh", sqlmock.NewRows([]string{"version", "dirty"}), nil, http.StatusOK, 0},
+		{"dirty", migrationRows(12, true), nil, http.StatusServiceUnavailable, 12},
+		{"unreachable", nil, errors.New("connection refused"), http.StatusServiceUnavailable, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestServer(t, &config.Config{}, nil)
+			query := mock.ExpectQuery("FROM schema_migrations")
+			if tt.err != nil {
+				query.WillReturnError(tt.err)
+			} else {
+				query.WillReturnRows(tt.rows)
+			}
+
+			rec := do(s, http.MethodGet, "/health")
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.wantCode, rec.Body)
+			}
+			var body struct {
+				Migration struct {
+					Version float64 `json:"version"`
+				} `json:"migration"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON body %s: %v", rec.Body, err)
+			}
+			if body.Migration.Version != tt.wantVersion {
+				t.Errorf("migration version = %v, want %v", body.Migration.Version, tt.wantVersion)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// binanceRoutes is a fake Binance answering each "METHOD /path" with a fixed JSON body.
+type binanceRoutes map[string]string
+
+func (routes binanceRoutes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, ok := routes[r.Method+" "+r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(body))
+}
+
+// openTradeRows returns count OPEN trades bought at 29000, as rows of the trade columns.
+func openTradeRows(count int) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "buy_order_id", "sell_order_id", "symbol", "buy_price", "buy_quantity", "sell_price_target",
+		"actual_sell_price", "status", "profit_usdt", "roi_percent", "opened_at", "closed_at", "last_status_update",
+		"error_reason", "reprice_count", "strategy_tag", "sell_profit_percentage",
+	})
+	now := time.Now()
+	for id := 1; id <= count; id++ {
+		rows.AddRow(id, int64(100+id), nil, "BTCUSDT", 29000.0, 0.001, 29580.0,
+			nil, models.TradeStatusOpen, nil, nil, now, nil, now, nil, 0, "", 2.0)
+	}
+	return rows
+}
+
+func TestStatus(t *testing.T) {
+	s, mock := newTestServer(t, &config.Config{OrderAmount: 20, BuyPercentages: []float64{2, 4}}, binanceRoutes{
+		"GET /api/v3/ticker/price": `{"symbol":"BTCUSDT","price":"30000.00000000"}`,
+	})
+	mock.ExpectQuery("SELECT COUNT").WithArgs(models.TradeStatusOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("SELECT COUNT").WithArgs(models.TradeStatusError).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT total_usdt_profit").WillReturnRows(sqlmock.NewRows([]string{"total_usdt_profit"}).AddRow(12.5))
+	mock.ExpectQuery("SELECT DISTINCT symbol").WithArgs(models.TradeStatusOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("BTCUSDT").AddRow("ETHUSDT"))
+	mock.ExpectQuery("FROM trades").WillReturnRows(openTradeRows(2))
+
+	rec := do(s, http.MethodGet, "/status")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	var body statusResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON body %s: %v", rec.Body, err)
+	}
+	if body.OpenTrades != 2 || body.ErrorTrades != 1 || body.RealizedProfit != 12.5 {
+		t.Errorf("open/error trades and profit = %d/%d/%v, want 2/1/12.5", body.OpenTrades, body.ErrorTrades, body.RealizedProfit)
+	}
+	// Two trades of 0.001 BTC bought 1000 USDT below the current price
+	if math.Abs(body.UnrealizedPnLUSDT-2) > 1e-9 {
+		t.Errorf("unrealized P&L = %v, want 2", body.UnrealizedPnLUSDT)
+	}
+	// A database shared with another bot lists its symbol too
+	if len(body.ActiveSymbols) != 2 || body.ActiveSymbols[0] != "BTCUSDT" || body.ActiveSymbols[1] != "ETHUSDT" {
+		t.Errorf("active symbols = %v, want [BTCUSDT ETHUSDT]", body.ActiveSymbols)
+	}
+	// Ten initial rungs and two additional ones of 20 USDT each
+	if body.LadderCapitalUSDT != 240 {
+		t.Errorf("ladder capital = %v, want 240", body.LadderCapitalUSDT)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStatusWhileStateChanges(t *testing.T) {
+	s, mock := newTestServer(t, &config.Config{OrderAmount: 20}, binanceRoutes{
+		"GET /api/v3/ticker/price": `{"symbol":"BTCUSDT","price":"30000.00000000"}`,
+	})
+	mock.ExpectQuery("SELECT COUNT").WithArgs(models.TradeStatusOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT COUNT").WithArgs(models.TradeStatusError).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT total_usdt_profit").WillReturnRows(sqlmock.NewRows([]string{"total_usdt_profit"}).AddRow(0))
+	mock.ExpectQuery("SELECT DISTINCT symbol").WithArgs(models.TradeStatusOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}))
+	mock.ExpectQuery("FROM trades").WillReturnRows(openTradeRows(0))
+
+	// Pausing flips the live state under the cycle mutex; run with -race to catch unguarded reads
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for paused := true; ; paused = !paused {
+			select {
+			case <-done:
+				return
+			default:
+				_ = s.tradingStrategy.SetPaused(context.Background(), paused) // Not persisted: sqlmock expects no save
+			}
+		}
+	}()
+	rec := do(s, http.MethodGet, "/status")
+	close(done)
+	wg.Wait()
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	var body statusResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Symbol != "BTCUSDT" || body.BotState == nil {
+		t.Errorf("body = %s, want the BTCUSDT status with a bot state", rec.Body)
+	}
+}
+
+// orderRows returns NEW BUY orders with the given Binance IDs, as rows of the order columns.
+func orderRows(binanceIDs ...int64) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "binance_id", "symbol", "type", "price", "quantity", "quote_qty", "status", "is_test",
+		"placed_at", "executed_at", "last_updated_at", "sell_profit_percentage",
+	})
+	now := time.Now()
+	for i, binanceID := range binanceIDs {
+		rows.AddRow(i+1, binanceID, "BTCUSDT", models.OrderTypeBuy, 29000.0, 0.001, 29.0, models.OrderStatusNew, false,
+			now, nil, now, 2.0)
+	}
+	return rows
+}
+
+func TestCancelOrder(t *testing.T) {
+	rejected := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":-2011,"msg":"Unknown order sent."}`))
+	})
+	cancelled := binanceRoutes{
+		"DELETE /api/v3/order": `{"symbol":"BTCUSDT","orderId":42,"status":"CANCELED"}`,
+		"GET /api/v3/order": `{"symbol":"BTCUSDT","orderId":42,"price":"29000","origQty":"0.001","executedQty":"0",` +
+			`"cummulativeQuoteQty":"0","status":"CANCELED","side":"BUY","type":"LIMIT"}`,
+	}
+
+	t.Run("valid cancel", func(t *testing.T) {
+		s, mock := newTestServer(t, &config.Config{}, cancelled)
+		mock.ExpectQuery("FROM orders").WithArgs(int64(42)).WillReturnRows(orderRows(42))
+		mock.ExpectExec("UPDATE orders").WithArgs(models.OrderStatusCanceled, sqlmock.AnyArg(), sqlmock.AnyArg(), int64(42)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO bot_states").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		rec := do(s, http.MethodPost, "/orders/42/cancel")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+		}
+		var body models.Order
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Status != models.OrderStatusCanceled {
+			t.Errorf("body = %s, want the order with status CANCELED", rec.Body)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("unknown order", func(t *testing.T) {
+		s, mock := newTestServer(t, &config.Config{}, nil)
+		mock.ExpectQuery("FROM orders").WithArgs(int64(42)).WillReturnError(sql.ErrNoRows)
+
+		rec := do(s, http.MethodPost, "/orders/42/cancel")
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404 (%s)", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		s, _ := newTestServer(t, &config.Config{}, nil)
+
+		rec := do(s, http.MethodPost, "/orders/abc/cancel")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400 (%s)", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("binance rejection", func(t *testing.T) {
+		s, mock := newTestServer(t, &config.Config{}, rejected)
+		mock.ExpectQuery("FROM orders").WithArgs(int64(42)).WillReturnRows(orderRows(42))
+
+		rec := do(s, http.MethodPost, "/orders/42/cancel")
+		if rec.Code != http.StatusBadGateway {
+			t.Errorf("status = %d, want 502 (%s)", rec.Code, rec.Body)
+		}
+		// The local order must stay untouched: no UPDATE was expected
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// cycleRoutes returns a fake Binance serving what a trading cycle reads: a TRADING BTCUSDT symbol,
+// balances, the ticker and an empty open order list.
+func cycleRoutes() binanceRoutes {
+	return binanceRoutes{
+		"GET /api/v3/exchangeInfo": `{"symbols":[{"symbol":"BTCUSDT","status":"TRADING","baseAsset":"BTC","quoteAsset":"USDT",` +
+			`"quoteAssetPrecision":8,"filters":[` +
+			`{"filterType":"PRICE_FILTER","minPrice":"0.01","maxPrice":"1000000","tickSize":"0.01"},` +
+			`{"filterType":"LOT_SIZE","minQty":"0.00001","maxQty":"9000","stepSize":"0.00001"},` +
+			`{"filterType":"NOTIONAL","minNotional":"5"}]}]}`,
+		"GET /api/v3/account":      `{"balances":[{"asset":"BTC","free":"0.01","locked":"0"},{"asset":"USDT","free":"1000","locked":"0"}]}`,
+		"GET /api/v3/ticker/price": `{"symbol":"BTCUSDT","price":"30000.00000000"}`,
+		"GET /api/v3/openOrders":   `[]`,
+	}
+}
+
+// expectPausedCycle sets up the database calls of a paused cycle with no trades and no active orders.
+func expectPausedCycle(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("FROM trades").WillReturnRows(openTradeRows(0))
+	mock.ExpectQuery("FROM trades").WillReturnRows(openTradeRows(0))
+	mock.ExpectQuery("FROM orders").WillReturnRows(orderRows())
+	mock.ExpectExec("INSERT INTO bot_states").WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestRunCycle(t *testing.T) {
+	s, mock := newTestServer(t, &config.Config{InitialUSDT: 1000}, cycleRoutes())
+	s.stateManager.GetBotState().Paused = true
+	expectPausedCycle(mock)
+
+	rec := do(s, http.MethodPost, "/cycle")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	var body services.CycleResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON body %s: %v", rec.Body, err)
+	}
+	if body.StartedAt.IsZero() || body.FinishedAt.Before(body.StartedAt) || len(body.Errors) != 0 {
+		t.Errorf("cycle result = %+v, want a finished cycle without errors", body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunCycleSerialized(t *testing.T) {
+	// Count the cycles reading balances at the same time; each holds the request briefly
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	routes := cycleRoutes()
+	binance := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/account" {
+			mu.Lock()
+			active++
+			maxActive = max(maxActive, active)
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}
+		routes.ServeHTTP(w, r)
+	})
+	s, mock := newTestServer(t, &config.Config{InitialUSDT: 1000}, binance)
+	s.stateManager.GetBotState().Paused = true
+	expectPausedCycle(mock)
+	expectPausedCycle(mock)
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = do(s, http.MethodPost, "/cycle").Code
+		}()
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("trigger %d: status = %d, want 200", i, code)
+		}
+	}
+	if maxActive != 1 {
+		t.Errorf("%d cycles ran at once, want them serialized", maxActive)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestListTradesIncludesErrorReason(t *testing.T) {
+	s, mock := newTestServer(t, &config.Config{}, nil)
+	now := time.Now()
+	mock.ExpectQuery("FROM trades").WithArgs(models.TradeStatusError).WillReturnRows(sqlmock.NewRows([]string{
+		"id", "buy_order_id", "sell_order_id", "symbol", "buy_price", "buy_quantity", "sell_price_target",
+		"actual_sell_price", "status", "profit_usdt", "roi_percent", "opened_at", "closed_at", "last_status_update",
+		"error_reason", "reprice_count", "strategy_tag", "sell_profit_percentage",
+	}).AddRow(7, 101, nil, "BTCUSDT", 29000.0, 0.001, 29580.0, nil, models.TradeStatusError, nil, nil,
+		now, now, now, "insufficient balance to place sell order", 0, "", 2.0))
+
+	rec := do(s, http.MethodGet, "/trades?status=error")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	var body []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON body %s: %v", rec.Body, err)
+	}
+	if len(body) != 1 || body[0]["error_reason"] != "insufficient balance to place sell order" {
+		t.Errorf("trades = %s, want the ERROR trade with its error_reason", rec.Body)
+	}
+}
+
+func TestRecentTradesLimit(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantLimit  int
+		wantStatus int
+	}{
+		{"", defaultRecentTrades, http.StatusOK},
+		{"?limit=5", 5, http.StatusOK},
+		{"?limit=100000", maxRecentTrades, http.StatusOK},
+		{"?limit=0", 0, http.StatusBadRequest},
+		{"?limit=abc", 0, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			s, mock := newTestServer(t, &config.Config{}, nil)
+			if tt.wantStatus == http.StatusOK {
+				mock.ExpectQuery("ORDER BY last_status_update DESC").WithArgs(tt.wantLimit).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			}
+
+			rec := do(s, http.MethodGet, "/trades/recent"+tt.query)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus == http.StatusOK && strings.TrimSpace(rec.Body.String()) != "[]" {
+				t.Errorf("body = %s, want an empty list", rec.Body)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestListTradesFiltersByTag(t *testing.T) {
+	s, mock := newTestServer(t, &config.Config{}, nil)
+	mock.ExpectQuery("strategy_tag = \\$2").WithArgs(models.TradeStatusSold, "grid-a").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	rec := do(s, http.MethodGet, "/trades?status=sold&tag=grid-a")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestListEvents(t *testing.T) {
+	s, mock := newTestServer(t, &config.Config{}, nil)
+	mock.ExpectQuery("FROM events").WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "details", "created_at"}).
+			AddRow(3, models.EventLiquidation, "trade 7 closed by stop-loss", time.Now()))
+
+	rec := do(s, http.MethodGet, "/events?limit=5")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+	var body []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON body %s: %v", rec.Body, err)
+	}
+	if len(body) != 1 || body[0]["type"] != string(models.EventLiquidation) || body[0]["details"] != "trade 7 closed by stop-loss" {
+		t.Errorf("events = %s, want the liquidation event", rec.Body)
+	}
+	if rec := do(s, http.MethodGet, "/events?limit=-1"); rec.Code != http.StatusBadRequest {
+		t.Errorf("status for a negative limit = %d, want 400", rec.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -12,49 +14,213 @@ type Config struct {
 	BinanceAPIKey               string
 	BinanceSecretKey            string
 	UseTestnet                  bool
+	BinanceBaseURL              string // Overrides the testnet/mainnet REST endpoint, e.g. a proxy, regional endpoint or local fake server (empty uses the default)
 	DatabaseURL                 string
+	DatabaseReadURL             string    // Optional read replica for the HTTP API and --report (empty reads from DatabaseURL)
+	DBHealthCheckSeconds        int       // Interval between database health pings (0 disables the reconnect monitor)
+	DBReconnectAfterFailures    int       // Consecutive failed pings after which the connection pool is reopened
+	DBReconnectMaxBackoffSecs   int       // Upper bound of the exponential backoff between reconnect attempts
 	Symbol                      string    // e.g., "BTCUSDT"
+	BaseAsset                   string    // Overrides the base asset derived from exchange info for SYMBOL (empty derives it)
+	QuoteAsset                  string    // Overrides the quote asset derived from exchange info for SYMBOL (empty derives it)
 	InitialUSDT                 float64   // Initial USDT amount for bot to manage
 	OrderAmount                 float64   // Amount in USDT to use for each buy order
+	OrderAmountPercent          float64   // Size each buy as this percentage of available USDT instead of ORDER_AMOUNT (0 disables)
 	OrderIntervalMinutes        int       // Interval in minutes between initial buy orders
+	InitialBuyOnFill            bool      // Place the next initial buy as soon as the previous one fills, without waiting for the interval
 	InitialBuyPercentage        float64   // Percentage below current price for initial buys (e.g., 1.0 for 1% below)
-	SellProfitPercentage        float64   // Percentage profit target for sell orders (e.g., 2.0 for 2% profit)
+	InitialTriggerDrop          float64   // Hold the initial ladder until price drops this percentage below the startup price (0 starts at once)
+	PauseInitialOnRise          float64   // Pause initial buys while the price rose more than this percentage within PAUSE_INITIAL_RISE_WINDOW_MINUTES (0 disables)
+	PauseInitialRiseWindowMins  int       // Window the PAUSE_INITIAL_ON_RISE rise is measured over
+	SellProfitPercentage        float64   // Percentage profit target for sell orders (e.g., 2.0 for 2% profit); the first entry of SellProfitPercentages
+	SellProfitPercentages       []float64 // Profit target per BUY_PERCENTAGES rung when SELL_PROFIT_PERCENTAGE is a list (a single entry applies to all)
+	SellTargetATRMultiple       float64   // Set each sell target this many ATRs above the buy price instead of SELL_PROFIT_PERCENTAGE (0 disables)
+	ATRPeriod                   int       // Bars the ATR for SELL_TARGET_ATR_MULTIPLE is averaged over
+	ATRInterval                 string    // Kline interval of those bars, e.g. "1h"
+	MinHoldMinutes              int       // Do not place a trade's sell until it has been open this many minutes (0 sells at once)
+	SellRepriceAfterMinutes     int       // Lower an unfilled sell toward break-even after this many minutes (0 disables)
+	SellOrderTTLMinutes         int       // Re-evaluate a sell still unfilled after this many minutes (0 disables)
+	SellOrderTTLAction          string    // What to do with an expired sell: "reprice" (toward break-even) or "market" (sell at market)
+	SellRepriceMinProfit        float64   // Minimum net profit percentage (after round-trip fees) a repriced sell may target
 	BuyPercentages              []float64 // List of percentages for subsequent "escalonadas" buys
 	MaxOpenTrades               int
+	MaxAdditionalBuysPerDay     int  // Cap on additional (non-initial) buys placed per UTC day (0 disables)
+	ShrinkBuyToFunds            bool // Retry a buy rejected for insufficient balance with the USDT actually free on the account
 	TradingCycleIntervalSeconds int
+	OrderStatusSource           string  // Where order status comes from: "rest" (polling), "websocket" (user data stream) or "both"
+	OrderPollIntervalSeconds    int     // Reconcile open orders on their own, faster ticker (0 only checks them during the cycle)
+	InitialOrderType            string  // Order type for initial ladder buys: "limit" or "market"
+	AdditionalOrderType         string  // Order type for additional buys: "limit" or "market"
+	InitialBuyTIF               string  // Time in force of initial limit buys: "GTC", "IOC" or "FOK"
+	AdditionalBuyTIF            string  // Time in force of additional limit buys: "GTC", "IOC" or "FOK"
+	Strategy                    string  // Entry strategy: "ladder" (limit buys below market) or "twap" (market buys in slices)
+	TWAPSlices                  int     // Number of slices INITIAL_USDT is split into when Strategy is "twap"
+	TWAPIntervalMinutes         int     // Interval in minutes between TWAP slices
+	PriceSource                 string  // Reference price: "last" (last trade) or "avg" (Binance 5-minute weighted average)
+	MaxPriceDeviation           float64 // Reject limit buys above / sells below market by more than this percentage (0 disables)
+	MaxPriceAgeSeconds          int     // With PriceSource "last", fall back to the book mid price if the last trade is older than this (0 disables)
+	CachedPriceMaxAgeSeconds    int     // When the price fetch fails, keep managing orders on the last known price if it is at most this old (0 skips the cycle)
+	DefaultPricePrecision       int     // Price decimals used only when exchange info lacks PRICE_FILTER (-1 fails the order instead)
+	DefaultQtyPrecision         int     // Quantity decimals used only when exchange info lacks LOT_SIZE (-1 fails the order instead)
+	APIErrorCooldownSeconds     int     // Pause all REST requests this long after a rate-limit, ban or auth error (0 disables)
+	ValidateBeforePlacing       bool    // Send each order to Binance's test endpoint first and only place it if it passes
+	VerifyPlacedOrders          bool    // Look each placed limit order up right away and flag it if Binance does not know it
+	RetryLotSizeRejections      bool    // Retry a limit order rejected for LOT_SIZE once, with its quantity floored onto the step grid
+	LogPriceDecimals            int     // Decimals prices are shown with in logs (-1 uses the symbol's tick size); storage keeps full precision
+	PriceRounding               string  // Price rounding to tick size: "nearest" or "conservative" (buys round down, sells round up)
+	StopLossPercentage          float64 // Percentage below the buy price at which a position is market-sold (0 disables stop-loss)
+	StopLossConfirmSeconds      int     // Seconds the price must stay below the stop before selling, to ignore transient wicks
+	StopLossMaxPriceAgeSeconds  int     // Refuse to stop out on a last trade price older than this, whatever MAX_PRICE_AGE_SECONDS allows (0 disables)
+	LiquidationMaxSlippage      float64 // Max percentage below best bid a liquidation may fill at, using an IOC limit order (0 sells at market)
+	DailyPriceSnapshot          bool    // Record the first price seen each UTC day in price_snapshots
+	PriceAlertPercentage        float64 // Log an ALERT when the price moves at least this much within PRICE_ALERT_WINDOW_MINUTES (0 disables)
+	PriceAlertWindowMinutes     int     // Window the PRICE_ALERT_PERCENTAGE move is measured over
+	MaxConsecutiveFailures      int     // Stop the bot after this many trading cycles in a row fail (0 never stops)
+	MaxCycles                   int     // Stop the bot after this many trading cycles (0 = unlimited)
+	MaxTotalOrders              int     // Stop placing buys once the bot has placed this many orders over its lifetime (0 = unlimited)
+	CancelOrdersOnShutdown      bool    // Cancel every open order on SYMBOL when the bot exits, so nothing fills while it is down
+	CycleJitterSeconds          int     // Random +/- offset applied to each cycle interval to desynchronize instances (0 disables)
+	MaxSpreadPercentage         float64 // Skip the cycle's order placement when the bid-ask spread exceeds this percentage of the bid (0 disables)
+	MinQuoteToResume            float64 // Available USDT needed to leave "funds depleted" mode (0 uses one order's size)
+	IgnoreDust                  bool    // Treat base asset balances below the symbol's minimum qty/notional as zero
+	ConvertDust                 bool    // When IgnoreDust is on, also try to convert the dust to BNB via Binance's dust transfer
+	AutoWithdrawProfitAbove     float64 // Transfer realized, not yet withdrawn USDT profit to the funding wallet once it exceeds this amount (0 disables)
+	StrategyTag                 string  // Label recorded on every trade this bot opens, to tell strategies apart (empty leaves trades untagged)
+	CSVSnapshotFile             string  // Append a balances/profit snapshot row to this CSV file (empty disables)
+	CSVSnapshotIntervalMinutes  int     // Interval in minutes between CSV snapshot rows
+	HTTPAddr                    string  // Address for the control HTTP API, e.g. ":8080" (empty disables it)
+	APIToken                    string  // Bearer token required by the control HTTP API
 }
 
+// Supported entry strategies.
+const (
+	StrategyLadder = "ladder"
+	StrategyTWAP   = "twap"
+)
+
+// Supported buy order types per phase.
+const (
+	OrderTypeLimit  = "limit"
+	OrderTypeMarket = "market"
+)
+
+// klineIntervals are the kline intervals Binance accepts, for ATR_INTERVAL.
+var klineIntervals = map[string]bool{
+	"1s": true, "1m": true, "3m": true, "5m": true, "15m": true, "30m": true,
+	"1h": true, "2h": true, "4h": true, "6h": true, "8h": true, "12h": true,
+	"1d": true, "3d": true, "1w": true, "1M": true,
+}
+
+// maxATRPeriod keeps the klines needed for the ATR (ATR_PERIOD + 1) within one request of Binance's 1000 limit.
+const maxATRPeriod = 999
+
+// Supported time-in-force values for limit buys.
+const (
+	TimeInForceGTC = "GTC" // Good till cancelled: rests on the book until filled or cancelled
+	TimeInForceIOC = "IOC" // Immediate or cancel: fills what it can at once, the rest expires
+	TimeInForceFOK = "FOK" // Fill or kill: fills completely at once or expires
+)
+
+// Supported order status sources.
+const (
+	OrderStatusSourceREST      = "rest"
+	OrderStatusSourceWebSocket = "websocket"
+	OrderStatusSourceBoth      = "both"
+)
+
+// Supported actions for sells that outlive SELL_ORDER_TTL_MINUTES.
+const (
+	SellOrderTTLActionReprice = "reprice"
+	SellOrderTTLActionMarket  = "market"
+)
+
+// Supported reference price sources.
+const (
+	PriceSourceLast = "last"
+	PriceSourceAvg  = "avg"
+)
+
+// Supported price rounding modes.
+const (
+	PriceRoundingNearest      = "nearest"
+	PriceRoundingConservative = "conservative"
+)
+
 // LoadConfig loads configuration from environment variables.
 func LoadConfig() (*Config, error) {
 	cfg := &Config{}
 
-	cfg.BinanceAPIKey = os.Getenv("BINANCE_API_KEY")
+	var err error
+	cfg.BinanceAPIKey, err = getEnvOrFile("BINANCE_API_KEY")
+	if err != nil {
+		return nil, err
+	}
 	if cfg.BinanceAPIKey == "" {
 		return nil, fmt.Errorf("BINANCE_API_KEY not set")
 	}
 
-	cfg.BinanceSecretKey = os.Getenv("BINANCE_SECRET_KEY") // <--- ESTE CAMPO YA SE CARGA AQUÍ
+	cfg.BinanceSecretKey, err = getEnvOrFile("BINANCE_SECRET_KEY") // <--- ESTE CAMPO YA SE CARGA AQUÍ
+	if err != nil {
+		return nil, err
+	}
 	if cfg.BinanceSecretKey == "" {
 		return nil, fmt.Errorf("BINANCE_SECRET_KEY not set")
 	}
 
 	useTestnetStr := os.Getenv("USE_TESTNET")
-	var err error
 	cfg.UseTestnet, err = strconv.ParseBool(useTestnetStr)
 	if err != nil {
 		fmt.Printf("WARNING: USE_TESTNET not set or invalid ('%s'). Defaulting to false.\n", useTestnetStr)
 		cfg.UseTestnet = false
 	}
+	cfg.BinanceBaseURL, err = parseBaseURLEnv("BINANCE_BASE_URL")
+	if err != nil {
+		return nil, err
+	}
 
-	cfg.DatabaseURL = os.Getenv("DATABASE_URL")
+	cfg.DatabaseURL, err = getEnvOrFile("DATABASE_URL")
+	if err != nil {
+		return nil, err
+	}
 	if cfg.DatabaseURL == "" {
 		return nil, fmt.Errorf("DATABASE_URL not set")
 	}
 
+	cfg.DatabaseReadURL, err = getEnvOrFile("DATABASE_READ_URL")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.DBHealthCheckSeconds, err = parseIntEnv("DB_HEALTH_CHECK_SECONDS", 30)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DBHealthCheckSeconds < 0 {
+		return nil, fmt.Errorf("DB_HEALTH_CHECK_SECONDS must be 0 (disabled) or positive, got %d", cfg.DBHealthCheckSeconds)
+	}
+
+	cfg.DBReconnectAfterFailures, err = parseIntEnv("DB_RECONNECT_AFTER_FAILURES", 3)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DBReconnectAfterFailures < 1 {
+		return nil, fmt.Errorf("DB_RECONNECT_AFTER_FAILURES must be at least 1, got %d", cfg.DBReconnectAfterFailures)
+	}
+
+	cfg.DBReconnectMaxBackoffSecs, err = parseIntEnv("DB_RECONNECT_MAX_BACKOFF_SECONDS", 60)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DBReconnectMaxBackoffSecs < 1 {
+		return nil, fmt.Errorf("DB_RECONNECT_MAX_BACKOFF_SECONDS must be at least 1, got %d", cfg.DBReconnectMaxBackoffSecs)
+	}
+
 	cfg.Symbol = os.Getenv("SYMBOL")
 	if cfg.Symbol == "" {
 		return nil, fmt.Errorf("SYMBOL not set")
 	}
+	cfg.BaseAsset = strings.ToUpper(strings.TrimSpace(os.Getenv("BASE_ASSET")))
+	cfg.QuoteAsset = strings.ToUpper(strings.TrimSpace(os.Getenv("QUOTE_ASSET")))
 
 	cfg.InitialUSDT, err = parseFloatEnv("INITIAL_USDT", 100.0)
 	if err != nil {
@@ -66,20 +232,133 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	cfg.OrderAmountPercent, err = parseFloatEnv("ORDER_AMOUNT_PERCENT", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OrderAmountPercent < 0 || cfg.OrderAmountPercent > 100 {
+		return nil, fmt.Errorf("ORDER_AMOUNT_PERCENT must be 0 (disabled) or between 0 and 100, got %f", cfg.OrderAmountPercent)
+	}
+	if os.Getenv("ORDER_AMOUNT") != "" && cfg.OrderAmountPercent > 0 {
+		return nil, fmt.Errorf("set either ORDER_AMOUNT or ORDER_AMOUNT_PERCENT, not both")
+	}
+
 	cfg.OrderIntervalMinutes, err = parseIntEnv("ORDER_INTERVAL_MINUTES", 60)
 	if err != nil {
 		return nil, err
 	}
 
+	cfg.InitialBuyOnFill, err = parseBoolEnv("INITIAL_BUY_ON_FILL", false)
+	if err != nil {
+		return nil, err
+	}
+
 	cfg.InitialBuyPercentage, err = parseFloatEnv("INITIAL_BUY_PERCENTAGE", 1.0)
 	if err != nil {
 		return nil, err
 	}
 
-	cfg.SellProfitPercentage, err = parseFloatEnv("SELL_PROFIT_PERCENTAGE", 2.0)
+	cfg.InitialTriggerDrop, err = parseFloatEnv("INITIAL_TRIGGER_DROP_PERCENTAGE", 0.0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.InitialTriggerDrop < 0 || cfg.InitialTriggerDrop >= 100 {
+		return nil, fmt.Errorf("INITIAL_TRIGGER_DROP_PERCENTAGE must be 0 (disabled) or between 0 and 100, got %f", cfg.InitialTriggerDrop)
+	}
+
+	cfg.PauseInitialOnRise, err = parseFloatEnv("PAUSE_INITIAL_ON_RISE", 0.0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PauseInitialOnRise < 0 {
+		return nil, fmt.Errorf("PAUSE_INITIAL_ON_RISE must be 0 (disabled) or positive, got %f", cfg.PauseInitialOnRise)
+	}
+
+	cfg.PauseInitialRiseWindowMins, err = parseIntEnv("PAUSE_INITIAL_RISE_WINDOW_MINUTES", 15)
 	if err != nil {
 		return nil, err
 	}
+	if cfg.PauseInitialRiseWindowMins < 1 {
+		return nil, fmt.Errorf("PAUSE_INITIAL_RISE_WINDOW_MINUTES must be at least 1, got %d", cfg.PauseInitialRiseWindowMins)
+	}
+
+	cfg.SellProfitPercentages = []float64{2.0}
+	if sellProfitStr := os.Getenv("SELL_PROFIT_PERCENTAGE"); sellProfitStr != "" {
+		parts := strings.Split(sellProfitStr, ",")
+		cfg.SellProfitPercentages = make([]float64, len(parts))
+		for i, p := range parts {
+			val, parseErr := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if parseErr != nil {
+				return nil, fmt.Errorf("invalid value in SELL_PROFIT_PERCENTAGE: '%s' is not a float: %w", p, parseErr)
+			}
+			cfg.SellProfitPercentages[i] = val
+		}
+	}
+	cfg.SellProfitPercentage = cfg.SellProfitPercentages[0]
+
+	cfg.SellTargetATRMultiple, err = parseFloatEnv("SELL_TARGET_ATR_MULTIPLE", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SellTargetATRMultiple < 0 {
+		return nil, fmt.Errorf("SELL_TARGET_ATR_MULTIPLE must be 0 (disabled) or positive, got %f", cfg.SellTargetATRMultiple)
+	}
+
+	cfg.ATRPeriod, err = parseIntEnv("ATR_PERIOD", 14)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ATRPeriod < 1 || cfg.ATRPeriod > maxATRPeriod {
+		return nil, fmt.Errorf("ATR_PERIOD must be between 1 and %d, got %d", maxATRPeriod, cfg.ATRPeriod)
+	}
+
+	cfg.ATRInterval = strings.TrimSpace(os.Getenv("ATR_INTERVAL"))
+	if cfg.ATRInterval == "" {
+		cfg.ATRInterval = "1h"
+	}
+	if !klineIntervals[cfg.ATRInterval] {
+		return nil, fmt.Errorf("invalid ATR_INTERVAL '%s': must be a Binance kline interval such as 15m, 1h or 1d", cfg.ATRInterval)
+	}
+
+	cfg.MinHoldMinutes, err = parseIntEnv("MIN_HOLD_MINUTES", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MinHoldMinutes < 0 {
+		return nil, fmt.Errorf("MIN_HOLD_MINUTES must be 0 (disabled) or positive, got %d", cfg.MinHoldMinutes)
+	}
+
+	cfg.SellRepriceAfterMinutes, err = parseIntEnv("SELL_REPRICE_AFTER_MINUTES", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SellRepriceAfterMinutes < 0 {
+		return nil, fmt.Errorf("SELL_REPRICE_AFTER_MINUTES must be 0 (disabled) or positive, got %d", cfg.SellRepriceAfterMinutes)
+	}
+
+	cfg.SellOrderTTLMinutes, err = parseIntEnv("SELL_ORDER_TTL_MINUTES", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SellOrderTTLMinutes < 0 {
+		return nil, fmt.Errorf("SELL_ORDER_TTL_MINUTES must be 0 (disabled) or positive, got %d", cfg.SellOrderTTLMinutes)
+	}
+
+	cfg.SellOrderTTLAction = strings.ToLower(os.Getenv("SELL_ORDER_TTL_ACTION"))
+	if cfg.SellOrderTTLAction == "" {
+		cfg.SellOrderTTLAction = SellOrderTTLActionReprice
+	}
+	if cfg.SellOrderTTLAction != SellOrderTTLActionReprice && cfg.SellOrderTTLAction != SellOrderTTLActionMarket {
+		return nil, fmt.Errorf("invalid SELL_ORDER_TTL_ACTION '%s': must be '%s' or '%s'", cfg.SellOrderTTLAction, SellOrderTTLActionReprice, SellOrderTTLActionMarket)
+	}
+
+	cfg.SellRepriceMinProfit, err = parseFloatEnv("SELL_REPRICE_MIN_PROFIT_PERCENTAGE", 0.2)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SellRepriceMinProfit < 0 {
+		return nil, fmt.Errorf("SELL_REPRICE_MIN_PROFIT_PERCENTAGE must be 0 or positive, got %f", cfg.SellRepriceMinProfit)
+	}
 
 	buyPercentagesStr := os.Getenv("BUY_PERCENTAGES")
 	if buyPercentagesStr != "" {
@@ -103,14 +382,514 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	cfg.MaxAdditionalBuysPerDay, err = parseIntEnv("MAX_ADDITIONAL_BUYS_PER_DAY", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxAdditionalBuysPerDay < 0 {
+		return nil, fmt.Errorf("MAX_ADDITIONAL_BUYS_PER_DAY must be 0 (disabled) or positive, got %d", cfg.MaxAdditionalBuysPerDay)
+	}
+
+	cfg.ShrinkBuyToFunds, err = parseBoolEnv("SHRINK_BUY_TO_FUNDS", false)
+	if err != nil {
+		return nil, err
+	}
+
 	cfg.TradingCycleIntervalSeconds, err = parseIntEnv("TRADING_CYCLE_INTERVAL_SECONDS", 300) //
 	if err != nil {
 		return nil, err
 	}
 
+	cfg.OrderStatusSource = strings.ToLower(os.Getenv("ORDER_STATUS_SOURCE"))
+	if cfg.OrderStatusSource == "" {
+		cfg.OrderStatusSource = OrderStatusSourceREST
+	}
+	switch cfg.OrderStatusSource {
+	case OrderStatusSourceREST, OrderStatusSourceWebSocket, OrderStatusSourceBoth:
+	default:
+		return nil, fmt.Errorf("invalid ORDER_STATUS_SOURCE '%s': must be '%s', '%s' or '%s'",
+			cfg.OrderStatusSource, OrderStatusSourceREST, OrderStatusSourceWebSocket, OrderStatusSourceBoth)
+	}
+
+	cfg.OrderPollIntervalSeconds, err = parseIntEnv("ORDER_POLL_INTERVAL_SECONDS", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OrderPollIntervalSeconds < 0 {
+		return nil, fmt.Errorf("ORDER_POLL_INTERVAL_SECONDS must be 0 (disabled) or positive, got %d", cfg.OrderPollIntervalSeconds)
+	}
+
+	cfg.InitialOrderType, err = parseOrderTypeEnv("INITIAL_ORDER_TYPE")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.AdditionalOrderType, err = parseOrderTypeEnv("ADDITIONAL_ORDER_TYPE")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.InitialBuyTIF, err = parseTimeInForceEnv("INITIAL_BUY_TIF")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.AdditionalBuyTIF, err = parseTimeInForceEnv("ADDITIONAL_BUY_TIF")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Strategy = strings.ToLower(os.Getenv("STRATEGY"))
+	if cfg.Strategy == "" {
+		cfg.Strategy = StrategyLadder
+	}
+	if cfg.Strategy != StrategyLadder && cfg.Strategy != StrategyTWAP {
+		return nil, fmt.Errorf("invalid STRATEGY '%s': must be '%s' or '%s'", cfg.Strategy, StrategyLadder, StrategyTWAP)
+	}
+
+	cfg.TWAPSlices, err = parseIntEnv("TWAP_SLICES", 10)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.TWAPSlices <= 0 {
+		return nil, fmt.Errorf("TWAP_SLICES must be greater than 0, got %d", cfg.TWAPSlices)
+	}
+
+	cfg.TWAPIntervalMinutes, err = parseIntEnv("TWAP_INTERVAL_MINUTES", cfg.OrderIntervalMinutes)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.PriceSource = strings.ToLower(os.Getenv("PRICE_SOURCE"))
+	if cfg.PriceSource == "" {
+		cfg.PriceSource = PriceSourceLast
+	}
+	if cfg.PriceSource != PriceSourceLast && cfg.PriceSource != PriceSourceAvg {
+		return nil, fmt.Errorf("invalid PRICE_SOURCE '%s': must be '%s' or '%s'", cfg.PriceSource, PriceSourceLast, PriceSourceAvg)
+	}
+
+	cfg.MaxPriceDeviation, err = parseFloatEnv("MAX_PRICE_DEVIATION_PERCENTAGE", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxPriceDeviation < 0 {
+		return nil, fmt.Errorf("MAX_PRICE_DEVIATION_PERCENTAGE must be 0 (disabled) or positive, got %f", cfg.MaxPriceDeviation)
+	}
+
+	cfg.CachedPriceMaxAgeSeconds, err = parseIntEnv("CACHED_PRICE_MAX_AGE_SECONDS", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.CachedPriceMaxAgeSeconds < 0 {
+		return nil, fmt.Errorf("CACHED_PRICE_MAX_AGE_SECONDS must be 0 (disabled) or positive, got %d", cfg.CachedPriceMaxAgeSeconds)
+	}
+
+	cfg.MaxPriceAgeSeconds, err = parseIntEnv("MAX_PRICE_AGE_SECONDS", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxPriceAgeSeconds < 0 {
+		return nil, fmt.Errorf("MAX_PRICE_AGE_SECONDS must be 0 (disabled) or positive, got %d", cfg.MaxPriceAgeSeconds)
+	}
+
+	cfg.DefaultPricePrecision, err = parseIntEnv("DEFAULT_PRICE_PRECISION", -1)
+	if err != nil {
+		return nil, err
+	}
+	cfg.DefaultQtyPrecision, err = parseIntEnv("DEFAULT_QTY_PRECISION", -1)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DefaultPricePrecision < -1 || cfg.DefaultPricePrecision > 18 || cfg.DefaultQtyPrecision < -1 || cfg.DefaultQtyPrecision > 18 {
+		return nil, fmt.Errorf("DEFAULT_PRICE_PRECISION and DEFAULT_QTY_PRECISION must be between 0 and 18, or -1 to disable")
+	}
+
+	cfg.APIErrorCooldownSeconds, err = parseIntEnv("API_ERROR_COOLDOWN_SECONDS", 60)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.APIErrorCooldownSeconds < 0 {
+		return nil, fmt.Errorf("API_ERROR_COOLDOWN_SECONDS must be 0 (disabled) or positive, got %d", cfg.APIErrorCooldownSeconds)
+	}
+
+	cfg.ValidateBeforePlacing, err = parseBoolEnv("VALIDATE_BEFORE_PLACING", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.VerifyPlacedOrders, err = parseBoolEnv("VERIFY_PLACED_ORDERS", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.RetryLotSizeRejections, err = parseBoolEnv("RETRY_LOT_SIZE_REJECTIONS", true)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.LogPriceDecimals, err = parseIntEnv("LOG_PRICE_DECIMALS", -1)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.LogPriceDecimals < -1 {
+		return nil, fmt.Errorf("LOG_PRICE_DECIMALS must be -1 (tick size) or 0 and above, got %d", cfg.LogPriceDecimals)
+	}
+
+	cfg.PriceRounding = strings.ToLower(os.Getenv("PRICE_ROUNDING"))
+	if cfg.PriceRounding == "" {
+		cfg.PriceRounding = PriceRoundingNearest
+	}
+	if cfg.PriceRounding != PriceRoundingNearest && cfg.PriceRounding != PriceRoundingConservative {
+		return nil, fmt.Errorf("invalid PRICE_ROUNDING '%s': must be '%s' or '%s'", cfg.PriceRounding, PriceRoundingNearest, PriceRoundingConservative)
+	}
+
+	cfg.StopLossPercentage, err = parseFloatEnv("STOP_LOSS_PERCENTAGE", 0.0)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.StopLossConfirmSeconds, err = parseIntEnv("STOP_LOSS_CONFIRM_SECONDS", 60)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.StopLossMaxPriceAgeSeconds, err = parseIntEnv("STOP_LOSS_MAX_PRICE_AGE_SECONDS", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.StopLossMaxPriceAgeSeconds < 0 {
+		return nil, fmt.Errorf("STOP_LOSS_MAX_PRICE_AGE_SECONDS must be 0 (disabled) or positive, got %d", cfg.StopLossMaxPriceAgeSeconds)
+	}
+
+	cfg.LiquidationMaxSlippage, err = parseFloatEnv("LIQUIDATION_MAX_SLIPPAGE_PERCENTAGE", 0.0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.LiquidationMaxSlippage < 0 || cfg.LiquidationMaxSlippage >= 100 {
+		return nil, fmt.Errorf("LIQUIDATION_MAX_SLIPPAGE_PERCENTAGE must be between 0 and 100, got %f", cfg.LiquidationMaxSlippage)
+	}
+
+	cfg.DailyPriceSnapshot, err = parseBoolEnv("DAILY_PRICE_SNAPSHOT", true)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.PriceAlertPercentage, err = parseFloatEnv("PRICE_ALERT_PERCENTAGE", 0.0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PriceAlertPercentage < 0 {
+		return nil, fmt.Errorf("PRICE_ALERT_PERCENTAGE must be 0 (disabled) or positive, got %f", cfg.PriceAlertPercentage)
+	}
+
+	cfg.PriceAlertWindowMinutes, err = parseIntEnv("PRICE_ALERT_WINDOW_MINUTES", 15)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PriceAlertWindowMinutes < 1 {
+		return nil, fmt.Errorf("PRICE_ALERT_WINDOW_MINUTES must be at least 1, got %d", cfg.PriceAlertWindowMinutes)
+	}
+
+	cfg.MaxTotalOrders, err = parseIntEnv("MAX_TOTAL_ORDERS", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxTotalOrders < 0 {
+		return nil, fmt.Errorf("MAX_TOTAL_ORDERS must be 0 (unlimited) or positive, got %d", cfg.MaxTotalOrders)
+	}
+
+	cfg.CancelOrdersOnShutdown, err = parseBoolEnv("CANCEL_ORDERS_ON_SHUTDOWN", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.MaxConsecutiveFailures, err = parseIntEnv("MAX_CONSECUTIVE_FAILURES", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxConsecutiveFailures < 0 {
+		return nil, fmt.Errorf("MAX_CONSECUTIVE_FAILURES must be 0 (disabled) or positive, got %d", cfg.MaxConsecutiveFailures)
+	}
+
+	cfg.MaxCycles, err = parseIntEnv("MAX_CYCLES", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxCycles < 0 {
+		return nil, fmt.Errorf("MAX_CYCLES must be 0 (unlimited) or positive, got %d", cfg.MaxCycles)
+	}
+
+	cfg.CycleJitterSeconds, err = parseIntEnv("CYCLE_JITTER_SECONDS", 0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.CycleJitterSeconds < 0 {
+		return nil, fmt.Errorf("CYCLE_JITTER_SECONDS must be 0 or positive, got %d", cfg.CycleJitterSeconds)
+	}
+
+	cfg.MaxSpreadPercentage, err = parseFloatEnv("MAX_SPREAD_PERCENTAGE", 0.0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxSpreadPercentage < 0 {
+		return nil, fmt.Errorf("MAX_SPREAD_PERCENTAGE must be 0 (disabled) or positive, got %f", cfg.MaxSpreadPercentage)
+	}
+
+	cfg.MinQuoteToResume, err = parseFloatEnv("MIN_QUOTE_TO_RESUME", 0.0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MinQuoteToResume < 0 {
+		return nil, fmt.Errorf("MIN_QUOTE_TO_RESUME must be 0 or positive, got %f", cfg.MinQuoteToResume)
+	}
+
+	cfg.IgnoreDust, err = parseBoolEnv("IGNORE_DUST", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.ConvertDust, err = parseBoolEnv("CONVERT_DUST", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.AutoWithdrawProfitAbove, err = parseFloatEnv("AUTO_WITHDRAW_PROFIT_ABOVE", 0.0)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AutoWithdrawProfitAbove < 0 {
+		return nil, fmt.Errorf("AUTO_WITHDRAW_PROFIT_ABOVE must be 0 (disabled) or positive, got %f", cfg.AutoWithdrawProfitAbove)
+	}
+
+	cfg.StrategyTag = strings.TrimSpace(os.Getenv("STRATEGY_TAG"))
+
+	cfg.CSVSnapshotFile = strings.TrimSpace(os.Getenv("CSV_SNAPSHOT_FILE"))
+	cfg.CSVSnapshotIntervalMinutes, err = parseIntEnv("CSV_SNAPSHOT_INTERVAL_MINUTES", 60)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.CSVSnapshotIntervalMinutes <= 0 {
+		return nil, fmt.Errorf("CSV_SNAPSHOT_INTERVAL_MINUTES must be positive, got %d", cfg.CSVSnapshotIntervalMinutes)
+	}
+
+	cfg.HTTPAddr = os.Getenv("HTTP_ADDR")
+	cfg.APIToken, err = getEnvOrFile("API_TOKEN")
+	if err != nil {
+		return nil, err
+	}
+	if cfg.HTTPAddr != "" && cfg.APIToken == "" {
+		return nil, fmt.Errorf("API_TOKEN must be set when HTTP_ADDR is enabled")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
+// Validate checks that the percentage settings are within sane ranges.
+// It returns an error for values that would make the bot trade dangerously. Profit targets
+// below the fees are warned about at startup, once the account's commission rates are known.
+func (c *Config) Validate() error {
+	if err := checkPercentageRange("INITIAL_BUY_PERCENTAGE", c.InitialBuyPercentage, minBuyPercentage, maxBuyPercentage); err != nil {
+		return err
+	}
+	for i, p := range c.SellProfitPercentages {
+		if err := checkPercentageRange(fmt.Sprintf("SELL_PROFIT_PERCENTAGE[%d]", i), p, minProfitPercentage, maxProfitPercentage); err != nil {
+			return err
+		}
+	}
+	if len(c.SellProfitPercentages) > 1 && len(c.SellProfitPercentages) != len(c.BuyPercentages) {
+		return fmt.Errorf("SELL_PROFIT_PERCENTAGE lists %d targets but BUY_PERCENTAGES has %d rungs: give one target per rung or a single value",
+			len(c.SellProfitPercentages), len(c.BuyPercentages))
+	}
+	for i, p := range c.BuyPercentages {
+		if err := checkPercentageRange(fmt.Sprintf("BUY_PERCENTAGES[%d]", i), p, minBuyPercentage, maxBuyPercentage); err != nil {
+			return err
+		}
+	}
+
+	if c.StopLossPercentage < 0 || c.StopLossPercentage >= 100 {
+		return fmt.Errorf("STOP_LOSS_PERCENTAGE (%g) out of range: must be 0 (disabled) or below 100", c.StopLossPercentage)
+	}
+	return nil
+}
+
+// SellProfitForRung returns the profit target for trades bought at BUY_PERCENTAGES[rung]. Rungs
+// without their own target, and the initial ladder (rung -1), use SellProfitPercentage.
+func (c *Config) SellProfitForRung(rung int) float64 {
+	if rung >= 0 && rung < len(c.SellProfitPercentages) {
+		return c.SellProfitPercentages[rung]
+	}
+	return c.SellProfitPercentage
+}
+
+// RoundTripFeePercentage returns the standard fees paid on a buy plus its sell, as a percentage of the
+// trade. It is only a fallback for when the account's own commission rates cannot be fetched.
+func (c *Config) RoundTripFeePercentage() float64 {
+	return 2 * defaultFeePercentage
+}
+
+// CheckReloadable returns an error if next changes a structural field that cannot be applied
+// without restarting the bot (credentials, database, symbol, capital, strategy, HTTP API).
+func (c *Config) CheckReloadable(next *Config) error {
+	switch {
+	case next.BinanceAPIKey != c.BinanceAPIKey || next.BinanceSecretKey != c.BinanceSecretKey:
+		return fmt.Errorf("binance credentials cannot be changed without a restart")
+	case next.UseTestnet != c.UseTestnet || next.BinanceBaseURL != c.BinanceBaseURL:
+		return fmt.Errorf("USE_TESTNET and BINANCE_BASE_URL cannot be changed without a restart")
+	case next.DatabaseURL != c.DatabaseURL || next.DatabaseReadURL != c.DatabaseReadURL:
+		return fmt.Errorf("DATABASE_URL and DATABASE_READ_URL cannot be changed without a restart")
+	case next.DBHealthCheckSeconds != c.DBHealthCheckSeconds || next.DBReconnectAfterFailures != c.DBReconnectAfterFailures ||
+		next.DBReconnectMaxBackoffSecs != c.DBReconnectMaxBackoffSecs:
+		return fmt.Errorf("database reconnect settings cannot be changed without a restart")
+	case next.Symbol != c.Symbol || next.BaseAsset != c.BaseAsset || next.QuoteAsset != c.QuoteAsset:
+		return fmt.Errorf("SYMBOL, BASE_ASSET and QUOTE_ASSET cannot be changed without a restart")
+	case next.InitialUSDT != c.InitialUSDT:
+		return fmt.Errorf("INITIAL_USDT cannot be changed without a restart")
+	case next.Strategy != c.Strategy:
+		return fmt.Errorf("STRATEGY cannot be changed without a restart")
+	case next.StrategyTag != c.StrategyTag:
+		return fmt.Errorf("STRATEGY_TAG cannot be changed without a restart")
+	case next.OrderPollIntervalSeconds != c.OrderPollIntervalSeconds:
+		return fmt.Errorf("ORDER_POLL_INTERVAL_SECONDS cannot be changed without a restart")
+	case next.OrderStatusSource != c.OrderStatusSource:
+		return fmt.Errorf("ORDER_STATUS_SOURCE cannot be changed without a restart")
+	case next.MaxPriceDeviation != c.MaxPriceDeviation:
+		return fmt.Errorf("MAX_PRICE_DEVIATION_PERCENTAGE cannot be changed without a restart")
+	case next.DefaultPricePrecision != c.DefaultPricePrecision || next.DefaultQtyPrecision != c.DefaultQtyPrecision:
+		return fmt.Errorf("DEFAULT_PRICE_PRECISION and DEFAULT_QTY_PRECISION cannot be changed without a restart")
+	case next.APIErrorCooldownSeconds != c.APIErrorCooldownSeconds:
+		return fmt.Errorf("API_ERROR_COOLDOWN_SECONDS cannot be changed without a restart")
+	case next.ValidateBeforePlacing != c.ValidateBeforePlacing:
+		return fmt.Errorf("VALIDATE_BEFORE_PLACING cannot be changed without a restart")
+	case next.RetryLotSizeRejections != c.RetryLotSizeRejections:
+		return fmt.Errorf("RETRY_LOT_SIZE_REJECTIONS cannot be changed without a restart")
+	case next.PriceRounding != c.PriceRounding:
+		return fmt.Errorf("PRICE_ROUNDING cannot be changed without a restart")
+	case next.CSVSnapshotFile != c.CSVSnapshotFile || next.CSVSnapshotIntervalMinutes != c.CSVSnapshotIntervalMinutes:
+		return fmt.Errorf("CSV_SNAPSHOT_FILE and CSV_SNAPSHOT_INTERVAL_MINUTES cannot be changed without a restart")
+	case next.HTTPAddr != c.HTTPAddr || next.APIToken != c.APIToken:
+		return fmt.Errorf("HTTP API settings cannot be changed without a restart")
+	}
+	return nil
+}
+
+// Redacted returns a copy of the configuration with secrets masked, safe for printing or logging.
+func (c *Config) Redacted() Config {
+	redacted := *c
+	redacted.BinanceAPIKey = maskSecret(c.BinanceAPIKey)
+	redacted.BinanceSecretKey = maskSecret(c.BinanceSecretKey)
+	redacted.APIToken = maskSecret(c.APIToken)
+	redacted.DatabaseURL = redactDSN(c.DatabaseURL)
+	redacted.DatabaseReadURL = redactDSN(c.DatabaseReadURL)
+	redacted.BuyPercentages = make([]float64, len(c.BuyPercentages))
+	copy(redacted.BuyPercentages, c.BuyPercentages)
+	redacted.SellProfitPercentages = make([]float64, len(c.SellProfitPercentages))
+	copy(redacted.SellProfitPercentages, c.SellProfitPercentages)
+	return redacted
+}
+
+// dsnPasswordPattern matches the password of a lib/pq key=value connection string (quoted or not), and a
+// password given as a query parameter of a URL.
+var dsnPasswordPattern = regexp.MustCompile(`(password=)('(?:[^'\\]|\\.)*'|[^\s&]*)`)
+
+// redactDSN helper function to mask the password of a database connection string while keeping host and
+// database visible. URLs with user info go through url.URL.Redacted; anything else, such as a lib/pq
+// key=value string (which url.Parse accepts without a scheme or user), has its password= value masked.
+// A string that is not a valid URL at all is masked like any other secret.
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return maskSecret(dsn)
+	}
+	if u.Scheme != "" && u.User != nil {
+		dsn = u.Redacted()
+	}
+	return dsnPasswordPattern.ReplaceAllString(dsn, "${1}xxxxx")
+}
+
+// maskSecret helper function to hide all but the last 4 characters of a secret.
+func maskSecret(secret string) string {
+	if len(secret) <= 4 {
+		return strings.Repeat("*", len(secret))
+	}
+	return strings.Repeat("*", len(secret)-4) + secret[len(secret)-4:]
+}
+
+// Allowed ranges for the percentage settings.
+const (
+	minBuyPercentage     = 0.01
+	maxBuyPercentage     = 50.0
+	minProfitPercentage  = 0.01
+	maxProfitPercentage  = 100.0
+	defaultFeePercentage = 0.1 // Binance spot taker/maker fee without discounts
+)
+
+// checkPercentageRange helper function to validate that a percentage lies within [min, max].
+func checkPercentageRange(key string, value, lo, hi float64) error {
+	if value < lo || value > hi {
+		return fmt.Errorf("%s (%g) out of range: must be between %g and %g", key, value, lo, hi)
+	}
+	return nil
+}
+
+// getEnvOrFile helper function to read a secret either from the file named by <key>_FILE
+// (Docker/Kubernetes secrets) or, if that is not set, from the <key> variable itself.
+func getEnvOrFile(key string) (string, error) {
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return os.Getenv(key), nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s_FILE ('%s'): %w", key, path, err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// parseOrderTypeEnv helper function to parse an order type environment variable, defaulting to limit.
+func parseOrderTypeEnv(key string) (string, error) {
+	val := strings.ToLower(os.Getenv(key))
+	if val == "" {
+		return OrderTypeLimit, nil
+	}
+	if val != OrderTypeLimit && val != OrderTypeMarket {
+		return "", fmt.Errorf("invalid %s '%s': must be '%s' or '%s'", key, val, OrderTypeLimit, OrderTypeMarket)
+	}
+	return val, nil
+}
+
+// parseBaseURLEnv helper function to parse an optional http(s) base URL environment variable. A trailing
+// slash is dropped because request paths are appended to the URL as is.
+func parseBaseURLEnv(key string) (string, error) {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return "", nil
+	}
+	u, err := url.Parse(val)
+	if err != nil {
+		return "", fmt.Errorf("environment variable %s ('%s') is not a valid URL: %w", key, val, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("environment variable %s ('%s') must be an absolute http or https URL", key, val)
+	}
+	return strings.TrimRight(val, "/"), nil
+}
+
+// parseTimeInForceEnv helper function to parse a time-in-force environment variable, defaulting to GTC.
+func parseTimeInForceEnv(key string) (string, error) {
+	val := strings.ToUpper(strings.TrimSpace(os.Getenv(key)))
+	switch val {
+	case "":
+		return TimeInForceGTC, nil
+	case TimeInForceGTC, TimeInForceIOC, TimeInForceFOK:
+		return val, nil
+	}
+	return "", fmt.Errorf("invalid %s '%s': must be '%s', '%s' or '%s'", key, val, TimeInForceGTC, TimeInForceIOC, TimeInForceFOK)
+}
+
 // parseIntEnv helper function to parse an integer environment variable with a default.
 func parseIntEnv(key string, defaultValue int) (int, error) {
 	valStr := os.Getenv(key)
@@ -124,6 +903,19 @@ func parseIntEnv(key string, defaultValue int) (int, error) {
 	return val, nil
 }
 
+// parseBoolEnv helper function to parse a boolean environment variable with a default.
+func parseBoolEnv(key string, defaultValue bool) (bool, error) {
+	valStr := os.Getenv(key)
+	if valStr == "" {
+		return defaultValue, nil
+	}
+	val, err := strconv.ParseBool(valStr)
+	if err != nil {
+		return false, fmt.Errorf("environment variable %s ('%s') is not a valid boolean: %w", key, valStr, err)
+	}
+	return val, nil
+}
+
 // parseFloatEnv helper function to parse a float environment variable with a default.
 func parseFloatEnv(key string, defaultValue float64) (float64, error) {
 	valStr := os.Getenv(key)
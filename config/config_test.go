@@ -0,0 +1,317 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validConfig returns a configuration that passes Validate.
+func validConfig() *Config {
+	return &Config{
+		InitialBuyPercentage:  1,
+		SellProfitPercentage:  2,
+		SellProfitPercentages: []float64{2},
+		BuyPercentages:        []float64{1, 2, 3},
+	}
+}
+
+func TestValidatePercentageRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string // Empty when the config is valid
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"buy discount at minimum", func(c *Config) { c.InitialBuyPercentage = 0.01 }, ""},
+		{"buy discount at maximum", func(c *Config) { c.InitialBuyPercentage = 50 }, ""},
+		{"buy discount zero", func(c *Config) { c.InitialBuyPercentage = 0 }, "INITIAL_BUY_PERCENTAGE"},
+		{"buy discount below minimum", func(c *Config) { c.InitialBuyPercentage = 0.009 }, "INITIAL_BUY_PERCENTAGE"},
+		{"buy discount absurd", func(c *Config) { c.InitialBuyPercentage = 99 }, "INITIAL_BUY_PERCENTAGE"},
+		{"ladder rung out of range", func(c *Config) { c.BuyPercentages = []float64{1, 50.5} }, "BUY_PERCENTAGES[1]"},
+		{"profit at minimum", func(c *Config) { c.SellProfitPercentages = []float64{0.01} }, ""},
+		{"profit at maximum", func(c *Config) { c.SellProfitPercentages = []float64{100} }, ""},
+		{"profit zero", func(c *Config) { c.SellProfitPercentages = []float64{0} }, "SELL_PROFIT_PERCENTAGE[0]"},
+		{"profit above maximum", func(c *Config) { c.SellProfitPercentages = []float64{100.01} }, "SELL_PROFIT_PERCENTAGE[0]"},
+		{"stop-loss disabled", func(c *Config) { c.StopLossPercentage = 0 }, ""},
+		{"stop-loss just below 100", func(c *Config) { c.StopLossPercentage = 99.99 }, ""},
+		{"stop-loss at 100", func(c *Config) { c.StopLossPercentage = 100 }, "STOP_LOSS_PERCENTAGE"},
+		{"stop-loss negative", func(c *Config) { c.StopLossPercentage = -1 }, "STOP_LOSS_PERCENTAGE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.modify(c)
+			err := c.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate error = %v, want one naming %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRedactedMasksSecrets(t *testing.T) {
+	c := validConfig()
+	c.BinanceAPIKey = "api-key-abcdef123456"
+	c.BinanceSecretKey = "super-secret-value-9876"
+	c.APIToken = "http-token-4321"
+	c.DatabaseURL = "postgres://bot:dbpassword@db:5432/trader?sslmode=disable"
+
+	redacted := c.Redacted()
+	out, err := json.Marshal(redacted)
+	if err != nil {
+		t.Fatalf("failed to encode redacted config: %v", err)
+	}
+	for _, secret := range []string{"super-secret-value", "api-key-abcdef", "http-token", "dbpassword"} {
+		if strings.Contains(string(out), secret) {
+			t.Errorf("redacted config leaks %q: %s", secret, out)
+		}
+	}
+	if redacted.BinanceSecretKey != "*******************9876" {
+		t.Errorf("BinanceSecretKey = %q, want all but the last 4 characters masked", redacted.BinanceSecretKey)
+	}
+	if !strings.Contains(redacted.DatabaseURL, "db:5432/trader") {
+		t.Errorf("DatabaseURL = %q, want host and database kept", redacted.DatabaseURL)
+	}
+
+	// The original keeps its secrets, and its slices are not shared
+	if c.BinanceSecretKey != "super-secret-value-9876" {
+		t.Errorf("Redacted modified the original secret: %q", c.BinanceSecretKey)
+	}
+	redacted.BuyPercentages[0] = 42
+	if c.BuyPercentages[0] == 42 {
+		t.Error("Redacted shares BuyPercentages with the original")
+	}
+}
+
+func TestRedactDSN(t *testing.T) {
+	tests := []struct {
+		name   string
+		dsn    string
+		secret string
+		keep   string
+	}{
+		{"url", "postgres://bot:dbpassword@db:5432/trader?sslmode=disable", "dbpassword", "db:5432/trader"},
+		{"key=value", "host=db user=bot password=dbpassword dbname=trader", "dbpassword", "host=db user=bot password=xxxxx dbname=trader"},
+		{"quoted key=value", `host=db password='hunter 2\'s' dbname=trader`, "hunter", "host=db password=xxxxx dbname=trader"},
+		{"url with query password", "postgres://db/trader?user=bot&password=dbpassword&sslmode=disable", "dbpassword", "sslmode=disable"},
+		{"no password", "host=db dbname=trader", "", "host=db dbname=trader"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redactDSN(tt.dsn)
+			if tt.secret != "" && strings.Contains(got, tt.secret) {
+				t.Errorf("redactDSN(%q) = %q, leaks the password", tt.dsn, got)
+			}
+			if !strings.Contains(got, tt.keep) {
+				t.Errorf("redactDSN(%q) = %q, want it to keep %q", tt.dsn, got, tt.keep)
+			}
+		})
+	}
+
+	c := validConfig()
+	c.DatabaseURL = "host=db user=bot password=primarysecret dbname=trader"
+	c.DatabaseReadURL = "host=replica user=bot password=replicasecret dbname=trader"
+	redacted := c.Redacted()
+	if strings.Contains(redacted.DatabaseURL, "primarysecret") || strings.Contains(redacted.DatabaseReadURL, "replicasecret") {
+		t.Errorf("Redacted leaks a key=value DSN password: %q, %q", redacted.DatabaseURL, redacted.DatabaseReadURL)
+	}
+}
+
+// writeSecret writes content to a file in a temporary directory and returns its path.
+func writeSecret(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write secret file: %v", err)
+	}
+	return path
+}
+
+func TestGetEnvOrFile(t *testing.T) {
+	t.Run("file preferred over inline value", func(t *testing.T) {
+		t.Setenv("TEST_SECRET", "inline")
+		t.Setenv("TEST_SECRET_FILE", writeSecret(t, "from-file\n"))
+		got, err := getEnvOrFile("TEST_SECRET")
+		if err != nil || got != "from-file" {
+			t.Errorf("getEnvOrFile = %q, %v, want the trimmed file content", got, err)
+		}
+	})
+	t.Run("inline value without file", func(t *testing.T) {
+		t.Setenv("TEST_SECRET", "inline")
+		got, err := getEnvOrFile("TEST_SECRET")
+		if err != nil || got != "inline" {
+			t.Errorf("getEnvOrFile = %q, %v, want the inline value", got, err)
+		}
+	})
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("TEST_SECRET", "inline")
+		t.Setenv("TEST_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+		if _, err := getEnvOrFile("TEST_SECRET"); err == nil {
+			t.Error("getEnvOrFile returned no error for a missing file")
+		}
+	})
+}
+
+func TestLoadConfigReadsSecretFiles(t *testing.T) {
+	t.Setenv("BINANCE_API_KEY", "")
+	t.Setenv("BINANCE_API_KEY_FILE", writeSecret(t, "file-api-key"))
+	t.Setenv("BINANCE_SECRET_KEY_FILE", writeSecret(t, "file-secret-key"))
+	t.Setenv("DATABASE_URL", "postgres://inline@db/trader")
+	t.Setenv("DATABASE_URL_FILE", writeSecret(t, "postgres://file@db/trader"))
+	t.Setenv("SYMBOL", "BTCUSDT")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if cfg.BinanceAPIKey != "file-api-key" || cfg.BinanceSecretKey != "file-secret-key" || cfg.DatabaseURL != "postgres://file@db/trader" {
+		t.Errorf("secrets = %q, %q, %q, want the values from the files", cfg.BinanceAPIKey, cfg.BinanceSecretKey, cfg.DatabaseURL)
+	}
+}
+
+func TestSellProfitForRung(t *testing.T) {
+	c := validConfig()
+	c.SellProfitPercentages = []float64{1.5, 2.5, 4}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+	for rung, want := range map[int]float64{-1: 2, 0: 1.5, 1: 2.5, 2: 4, 3: 2} {
+		if got := c.SellProfitForRung(rung); got != want {
+			t.Errorf("SellProfitForRung(%d) = %v, want %v", rung, got, want)
+		}
+	}
+
+	c.SellProfitPercentages = []float64{1.5, 2.5}
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "one target per rung") {
+		t.Errorf("Validate error = %v, want a mismatch between targets and rungs", err)
+	}
+}
+
+func TestLoadConfigSellProfitList(t *testing.T) {
+	t.Setenv("BINANCE_API_KEY", "key")
+	t.Setenv("BINANCE_SECRET_KEY", "secret")
+	t.Setenv("DATABASE_URL", "postgres://db/trader")
+	t.Setenv("SYMBOL", "BTCUSDT")
+	t.Setenv("BUY_PERCENTAGES", "1,2,3")
+	t.Setenv("SELL_PROFIT_PERCENTAGE", "1.5, 2.5, 4")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if len(cfg.SellProfitPercentages) != 3 || cfg.SellProfitPercentages[2] != 4 || cfg.SellProfitPercentage != 1.5 {
+		t.Errorf("targets = %v (default %v), want [1.5 2.5 4] with the first as default", cfg.SellProfitPercentages, cfg.SellProfitPercentage)
+	}
+}
+
+func TestLoadConfigOrderAmountPercent(t *testing.T) {
+	tests := []struct {
+		name        string
+		orderAmount string
+		percent     string
+		wantErr     string
+	}{
+		{"percentage only", "", "5", ""},
+		{"both set", "20", "5", "not both"},
+		{"above 100", "", "150", "between 0 and 100"},
+		{"negative", "", "-1", "between 0 and 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BINANCE_API_KEY", "key")
+			t.Setenv("BINANCE_SECRET_KEY", "secret")
+			t.Setenv("DATABASE_URL", "postgres://db/trader")
+			t.Setenv("SYMBOL", "BTCUSDT")
+			t.Setenv("ORDER_AMOUNT", tt.orderAmount)
+			t.Setenv("ORDER_AMOUNT_PERCENT", tt.percent)
+
+			cfg, err := LoadConfig()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadConfig error = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig returned error: %v", err)
+			}
+			if cfg.OrderAmountPercent != 5 {
+				t.Errorf("OrderAmountPercent = %v, want 5", cfg.OrderAmountPercent)
+			}
+		})
+	}
+}
+
+func TestParseTimeInForceEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", TimeInForceGTC, false},
+		{"ioc", TimeInForceIOC, false},
+		{" FOK ", TimeInForceFOK, false},
+		{"GTX", "", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("INITIAL_BUY_TIF", tt.value)
+		got, err := parseTimeInForceEnv("INITIAL_BUY_TIF")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseTimeInForceEnv(%q) = %q, %v, want %q (error: %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseBaseURLEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"https://api1.binance.com", "https://api1.binance.com", false},
+		{" http://localhost:8080/ ", "http://localhost:8080", false},
+		{"api.binance.com", "", true},
+		{"ftp://api.binance.com", "", true},
+		{"https://", "", true},
+		{"http://[::1", "", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("BINANCE_BASE_URL", tt.value)
+		got, err := parseBaseURLEnv("BINANCE_BASE_URL")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseBaseURLEnv(%q) = %q, %v, want %q (error: %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadConfigPriceDeviationOptIn(t *testing.T) {
+	t.Setenv("BINANCE_API_KEY", "key")
+	t.Setenv("BINANCE_SECRET_KEY", "secret")
+	t.Setenv("DATABASE_URL", "postgres://db/trader")
+	t.Setenv("SYMBOL", "BTCUSDT")
+
+	// The check costs a price request per order, so it stays off unless asked for
+	t.Setenv("MAX_PRICE_DEVIATION_PERCENTAGE", "")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if cfg.MaxPriceDeviation != 0 {
+		t.Errorf("default MaxPriceDeviation = %v, want 0 (disabled)", cfg.MaxPriceDeviation)
+	}
+
+	t.Setenv("MAX_PRICE_DEVIATION_PERCENTAGE", "1.5")
+	if cfg, err = LoadConfig(); err != nil || cfg.MaxPriceDeviation != 1.5 {
+		t.Errorf("MaxPriceDeviation = %v (%v), want 1.5", cfg.MaxPriceDeviation, err)
+	}
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"binance-trader-bot/utils"
+)
+
+// pingTimeout bounds each health check and reconnect attempt so a hung server is treated as down.
+const pingTimeout = 5 * time.Second
+
+// HealthMonitor pings the database on an interval and, after a run of consecutive failures,
+// reopens the connection pool with exponential backoff. This lets the bot recover on its own
+// after Postgres restarts, instead of erroring every cycle on connections that no longer work.
+type HealthMonitor struct {
+	name           string // Which database is monitored, for the logs
+	dataSourceName string
+	interval       time.Duration
+	maxFailures    int           // Consecutive failed pings that trigger a reconnect
+	maxBackoff     time.Duration // Upper bound of the wait between reconnect attempts
+	current        func() *sql.DB
+	replace        func(*sql.DB) *sql.DB // Swaps in the new pool and returns the old one
+	open           func(ctx context.Context, dataSourceName string) (*sql.DB, error)
+	logger         *utils.Logger
+}
+
+// NewHealthMonitor creates a HealthMonitor for the database called name in its logs. current returns
+// the pool in use and replace swaps in a reopened one, returning the previous pool so the monitor can
+// close it.
+func NewHealthMonitor(name, dataSourceName string, interval time.Duration, maxFailures int, maxBackoff time.Duration,
+	current func() *sql.DB, replace func(*sql.DB) *sql.DB, logger *utils.Logger) *HealthMonitor {
+	return &HealthMonitor{
+		name:           name,
+		dataSourceName: dataSourceName,
+		interval:       interval,
+		maxFailures:    maxFailures,
+		maxBackoff:     maxBackoff,
+		current:        current,
+		replace:        replace,
+		open:           openPostgresDB,
+		logger:         logger,
+	}
+}
+
+// Run checks the database until ctx is cancelled.
+func (m *HealthMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := ping(ctx, m.current()); err != nil {
+			failures++
+			m.logger.Warnf("Database (%s) health check failed (%d/%d): %v", m.name, failures, m.maxFailures, err)
+			if failures < m.maxFailures {
+				continue
+			}
+			if !m.reconnect(ctx) {
+				return // Cancelled while reconnecting
+			}
+		} else if failures > 0 {
+			m.logger.Infof("Database (%s) health check recovered after %d failed attempts.", m.name, failures)
+		}
+		failures = 0
+	}
+}
+
+// reconnect opens a new pool, retrying with exponential backoff until it answers a ping, and swaps
+// it in. It returns false if ctx was cancelled first.
+func (m *HealthMonitor) reconnect(ctx context.Context) bool {
+	backoff := min(time.Second, m.maxBackoff)
+	for attempt := 1; ; attempt++ {
+		db, err := m.open(ctx, m.dataSourceName)
+		if err == nil {
+			m.replace(db).Close()
+			m.logger.Infof("Database (%s) connection pool reopened after %d attempt(s).", m.name, attempt)
+			return true
+		}
+		m.logger.Errorf("Database (%s) reconnect attempt %d failed, retrying in %s: %v", m.name, attempt, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > m.maxBackoff {
+			backoff = m.maxBackoff
+		}
+	}
+}
+
+// openPostgresDB opens a new connection pool and verifies it with a single ping.
+func openPostgresDB(ctx context.Context, dataSourceName string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database connection: %w", err)
+	}
+	if err := ping(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func ping(ctx context.Context, db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	return db.PingContext(ctx)
+}
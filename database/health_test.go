@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"binance-trader-bot/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func newPingMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, mock
+}
+
+func TestHealthMonitorReconnects(t *testing.T) {
+	// The pool loses its connection: every ping fails until it is replaced
+	oldDB, oldMock := newPingMock(t)
+	for i := 0; i < 2; i++ {
+		oldMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	}
+	oldMock.ExpectClose()
+	newDB, _ := newPingMock(t)
+
+	var mu sync.Mutex
+	current := oldDB
+	replaced := make(chan struct{})
+	monitor := NewHealthMonitor("primary", "postgres://test", 5*time.Millisecond, 2, 5*time.Millisecond,
+		func() *sql.DB {
+			mu.Lock()
+			defer mu.Unlock()
+			return current
+		},
+		func(db *sql.DB) *sql.DB {
+			mu.Lock()
+			defer mu.Unlock()
+			previous := current
+			current = db
+			close(replaced)
+			return previous
+		},
+		utils.NewLogger())
+
+	// Postgres is still down on the first reopen attempt and back on the second
+	opens := 0
+	monitor.open = func(ctx context.Context, dataSourceName string) (*sql.DB, error) {
+		opens++
+		if opens == 1 {
+			return nil, errors.New("connection refused")
+		}
+		return newDB, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		monitor.Run(ctx)
+	}()
+
+	select {
+	case <-replaced:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the pool was not reopened after repeated ping failures")
+	}
+	cancel()
+	<-done
+
+	if opens != 2 {
+		t.Errorf("opened %d pools, want a retry after the failed attempt", opens)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if current != newDB {
+		t.Error("the monitor did not swap in the reopened pool")
+	}
+	if err := oldMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("old pool: %v", err)
+	}
+}
+
+func TestHealthMonitorToleratesIsolatedFailures(t *testing.T) {
+	db, mock := newPingMock(t)
+	mock.ExpectPing().WillReturnError(errors.New("timeout"))
+	mock.ExpectPing()
+	mock.ExpectPing().WillReturnError(errors.New("timeout"))
+	mock.ExpectPing()
+
+	monitor := NewHealthMonitor("primary", "postgres://test", 5*time.Millisecond, 2, 5*time.Millisecond,
+		func() *sql.DB { return db },
+		func(*sql.DB) *sql.DB {
+			t.Error("the pool was reopened although no two pings in a row failed")
+			return db
+		},
+		utils.NewLogger())
+	monitor.open = func(ctx context.Context, dataSourceName string) (*sql.DB, error) {
+		return nil, errors.New("unexpected reopen")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Millisecond)
+	defer cancel()
+	monitor.Run(ctx)
+}
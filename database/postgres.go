@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -83,6 +84,47 @@ func RunMigrations(dataSourceName string) error {
 	return nil
 }
 
+// MigrationState is the schema version recorded by golang-migrate.
+type MigrationState struct {
+	Version uint `json:"version"` // Last applied migration (0 when none has been applied)
+	Dirty   bool `json:"dirty"`   // A migration failed half-way and the schema needs manual repair
+}
+
+// MigrationStatus reports the migration version applied to the database, without applying anything.
+func MigrationStatus(dataSourceName string) (*MigrationState, error) {
+	m, err := migrate.New("file://./migrations", dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return &MigrationState{}, nil // Fresh database
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return &MigrationState{Version: version, Dirty: dirty}, nil
+}
+
+// RollbackMigrations reverts the last steps applied migrations by running their down files.
+func RollbackMigrations(dataSourceName string, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("number of migrations to roll back must be positive, got %d", steps)
+	}
+	m, err := migrate.New("file://./migrations", dataSourceName)
+	if err != nil {
+		return fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	defer m.Close()
+
+	if err := m.Steps(-steps); err != nil {
+		return fmt.Errorf("failed to roll back %d migrations: %w", steps, err)
+	}
+	return nil
+}
+
 // --- SQL MIGRATION FILES (example content) ---
 // You will need to create these files manually in your 'migrations' directory:
 
@@ -168,3 +210,218 @@ ON CONFLICT (id) DO NOTHING;
 /*
 DROP TABLE IF EXISTS bot_states;
 */
+
+// migrations/000004_add_last_initial_buy_order_id.up.sql
+/*
+ALTER TABLE bot_states ADD COLUMN IF NOT EXISTS last_initial_buy_order_id BIGINT;
+*/
+
+// migrations/000004_add_last_initial_buy_order_id.down.sql
+/*
+ALTER TABLE bot_states DROP COLUMN IF EXISTS last_initial_buy_order_id;
+*/
+
+// migrations/000005_add_twap_slices_placed_count.up.sql
+/*
+ALTER TABLE bot_states ADD COLUMN IF NOT EXISTS twap_slices_placed_count INT NOT NULL DEFAULT 0;
+*/
+
+// migrations/000005_add_twap_slices_placed_count.down.sql
+/*
+ALTER TABLE bot_states DROP COLUMN IF EXISTS twap_slices_placed_count;
+*/
+
+// migrations/000006_create_orders_archive_table.up.sql
+/*
+CREATE TABLE IF NOT EXISTS orders_archive (
+    id BIGINT PRIMARY KEY,
+    binance_id BIGINT UNIQUE NOT NULL,
+    symbol VARCHAR(50) NOT NULL,
+    type VARCHAR(10) NOT NULL,
+    price NUMERIC(20, 10) NOT NULL,
+    quantity NUMERIC(20, 10) NOT NULL,
+    quote_qty NUMERIC(20, 10) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    is_test BOOLEAN NOT NULL DEFAULT FALSE,
+    placed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    executed_at TIMESTAMP WITH TIME ZONE,
+    last_updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_archive_placed_at ON orders_archive (placed_at);
+*/
+
+// migrations/000006_create_orders_archive_table.down.sql
+/*
+DROP TABLE IF EXISTS orders_archive;
+*/
+
+// migrations/000007_add_bot_state_initialized.up.sql
+/*
+ALTER TABLE bot_states ADD COLUMN IF NOT EXISTS initialized BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- States that already hold an investment were configured by a previous run.
+UPDATE bot_states SET initialized = TRUE WHERE initial_usdt_investment > 0;
+*/
+
+// migrations/000007_add_bot_state_initialized.down.sql
+/*
+ALTER TABLE bot_states DROP COLUMN IF EXISTS initialized;
+*/
+
+// migrations/000008_add_total_usdt_withdrawn.up.sql
+/*
+ALTER TABLE bot_states ADD COLUMN IF NOT EXISTS total_usdt_withdrawn NUMERIC(20, 10) NOT NULL DEFAULT 0;
+*/
+
+// migrations/000008_add_total_usdt_withdrawn.down.sql
+/*
+ALTER TABLE bot_states DROP COLUMN IF EXISTS total_usdt_withdrawn;
+*/
+
+// migrations/000009_add_reserved_usdt.up.sql
+/*
+ALTER TABLE bot_states ADD COLUMN IF NOT EXISTS reserved_usdt NUMERIC(20, 10) NOT NULL DEFAULT 0;
+*/
+
+// migrations/000009_add_reserved_usdt.down.sql
+/*
+ALTER TABLE bot_states DROP COLUMN IF EXISTS reserved_usdt;
+*/
+
+// migrations/000010_add_trade_error_reason.up.sql
+/*
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS error_reason TEXT;
+*/
+
+// migrations/000010_add_trade_error_reason.down.sql
+/*
+ALTER TABLE trades DROP COLUMN IF EXISTS error_reason;
+*/
+
+// migrations/000011_add_trade_reprice_count.up.sql
+/*
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS reprice_count INT NOT NULL DEFAULT 0;
+*/
+
+// migrations/000011_add_trade_reprice_count.down.sql
+/*
+ALTER TABLE trades DROP COLUMN IF EXISTS reprice_count;
+*/
+
+// migrations/000012_add_open_position_cost_basis.up.sql
+/*
+ALTER TABLE bot_states ADD COLUMN IF NOT EXISTS open_position_quantity NUMERIC(20, 10) NOT NULL DEFAULT 0;
+ALTER TABLE bot_states ADD COLUMN IF NOT EXISTS open_position_cost_basis NUMERIC(20, 10) NOT NULL DEFAULT 0;
+*/
+
+// migrations/000012_add_open_position_cost_basis.down.sql
+/*
+ALTER TABLE bot_states DROP COLUMN IF EXISTS open_position_cost_basis;
+ALTER TABLE bot_states DROP COLUMN IF EXISTS open_position_quantity;
+*/
+
+// migrations/000013_add_funds_depleted.up.sql
+/*
+ALTER TABLE bot_states ADD COLUMN IF NOT EXISTS funds_depleted BOOLEAN NOT NULL DEFAULT FALSE;
+*/
+
+// migrations/000013_add_funds_depleted.down.sql
+/*
+ALTER TABLE bot_states DROP COLUMN IF EXISTS funds_depleted;
+*/
+
+// migrations/000014_add_trade_strategy_tag.up.sql
+/*
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS strategy_tag TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_trades_strategy_tag_status ON trades (strategy_tag, status);
+*/
+
+// migrations/000014_add_trade_strategy_tag.down.sql
+/*
+DROP INDEX IF EXISTS idx_trades_strategy_tag_status;
+ALTER TABLE trades DROP COLUMN IF EXISTS strategy_tag;
+*/
+
+// migrations/000015_create_price_snapshots_table.up.sql
+/*
+CREATE TABLE IF NOT EXISTS price_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    symbol VARCHAR(50) NOT NULL,
+    snapshot_date DATE NOT NULL,
+    price NUMERIC(20, 10) NOT NULL,
+    taken_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_price_snapshots_symbol_date UNIQUE (symbol, snapshot_date)
+);
+*/
+
+// migrations/000015_create_price_snapshots_table.down.sql
+/*
+DROP TABLE IF EXISTS price_snapshots;
+*/
+
+// migrations/000016_add_sell_profit_percentage.up.sql
+/*
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS sell_profit_percentage NUMERIC(20, 10) NOT NULL DEFAULT 0;
+ALTER TABLE orders_archive ADD COLUMN IF NOT EXISTS sell_profit_percentage NUMERIC(20, 10) NOT NULL DEFAULT 0;
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS sell_profit_percentage NUMERIC(20, 10) NOT NULL DEFAULT 0;
+*/
+
+// migrations/000016_add_sell_profit_percentage.down.sql
+/*
+ALTER TABLE trades DROP COLUMN IF EXISTS sell_profit_percentage;
+ALTER TABLE orders_archive DROP COLUMN IF EXISTS sell_profit_percentage;
+ALTER TABLE orders DROP COLUMN IF EXISTS sell_profit_percentage;
+*/
+
+// migrations/000017_create_events_table.up.sql
+/*
+CREATE TABLE IF NOT EXISTS events (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(50) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_created_at ON events (created_at);
+*/
+
+// migrations/000017_create_events_table.down.sql
+/*
+DROP TABLE IF EXISTS events;
+*/
+
+// migrations/000018_add_paused.up.sql
+/*
+ALTER TABLE bot_states ADD COLUMN IF NOT EXISTS paused BOOLEAN NOT NULL DEFAULT FALSE;
+*/
+
+// migrations/000018_add_paused.down.sql
+/*
+ALTER TABLE bot_states DROP COLUMN IF EXISTS paused;
+*/
+
+// migrations/000019_add_trade_roi_percent.up.sql
+/*
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS roi_percent NUMERIC(20, 10);
+
+-- Backfill trades sold before the column existed
+UPDATE trades SET roi_percent = profit_usdt / (buy_price * buy_quantity) * 100
+WHERE status = 'SOLD' AND profit_usdt IS NOT NULL AND buy_price * buy_quantity > 0;
+*/
+
+// migrations/000019_add_trade_roi_percent.down.sql
+/*
+ALTER TABLE trades DROP COLUMN IF EXISTS roi_percent;
+*/
+
+// migrations/000020_add_total_orders_placed.up.sql
+/*
+ALTER TABLE bot_states ADD COLUMN IF NOT EXISTS total_orders_placed INTEGER NOT NULL DEFAULT 0;
+*/
+
+// migrations/000020_add_total_orders_placed.down.sql
+/*
+ALTER TABLE bot_states DROP COLUMN IF EXISTS total_orders_placed;
+*/
@@ -0,0 +1,16 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRollbackMigrationsRejectsNonPositiveSteps(t *testing.T) {
+	// Steps(-0) or Steps(+n) would be a no-op or a migration up; neither may reach the database
+	for _, steps := range []int{0, -2} {
+		err := RollbackMigrations("postgres://bot@localhost:1/bot?sslmode=disable", steps)
+		if err == nil || !strings.Contains(err.Error(), "must be positive") {
+			t.Errorf("RollbackMigrations(%d) error = %v, want the step count rejected", steps, err)
+		}
+	}
+}
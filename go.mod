@@ -3,6 +3,7 @@ module binance-trader-bot
 go 1.23.4
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/golang-migrate/migrate/v4 v4.18.3
 	github.com/shopspring/decimal v1.4.0
 )
@@ -18,6 +19,6 @@ require (
 	github.com/adshao/go-binance/v2 v2.8.2
 	github.com/hashicorp/errwrap v1.1.0 // indirect
 	github.com/hashicorp/go-multierror v1.1.1 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/lib/pq v1.10.9
 	go.uber.org/atomic v1.7.0 // indirect
 )
@@ -0,0 +1,41 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+)
+
+// Candle is one OHLC bar, oldest first when passed in a series.
+type Candle struct {
+	High  float64
+	Low   float64
+	Close float64
+}
+
+// TrueRange returns the true range of c given the previous bar's close: the largest of the bar's
+// high-low range and the gaps from the previous close to its high and low.
+func TrueRange(c Candle, prevClose float64) float64 {
+	return math.Max(c.High-c.Low, math.Max(math.Abs(c.High-prevClose), math.Abs(c.Low-prevClose)))
+}
+
+// ATR returns the Average True Range of candles over period bars, using Wilder's smoothing: the first
+// value is the simple mean of the first period true ranges, and each later bar moves it by 1/period.
+// The first candle only provides the previous close, so at least period+1 candles are required.
+func ATR(candles []Candle, period int) (float64, error) {
+	if period <= 0 {
+		return 0, fmt.Errorf("ATR period must be positive, got %d", period)
+	}
+	if len(candles) < period+1 {
+		return 0, fmt.Errorf("ATR over %d bars needs at least %d candles, got %d", period, period+1, len(candles))
+	}
+
+	atr := 0.0
+	for i := 1; i <= period; i++ {
+		atr += TrueRange(candles[i], candles[i-1].Close)
+	}
+	atr /= float64(period)
+	for i := period + 1; i < len(candles); i++ {
+		atr = (atr*float64(period-1) + TrueRange(candles[i], candles[i-1].Close)) / float64(period)
+	}
+	return atr, nil
+}
@@ -0,0 +1,47 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTrueRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		candle    Candle
+		prevClose float64
+		want      float64
+	}{
+		{"inside the bar", Candle{High: 105, Low: 95, Close: 100}, 100, 10},
+		{"gap up", Candle{High: 120, Low: 115, Close: 118}, 100, 20},
+		{"gap down", Candle{High: 90, Low: 85, Close: 88}, 100, 15},
+	}
+	for _, tt := range tests {
+		if got := TrueRange(tt.candle, tt.prevClose); got != tt.want {
+			t.Errorf("%s: TrueRange = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestATR(t *testing.T) {
+	candles := []Candle{
+		{High: 101, Low: 99, Close: 100},  // Only provides the previous close
+		{High: 102, Low: 98, Close: 100},  // TR 4
+		{High: 103, Low: 97, Close: 100},  // TR 6
+		{High: 110, Low: 100, Close: 108}, // TR 10
+	}
+	// The first value is the mean of the first two true ranges, then Wilder's smoothing: (5*1 + 10) / 2
+	if got, err := ATR(candles, 2); err != nil || math.Abs(got-7.5) > 1e-12 {
+		t.Errorf("ATR(2) = %v, %v, want 7.5", got, err)
+	}
+	if got, err := ATR(candles, 3); err != nil || math.Abs(got-20.0/3) > 1e-12 {
+		t.Errorf("ATR(3) = %v, %v, want the plain mean 6.667", got, err)
+	}
+
+	if _, err := ATR(candles, 4); err == nil {
+		t.Error("ATR(4) over 4 candles returned no error, want period+1 candles required")
+	}
+	if _, err := ATR(candles, 0); err == nil {
+		t.Error("ATR(0) returned no error")
+	}
+}
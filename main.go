@@ -4,35 +4,116 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"binance-trader-bot/api"
 	"binance-trader-bot/config"
 	"binance-trader-bot/database"
+	"binance-trader-bot/metrics"
+	"binance-trader-bot/models"
 	"binance-trader-bot/repositories"
 	"binance-trader-bot/services"
 	"binance-trader-bot/utils"
 )
 
+// maxStateSaveFailures is how many consecutive cycles may fail to persist the bot state before
+// the bot stops instead of trading on state it cannot save.
+const maxStateSaveFailures = 3
+
+// shutdownCancelTimeout bounds the Binance and database calls CANCEL_ORDERS_ON_SHUTDOWN makes on exit.
+const shutdownCancelTimeout = 30 * time.Second
+
 func main() {
+	printConfig := flag.Bool("print-config", false, "Print the effective configuration (secrets masked) and exit")
+	archive := flag.Bool("archive", false, "Archive terminal orders placed before --before that no trade references, then exit")
+	archiveBefore := flag.String("before", "", "Cutoff date for --archive (YYYY-MM-DD or RFC3339)")
+	report := flag.Bool("report", false, "Print per-symbol trade statistics and their totals, then exit")
+	positions := flag.Bool("positions", false, "Print the OPEN trades of each symbol consolidated into one position, then exit")
+	reportJSON := flag.Bool("json", false, "With --report, --positions or --reconcile, print the result as a single JSON object")
+	reportTag := flag.String("tag", "", "With --report or --positions, only aggregate trades opened under this strategy tag")
+	reconcile := flag.Bool("reconcile", false, "Compare realized trade profit with the recorded USDT balance change, then exit (status 1 on a mismatch)")
+	reconcileTolerance := flag.Float64("tolerance", 1.0, "With --reconcile, largest discrepancy in USDT that is not flagged")
+	migrationStatus := flag.Bool("migration-status", false, "Print the applied migration version and dirty state, then exit")
+	migrateDown := flag.Int("migrate-down", 0, "Roll back this many migrations (requires --confirm), then exit")
+	confirm := flag.Bool("confirm", false, "Confirm a destructive command such as --migrate-down")
+	selfTest := flag.Bool("selftest", false, "Check configuration, database and Binance access without placing orders, then exit")
+	flag.Parse()
+
 	logger := utils.NewLogger()
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if *selfTest {
+		if !runSelfTest(ctx, logger) {
+			cancel()
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Cargar configuración
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		logger.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	if *printConfig {
+		out, err := json.MarshalIndent(cfg.Redacted(), "", "  ")
+		if err != nil {
+			logger.Fatalf("Failed to encode configuration: %v", err)
+		}
+		fmt.Println(string(out))
+		return
+	}
+
+	if *migrationStatus {
+		state, err := database.MigrationStatus(cfg.DatabaseURL)
+		if err != nil {
+			logger.Fatalf("Failed to read migration status: %v", err)
+		}
+		fmt.Printf("Migration version: %d (dirty: %t)\n", state.Version, state.Dirty)
+		if state.Dirty {
+			cancel()
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Revertir migraciones antes de que RunMigrations las vuelva a aplicar
+	if *migrateDown > 0 {
+		state, err := database.MigrationStatus(cfg.DatabaseURL)
+		if err != nil {
+			logger.Fatalf("Failed to read migration status: %v", err)
+		}
+		if !*confirm {
+			logger.Fatalf("--migrate-down %d would roll the schema back from version %d, dropping the data those migrations added. Re-run with --confirm to proceed.",
+				*migrateDown, state.Version)
+		}
+		if err := database.RollbackMigrations(cfg.DatabaseURL, *migrateDown); err != nil {
+			logger.Fatalf("Rollback failed: %v", err)
+		}
+		after, err := database.MigrationStatus(cfg.DatabaseURL)
+		if err != nil {
+			logger.Fatalf("Rolled back, but failed to read the new migration status: %v", err)
+		}
+		logger.Infof("Rolled back %d migrations: version %d -> %d (dirty: %t). Deploy a matching binary before starting the bot, or it will migrate up again.",
+			*migrateDown, state.Version, after.Version, after.Dirty)
+		return
+	}
+
 	// Conectar a la base de datos
 	db, err := database.NewPostgresDB(cfg.DatabaseURL)
 	if err != nil {
 		logger.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer db.Close()
 
 	// Ejecutar migraciones (CORRECCIÓN AQUÍ)
 	err = database.RunMigrations(cfg.DatabaseURL) // <--- CORRECCIÓN CLAVE: Pasar cfg.DatabaseURL
@@ -42,41 +123,331 @@ func main() {
 
 	// Inicializar repositorios
 	tradeRepo := repositories.NewTradeRepository(db)
+	defer func() { tradeRepo.DB().Close() }() // The health monitor may have replaced the original pool
+
+	// Réplica de lectura opcional para la API HTTP y el informe
+	readRepo := tradeRepo
+	if cfg.DatabaseReadURL != "" {
+		readDB, err := database.NewPostgresDB(cfg.DatabaseReadURL)
+		if err != nil {
+			logger.Fatalf("Failed to connect to read replica: %v", err)
+		}
+		readRepo = repositories.NewTradeRepository(readDB)
+		defer func() { readRepo.DB().Close() }() // Reopened by its own health monitor too
+	}
+
+	if *archive {
+		cutoff, err := parseCutoff(*archiveBefore)
+		if err != nil {
+			logger.Fatalf("Invalid --before value: %v", err)
+		}
+		archived, err := tradeRepo.ArchiveOrdersBefore(ctx, cutoff)
+		if err != nil {
+			logger.Fatalf("Failed to archive orders: %v", err)
+		}
+		logger.Infof("Archived %d orders placed before %s.", archived, cutoff.Format(time.RFC3339))
+		return
+	}
+
+	if *report {
+		if err := runReport(ctx, readRepo, *reportTag, *reportJSON, os.Stdout); err != nil {
+			logger.Fatalf("Failed to build report: %v", err)
+		}
+		return
+	}
+
+	if *positions {
+		if err := runPositions(ctx, readRepo, *reportTag, *reportJSON, os.Stdout); err != nil {
+			logger.Fatalf("Failed to build positions report: %v", err)
+		}
+		return
+	}
+
+	if *reconcile {
+		flagged, err := runReconcile(ctx, readRepo, *reconcileTolerance, *reportJSON, os.Stdout)
+		if err != nil {
+			logger.Fatalf("Failed to reconcile profit: %v", err)
+		}
+		if flagged {
+			cancel()
+			os.Exit(1)
+		}
+		return
+	}
 
 	// Inicializar servicios
-	binanceService := services.NewBinanceService(cfg.BinanceAPIKey, cfg.BinanceSecretKey, cfg.UseTestnet, logger)
+	binanceService := services.NewBinanceService(cfg.BinanceAPIKey, cfg.BinanceSecretKey, cfg.UseTestnet, cfg.PriceRounding == config.PriceRoundingConservative, logger)
+	binanceService.SetDefaultPrecision(cfg.DefaultPricePrecision, cfg.DefaultQtyPrecision)
+	binanceService.SetMaxPriceDeviation(cfg.MaxPriceDeviation)
+	binanceService.SetValidateBeforePlacing(cfg.ValidateBeforePlacing)
+	binanceService.SetRetryLotSizeRejections(cfg.RetryLotSizeRejections)
+	metricsRegistry := metrics.NewRegistry()
+	binanceService.SetMetrics(metricsRegistry)
+	binanceService.SetAPICooldown(time.Duration(cfg.APIErrorCooldownSeconds) * time.Second)
+	if cfg.BinanceBaseURL != "" {
+		binanceService.SetBaseURL(cfg.BinanceBaseURL)
+	}
 	stateManager := services.NewStateManager(tradeRepo, logger)
-	tradingStrategy := services.NewTradingStrategy(binanceService, stateManager, cfg, logger)
+	stateManager.SetStrategyTag(cfg.StrategyTag)
+	stateManager.SetReadRepository(readRepo)
+	tradingStrategy := services.NewTradingStrategy(binanceService, stateManager, cfg, metricsRegistry, logger)
+
+	// Avisar si los objetivos de beneficio no cubren las comisiones de la cuenta
+	tradingStrategy.WarnUnprofitableTargets(ctx)
+
+	// Verificar que las órdenes cumplen los mínimos del símbolo
+	if err := tradingStrategy.ValidateOrderSizes(ctx); err != nil {
+		logger.Fatalf("Invalid order size configuration: %v", err)
+	}
+
+	// Estimar el capital necesario para llenar todos los escalones de la estrategia
+	if price, err := binanceService.GetCurrentPrice(ctx, cfg.Symbol); err != nil {
+		logger.Warnf("Could not fetch the current price to estimate ladder capital: %v", err)
+	} else {
+		estimate := tradingStrategy.EstimateLadderCapital(price)
+		logger.Infof("Filling all %d ladder rungs needs %.2f USDT at the current price %f.", len(estimate.Rungs), estimate.TotalUSDT, price)
+		if estimate.TotalUSDT > cfg.InitialUSDT {
+			logger.Warnf("INITIAL_USDT (%.2f) does not cover the full ladder (%.2f USDT). Later rungs will wait for funds.",
+				cfg.InitialUSDT, estimate.TotalUSDT)
+		}
+	}
 
 	// Cargar estado inicial del bot
 	if err := stateManager.LoadBotState(ctx); err != nil {
 		logger.Fatalf("Failed to load bot state: %v", err)
 	}
+	stateManager.RecordEvent(ctx, models.EventStart, fmt.Sprintf("%s started (strategy tag %q)", cfg.Symbol, cfg.StrategyTag))
+
+	// Iniciar la API HTTP de control (opcional)
+	if cfg.HTTPAddr != "" {
+		apiServer := api.NewServer(binanceService, stateManager, tradingStrategy, cfg, metricsRegistry, logger)
+		go apiServer.Start(ctx)
+	}
+
+	// Reabrir el pool de conexiones si la base de datos deja de responder (p. ej. tras reiniciar Postgres)
+	if cfg.DBHealthCheckSeconds > 0 {
+		monitor := database.NewHealthMonitor("primary", cfg.DatabaseURL,
+			time.Duration(cfg.DBHealthCheckSeconds)*time.Second, cfg.DBReconnectAfterFailures,
+			time.Duration(cfg.DBReconnectMaxBackoffSecs)*time.Second,
+			tradeRepo.DB, tradeRepo.ReplaceDB, logger)
+		go monitor.Run(ctx)
+
+		// La réplica de lectura se vigila igual, para que la API HTTP se recupere sola
+		if readRepo != tradeRepo {
+			readMonitor := database.NewHealthMonitor("read replica", cfg.DatabaseReadURL,
+				time.Duration(cfg.DBHealthCheckSeconds)*time.Second, cfg.DBReconnectAfterFailures,
+				time.Duration(cfg.DBReconnectMaxBackoffSecs)*time.Second,
+				readRepo.DB, readRepo.ReplaceDB, logger)
+			go readMonitor.Run(ctx)
+		}
+	}
+
+	// Guardar periódicamente una fila con balances y beneficio en un CSV (opcional, alternativa a Prometheus)
+	if cfg.CSVSnapshotFile != "" {
+		snapshots := services.NewCSVSnapshotWriter(cfg.CSVSnapshotFile,
+			time.Duration(cfg.CSVSnapshotIntervalMinutes)*time.Minute, cfg.Symbol, stateManager, logger)
+		go snapshots.Run(ctx)
+	}
 
 	// Manejo de señales para un apagado limpio
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// Bucle principal del bot
+	// Recargar parámetros no estructurales con SIGHUP
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
 	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				logger.Info("Shutting down trading cycle loop...")
-				return
-			default:
-				if err := tradingStrategy.ExecuteTradingCycle(ctx); err != nil {
-					logger.Errorf("Error during trading cycle: %v", err)
-				}
-				logger.Infof("Next trading cycle in %d seconds...", cfg.TradingCycleIntervalSeconds)
-				time.Sleep(time.Duration(cfg.TradingCycleIntervalSeconds) * time.Second)
+		for range hupChan {
+			logger.Info("SIGHUP received. Reloading configuration...")
+			newCfg, err := config.LoadConfig()
+			if err != nil {
+				logger.Errorf("Config reload failed, keeping current configuration: %v", err)
+				stateManager.RecordEvent(ctx, models.EventConfigRejected, err.Error())
+				continue
+			}
+			if err := tradingStrategy.UpdateConfig(newCfg); err != nil {
+				logger.Errorf("Config reload rejected, keeping current configuration: %v", err)
+				stateManager.RecordEvent(ctx, models.EventConfigRejected, err.Error())
+				continue
 			}
+			logger.Info("Configuration reloaded.")
+			stateManager.RecordEvent(ctx, models.EventConfigReload, "configuration reloaded via SIGHUP")
 		}
 	}()
 
-	// Esperar señal de apagado
-	<-sigChan
-	logger.Info("Shutdown signal received. Exiting.")
-	cancel()                    // Notificar a las goroutines que se detengan
-	time.Sleep(2 * time.Second) // Dar tiempo para que las goroutines terminen
+	// Recibir cambios de estado de órdenes por el user data stream (websocket o both)
+	if cfg.OrderStatusSource != config.OrderStatusSourceREST {
+		go binanceService.RunUserDataStream(ctx, tradingStrategy.HandleOrderUpdate)
+	}
+
+	// Reconciliar órdenes abiertas con su propio intervalo (opcional; en "both" es la red de seguridad del stream)
+	if cfg.OrderPollIntervalSeconds > 0 && cfg.OrderStatusSource != config.OrderStatusSourceWebSocket {
+		go func() {
+			ticker := time.NewTicker(time.Duration(cfg.OrderPollIntervalSeconds) * time.Second)
+			defer ticker.Stop()
+			runOrderPoller(ctx, tradingStrategy, ticker.C, logger)
+		}()
+	}
+
+	// Bucle principal del bot
+	loopDone := make(chan struct{})
+	var stopReason string // Written by the loop before it closes loopDone
+	go func() {
+		defer close(loopDone)
+		stopReason = runTradingLoop(ctx, tradingStrategy, stateManager, logger, time.Sleep)
+	}()
+
+	// Esperar señal de apagado o el fin del bucle
+	loopFinished := false
+	select {
+	case <-sigChan:
+		logger.Info("Shutdown signal received. Exiting.")
+		// ctx is still live here; the stop event is recorded before cancelling it
+		stateManager.RecordEvent(ctx, models.EventStop, "shutdown signal received")
+	case <-loopDone:
+		logger.Info("Trading cycle loop finished. Exiting.")
+		stateManager.RecordEvent(ctx, models.EventStop, stopReason)
+		loopFinished = true
+	}
+	cancel() // Notificar a las goroutines que se detengan
+
+	// Cancelar las órdenes abiertas para que nada se ejecute mientras el bot está detenido
+	cancelOrdersOnShutdown(tradingStrategy.Config(), tradingStrategy, logger)
+
+	if !loopFinished {
+		time.Sleep(2 * time.Second) // Dar tiempo para que las goroutines terminen
+	}
+}
+
+// cycleRunner runs one trading cycle and exposes the configuration currently in effect.
+type cycleRunner interface {
+	ExecuteTradingCycle(ctx context.Context) error
+	Config() *config.Config
+}
+
+// eventRecorder records a lifecycle event in the events table.
+type eventRecorder interface {
+	RecordEvent(ctx context.Context, eventType models.EventType, details string)
+}
+
+// runTradingLoop runs trading cycles until ctx is cancelled or a stop condition (MAX_CYCLES,
+// MAX_CONSECUTIVE_FAILURES, unsaved state) is reached, waiting between cycles with sleep.
+// It returns the reason the loop stopped.
+func runTradingLoop(ctx context.Context, runner cycleRunner, events eventRecorder, logger *utils.Logger, sleep func(time.Duration)) string {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano())) // Separate instances jitter differently
+	cycles := 0
+	stateSaveFailures := 0
+	cycleFailures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Shutting down trading cycle loop...")
+			return "trading cycle loop finished"
+		default:
+		}
+		err := runner.ExecuteTradingCycle(ctx)
+		if err != nil && !errors.Is(err, services.ErrCycleInProgress) {
+			logger.Errorf("Error during trading cycle: %v", err)
+		}
+		// Seguir operando sin poder guardar el estado es peligroso: parar de forma controlada
+		if errors.Is(err, services.ErrStateNotSaved) {
+			stateSaveFailures++
+			logger.Errorf("ALERT: bot state not saved (%d/%d consecutive cycles).", stateSaveFailures, maxStateSaveFailures)
+			if stateSaveFailures >= maxStateSaveFailures {
+				logger.Errorf("Bot state could not be saved for %d consecutive cycles. Stopping trading cycle loop.", stateSaveFailures)
+				events.RecordEvent(ctx, models.EventHalt, fmt.Sprintf("bot state not saved for %d consecutive cycles", stateSaveFailures))
+				return "halted: bot state not saved"
+			}
+		} else if err == nil {
+			stateSaveFailures = 0
+		}
+		cycles++
+		currentCfg := runner.Config() // May have been reloaded via SIGHUP
+		// Un ciclo que falla una y otra vez (Binance o la base de datos caídos) no aporta nada: parar
+		if err != nil && !errors.Is(err, services.ErrCycleInProgress) {
+			cycleFailures++
+			if currentCfg.MaxConsecutiveFailures > 0 && cycleFailures >= currentCfg.MaxConsecutiveFailures {
+				logger.Errorf("ALERT: %d consecutive trading cycles failed (MAX_CONSECUTIVE_FAILURES). Stopping trading cycle loop. Last error: %v",
+					cycleFailures, err)
+				events.RecordEvent(ctx, models.EventHalt, fmt.Sprintf("%d consecutive trading cycles failed, last error: %v", cycleFailures, err))
+				return "halted: MAX_CONSECUTIVE_FAILURES"
+			}
+		} else if err == nil {
+			cycleFailures = 0
+		}
+		if currentCfg.MaxCycles > 0 && cycles >= currentCfg.MaxCycles {
+			logger.Infof("Reached MAX_CYCLES (%d). Stopping trading cycle loop.", currentCfg.MaxCycles)
+			return fmt.Sprintf("reached MAX_CYCLES (%d)", currentCfg.MaxCycles)
+		}
+		delay := nextCycleDelay(rng, currentCfg.TradingCycleIntervalSeconds, currentCfg.CycleJitterSeconds)
+		logger.Infof("Next trading cycle in %s...", delay)
+		sleep(delay)
+	}
+}
+
+// orderReconciler checks the local open orders against Binance.
+type orderReconciler interface {
+	ReconcileOrders(ctx context.Context) error
+}
+
+// runOrderPoller reconciles the open orders on every tick until ctx is cancelled, at its own rate
+// rather than the trading cycle's.
+func runOrderPoller(ctx context.Context, reconciler orderReconciler, ticks <-chan time.Time, logger *utils.Logger) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticks:
+			if err := reconciler.ReconcileOrders(ctx); err != nil {
+				logger.Errorf("Error reconciling open orders: %v", err)
+			}
+		}
+	}
+}
+
+// openOrderCanceller cancels every open order on the traded symbol.
+type openOrderCanceller interface {
+	CancelOpenOrders(ctx context.Context) (int, error)
+}
+
+// cancelOrdersOnShutdown cancels the open orders when CANCEL_ORDERS_ON_SHUTDOWN is set, so nothing
+// fills while the bot is down, and leaves them on the book otherwise. It reports whether it cancelled.
+func cancelOrdersOnShutdown(cfg *config.Config, canceller openOrderCanceller, logger *utils.Logger) bool {
+	if !cfg.CancelOrdersOnShutdown {
+		return false
+	}
+	shutdownCtx, stop := context.WithTimeout(context.Background(), shutdownCancelTimeout)
+	defer stop()
+	cancelled, err := canceller.CancelOpenOrders(shutdownCtx)
+	if err != nil {
+		logger.Errorf("CANCEL_ORDERS_ON_SHUTDOWN: failed to cancel open orders: %v", err)
+		return false
+	}
+	logger.Infof("CANCEL_ORDERS_ON_SHUTDOWN: cancelled %d open orders.", cancelled)
+	return true
+}
+
+// nextCycleDelay returns the base cycle interval shifted by an offset drawn from rng in [-jitter, +jitter]
+// seconds, never going below one second.
+func nextCycleDelay(rng *rand.Rand, intervalSeconds, jitterSeconds int) time.Duration {
+	delay := time.Duration(intervalSeconds) * time.Second
+	if jitterSeconds > 0 {
+		jitter := time.Duration(jitterSeconds) * time.Second
+		delay += time.Duration(rng.Int63n(int64(2*jitter)+1)) - jitter
+	}
+	if delay < time.Second {
+		delay = time.Second
+	}
+	return delay
+}
+
+// parseCutoff parses the --before flag as a date (YYYY-MM-DD) or a full RFC3339 timestamp.
+func parseCutoff(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("--before is required with --archive")
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
 }
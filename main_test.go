@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"binance-trader-bot/config"
+	"binance-trader-bot/models"
+	"binance-trader-bot/services"
+	"binance-trader-bot/utils"
+)
+
+// stubCanceller records CancelOpenOrders calls and answers with a fixed result.
+type stubCanceller struct {
+	calls     int
+	cancelled int
+	err       error
+}
+
+func (s *stubCanceller) CancelOpenOrders(ctx context.Context) (int, error) {
+	s.calls++
+	return s.cancelled, s.err
+}
+
+func TestCancelOrdersOnShutdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		err       error
+		wantCalls int
+		want      bool
+	}{
+		{name: "flag set", enabled: true, wantCalls: 1, want: true},
+		{name: "flag not set", enabled: false, wantCalls: 0, want: false},
+		{name: "cancel fails", enabled: true, err: errors.New("binance unreachable"), wantCalls: 1, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canceller := &stubCanceller{cancelled: 2, err: tt.err}
+			cfg := &config.Config{CancelOrdersOnShutdown: tt.enabled}
+
+			got := cancelOrdersOnShutdown(cfg, canceller, utils.NewLogger())
+			if got != tt.want {
+				t.Errorf("cancelOrdersOnShutdown = %v, want %v", got, tt.want)
+			}
+			if canceller.calls != tt.wantCalls {
+				t.Errorf("CancelOpenOrders called %d times, want %d", canceller.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// stubCycleRunner counts trading cycles and answers each one with the next error in errs
+// (nil once errs runs out).
+type stubCycleRunner struct {
+	cfg    *config.Config
+	errs   []error
+	cycles int
+}
+
+func (s *stubCycleRunner) ExecuteTradingCycle(ctx context.Context) error {
+	s.cycles++
+	if len(s.errs) == 0 {
+		return nil
+	}
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+	return err
+}
+
+func (s *stubCycleRunner) Config() *config.Config { return s.cfg }
+
+// stubEventRecorder collects the recorded event types.
+type stubEventRecorder struct {
+	events []models.EventType
+}
+
+func (s *stubEventRecorder) RecordEvent(ctx context.Context, eventType models.EventType, details string) {
+	s.events = append(s.events, eventType)
+}
+
+func TestRunTradingLoopMaxCycles(t *testing.T) {
+	runner := &stubCycleRunner{cfg: &config.Config{MaxCycles: 3, TradingCycleIntervalSeconds: 1}}
+	var sleeps []time.Duration
+
+	reason := runTradingLoop(context.Background(), runner, &stubEventRecorder{}, utils.NewLogger(),
+		func(d time.Duration) { sleeps = append(sleeps, d) })
+
+	if runner.cycles != 3 {
+		t.Errorf("ran %d cycles, want 3", runner.cycles)
+	}
+	if len(sleeps) != 2 {
+		t.Errorf("slept %d times, want 2 (none after the last cycle)", len(sleeps))
+	}
+	if reason != "reached MAX_CYCLES (3)" {
+		t.Errorf("stop reason = %q", reason)
+	}
+}
+
+func TestRunTradingLoopStateNotSaved(t *testing.T) {
+	notSaved := fmt.Errorf("%w after 3 attempts: connection refused", services.ErrStateNotSaved)
+	tests := []struct {
+		name       string
+		errs       []error
+		wantCycles int
+		wantReason string
+		wantHalt   bool
+	}{
+		{"unsaved cycles interleaved with saved ones", []error{notSaved, notSaved, nil, notSaved, notSaved}, 6, "reached MAX_CYCLES (6)", false},
+		{"persistently unsaved", []error{notSaved, notSaved, notSaved}, 3, "halted: bot state not saved", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &stubCycleRunner{cfg: &config.Config{MaxCycles: 6, TradingCycleIntervalSeconds: 1}, errs: tt.errs}
+			events := &stubEventRecorder{}
+
+			reason := runTradingLoop(context.Background(), runner, events, utils.NewLogger(), func(time.Duration) {})
+
+			if runner.cycles != tt.wantCycles || reason != tt.wantReason {
+				t.Errorf("ran %d cycles, stopped with %q; want %d, %q", runner.cycles, reason, tt.wantCycles, tt.wantReason)
+			}
+			halted := len(events.events) == 1 && events.events[0] == models.EventHalt
+			if halted != tt.wantHalt {
+				t.Errorf("events = %v, want halt recorded: %t", events.events, tt.wantHalt)
+			}
+		})
+	}
+}
+
+func TestRunTradingLoopConsecutiveFailures(t *testing.T) {
+	failed := errors.New("failed to get current price: connection refused")
+	tests := []struct {
+		name       string
+		errs       []error
+		wantCycles int
+		wantReason string
+		wantHalt   bool
+	}{
+		{"failures reach the threshold", []error{failed, failed, failed}, 3, "halted: MAX_CONSECUTIVE_FAILURES", true},
+		{"a successful cycle resets the count", []error{failed, failed, nil, failed, failed, nil}, 6, "reached MAX_CYCLES (6)", false},
+		{"overlapping cycles are not failures", []error{failed, failed, services.ErrCycleInProgress, services.ErrCycleInProgress, nil}, 6, "reached MAX_CYCLES (6)", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &stubCycleRunner{cfg: &config.Config{MaxCycles: 6, MaxConsecutiveFailures: 3, TradingCycleIntervalSeconds: 1}, errs: tt.errs}
+			events := &stubEventRecorder{}
+
+			reason := runTradingLoop(context.Background(), runner, events, utils.NewLogger(), func(time.Duration) {})
+
+			if runner.cycles != tt.wantCycles || reason != tt.wantReason {
+				t.Errorf("ran %d cycles, stopped with %q; want %d, %q", runner.cycles, reason, tt.wantCycles, tt.wantReason)
+			}
+			halted := len(events.events) == 1 && events.events[0] == models.EventHalt
+			if halted != tt.wantHalt {
+				t.Errorf("events = %v, want halt recorded: %t", events.events, tt.wantHalt)
+			}
+		})
+	}
+}
+
+func TestRunTradingLoopFailuresUnlimited(t *testing.T) {
+	failed := errors.New("database unavailable")
+	runner := &stubCycleRunner{cfg: &config.Config{MaxCycles: 5, TradingCycleIntervalSeconds: 1},
+		errs: []error{failed, failed, failed, failed, failed}}
+
+	reason := runTradingLoop(context.Background(), runner, &stubEventRecorder{}, utils.NewLogger(), func(time.Duration) {})
+
+	if runner.cycles != 5 || reason != "reached MAX_CYCLES (5)" {
+		t.Errorf("ran %d cycles, stopped with %q; want the loop to keep going with MAX_CONSECUTIVE_FAILURES unset", runner.cycles, reason)
+	}
+}
+
+// stubReconciler counts order reconciliations.
+type stubReconciler struct {
+	calls int
+}
+
+func (s *stubReconciler) ReconcileOrders(ctx context.Context) error {
+	s.calls++
+	return nil
+}
+
+func TestOrderPollerRunsIndependently(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := utils.NewLogger()
+
+	// The trading loop waits on a fake clock between cycles
+	runner := &stubCycleRunner{cfg: &config.Config{MaxCycles: 2, TradingCycleIntervalSeconds: 60}}
+	sleeping, wake := make(chan struct{}), make(chan struct{})
+	loopDone := make(chan struct{})
+	go func() {
+		defer close(loopDone)
+		runTradingLoop(ctx, runner, &stubEventRecorder{}, logger, func(time.Duration) {
+			sleeping <- struct{}{}
+			<-wake
+		})
+	}()
+	<-sleeping // First cycle done, waiting for the next one
+
+	reconciler := &stubReconciler{}
+	pollTicks := make(chan time.Time)
+	pollerCtx, stopPoller := context.WithCancel(ctx)
+	pollerDone := make(chan struct{})
+	go func() {
+		defer close(pollerDone)
+		runOrderPoller(pollerCtx, reconciler, pollTicks, logger)
+	}()
+	for i := 0; i < 5; i++ {
+		pollTicks <- time.Now()
+	}
+	stopPoller()
+	<-pollerDone
+
+	if reconciler.calls != 5 {
+		t.Errorf("reconciled %d times, want one per poll tick (5)", reconciler.calls)
+	}
+	if runner.cycles != 1 {
+		t.Errorf("ran %d cycles while polling, want 1", runner.cycles)
+	}
+
+	close(wake)
+	<-loopDone
+	if runner.cycles != 2 {
+		t.Errorf("ran %d cycles, want 2", runner.cycles)
+	}
+}
+
+func TestNextCycleDelay(t *testing.T) {
+	tests := []struct {
+		name             string
+		interval, jitter int
+		wantMin, wantMax time.Duration
+	}{
+		{"no jitter", 60, 0, 60 * time.Second, 60 * time.Second},
+		{"jitter", 60, 10, 50 * time.Second, 70 * time.Second},
+		{"floor of one second", 2, 10, time.Second, 12 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			seen := map[time.Duration]bool{}
+			for i := 0; i < 1000; i++ {
+				delay := nextCycleDelay(rng, tt.interval, tt.jitter)
+				if delay < tt.wantMin || delay > tt.wantMax {
+					t.Fatalf("delay = %s, want within [%s, %s]", delay, tt.wantMin, tt.wantMax)
+				}
+				seen[delay] = true
+			}
+			if tt.jitter > 0 && len(seen) < 2 {
+				t.Error("jitter produced a fixed delay")
+			}
+		})
+	}
+}
+
+func TestParseCutoff(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2026-01-15", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), false},
+		{"2026-01-15T12:30:00Z", time.Date(2026, 1, 15, 12, 30, 0, 0, time.UTC), false},
+		{"", time.Time{}, true},
+		{"15/01/2026", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseCutoff(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCutoff(%q) error = %v, want error %t", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseCutoff(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
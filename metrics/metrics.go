@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry holds the bot's metrics. Every series carries a "symbol" label so that
+// several trading pairs can be observed separately.
+type Registry struct {
+	mu            sync.Mutex
+	ordersPlaced  map[string]float64 // Symbol -> orders placed since start
+	openTrades    map[string]float64 // Symbol -> currently open trades
+	profitUSDT    map[string]float64 // Symbol -> realized profit in USDT
+	unrealizedPnL map[string]float64 // Symbol -> unrealized profit of open trades in USDT
+
+	binanceLatency map[string]*latency // Binance REST operation ("METHOD /path") -> request durations
+}
+
+// latency accumulates request durations for a summary without quantiles.
+type latency struct {
+	count      float64
+	sumSeconds float64
+}
+
+// NewRegistry creates and returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		ordersPlaced:  make(map[string]float64),
+		openTrades:    make(map[string]float64),
+		profitUSDT:    make(map[string]float64),
+		unrealizedPnL: make(map[string]float64),
+
+		binanceLatency: make(map[string]*latency),
+	}
+}
+
+// IncOrdersPlaced counts one order placed on symbol.
+func (r *Registry) IncOrdersPlaced(symbol string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ordersPlaced[symbol]++
+}
+
+// SetOpenTrades records the number of open trades on symbol.
+func (r *Registry) SetOpenTrades(symbol string, count int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.openTrades[symbol] = float64(count)
+}
+
+// SetProfitUSDT records the realized profit in USDT on symbol.
+func (r *Registry) SetProfitUSDT(symbol string, profit float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profitUSDT[symbol] = profit
+}
+
+// SetUnrealizedPnLUSDT records the unrealized profit in USDT of the open trades on symbol.
+func (r *Registry) SetUnrealizedPnLUSDT(symbol string, pnl float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unrealizedPnL[symbol] = pnl
+}
+
+// ObserveBinanceLatency records how long one Binance REST request for operation took.
+func (r *Registry) ObserveBinanceLatency(operation string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.binanceLatency[operation]
+	if !ok {
+		l = &latency{}
+		r.binanceLatency[operation] = l
+	}
+	l.count++
+	l.sumSeconds += d.Seconds()
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	families := []struct {
+		name, help, kind string
+		values           map[string]float64
+	}{
+		{"orders_placed_total", "Orders placed on Binance.", "counter", r.ordersPlaced},
+		{"open_trades", "Trades currently open.", "gauge", r.openTrades},
+		{"profit_usdt", "Realized profit in USDT.", "gauge", r.profitUSDT},
+		{"unrealized_pnl_usdt", "Unrealized profit of open trades in USDT.", "gauge", r.unrealizedPnL},
+	}
+
+	for _, f := range families {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind); err != nil {
+			return err
+		}
+		symbols := make([]string, 0, len(f.values))
+		for symbol := range f.values {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+		for _, symbol := range symbols {
+			if _, err := fmt.Fprintf(w, "%s{symbol=\"%s\"} %g\n", f.name, escapeLabel(symbol), f.values[symbol]); err != nil {
+				return err
+			}
+		}
+	}
+	return r.writeLatency(w)
+}
+
+// writeLatency writes the Binance request durations as a summary labelled by operation.
+func (r *Registry) writeLatency(w io.Writer) error {
+	const name = "binance_request_duration_seconds"
+	if _, err := fmt.Fprintf(w, "# HELP %s Round-trip time of Binance REST requests.\n# TYPE %s summary\n", name, name); err != nil {
+		return err
+	}
+	operations := make([]string, 0, len(r.binanceLatency))
+	for operation := range r.binanceLatency {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+	for _, operation := range operations {
+		l := r.binanceLatency[operation]
+		label := escapeLabel(operation)
+		if _, err := fmt.Fprintf(w, "%s_sum{operation=\"%s\"} %g\n%s_count{operation=\"%s\"} %g\n",
+			name, label, l.sumSeconds, name, label, l.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
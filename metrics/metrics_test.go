@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteTextLabelsEverySymbol(t *testing.T) {
+	r := NewRegistry()
+	r.IncOrdersPlaced("BTCUSDT")
+	r.IncOrdersPlaced("BTCUSDT")
+	r.IncOrdersPlaced("ETHUSDT")
+	r.SetOpenTrades("BTCUSDT", 2)
+	r.SetOpenTrades("ETHUSDT", 1)
+	r.SetProfitUSDT("BTCUSDT", 12.5)
+	r.SetProfitUSDT("ETHUSDT", -0.25)
+	r.ObserveBinanceLatency("GET /api/v3/ticker/price", 100*time.Millisecond)
+	r.ObserveBinanceLatency("GET /api/v3/ticker/price", 300*time.Millisecond)
+
+	var out strings.Builder
+	if err := r.WriteText(&out); err != nil {
+		t.Fatalf("WriteText returned error: %v", err)
+	}
+	for _, want := range []string{
+		"# TYPE orders_placed_total counter",
+		`orders_placed_total{symbol="BTCUSDT"} 2`,
+		`orders_placed_total{symbol="ETHUSDT"} 1`,
+		"# TYPE open_trades gauge",
+		`open_trades{symbol="BTCUSDT"} 2`,
+		`open_trades{symbol="ETHUSDT"} 1`,
+		`profit_usdt{symbol="BTCUSDT"} 12.5`,
+		`profit_usdt{symbol="ETHUSDT"} -0.25`,
+		`binance_request_duration_seconds_sum{operation="GET /api/v3/ticker/price"} 0.4`,
+		`binance_request_duration_seconds_count{operation="GET /api/v3/ticker/price"} 2`,
+	} {
+		if !strings.Contains(out.String(), want+"\n") {
+			t.Errorf("metrics missing line %q:\n%s", want, out.String())
+		}
+	}
+	// Series of a family are written in symbol order so scrapes are stable
+	if strings.Index(out.String(), `open_trades{symbol="BTCUSDT"}`) > strings.Index(out.String(), `open_trades{symbol="ETHUSDT"}`) {
+		t.Error("series are not sorted by symbol")
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Errorf("escapeLabel = %q", got)
+	}
+}
@@ -10,12 +10,22 @@ type BotState struct {
 	ID                          int64      `json:"id" db:"id"`
 	InitialUSDTInvestment       float64    `json:"initial_usdt_investment" db:"initial_usdt_investment"`
 	CurrentUSDTBalance          float64    `json:"current_usdt_balance" db:"current_usdt_balance"`
-	CurrentBTCBalance           float64    `json:"current_btc_balance" db:"current_btc_balance"` // Track actual BTC balance
+	CurrentBTCBalance           float64    `json:"current_btc_balance" db:"current_btc_balance"`           // Track actual BTC balance
+	ReservedUSDT                float64    `json:"reserved_usdt" db:"reserved_usdt"`                       // USDT committed to open buy orders
+	OpenPositionQuantity        float64    `json:"open_position_quantity" db:"open_position_quantity"`     // Base asset bought by the bot and not yet sold
+	OpenPositionCostBasis       float64    `json:"open_position_cost_basis" db:"open_position_cost_basis"` // USDT paid for OpenPositionQuantity
 	TotalUSDTInvested           float64    `json:"total_usdt_invested" db:"total_usdt_invested"`
 	TotalUSDTProfit             float64    `json:"total_usdt_profit" db:"total_usdt_profit"`
+	TotalUSDTWithdrawn          float64    `json:"total_usdt_withdrawn" db:"total_usdt_withdrawn"` // Profit moved to the funding wallet
 	InitialBuyOrdersPlacedCount int        `json:"initial_buy_orders_placed_count" db:"initial_buy_orders_placed_count"`
 	LastInitialBuyOrderPlacedAt *time.Time `json:"last_initial_buy_order_placed_at,omitempty" db:"last_initial_buy_order_placed_at"`
+	LastInitialBuyOrderID       *int64     `json:"last_initial_buy_order_id,omitempty" db:"last_initial_buy_order_id"` // Binance ID of the most recent initial buy
+	TWAPSlicesPlacedCount       int        `json:"twap_slices_placed_count" db:"twap_slices_placed_count"`
 	IsInitialBuyingComplete     bool       `json:"is_initial_buying_complete" db:"is_initial_buying_complete"`
+	Initialized                 bool       `json:"initialized" db:"initialized"`                 // True once the state has been configured from INITIAL_USDT
+	FundsDepleted               bool       `json:"funds_depleted" db:"funds_depleted"`           // Buying paused until quote funds are replenished
+	Paused                      bool       `json:"paused" db:"paused"`                           // No new orders placed until resumed via POST /resume
+	TotalOrdersPlaced           int        `json:"total_orders_placed" db:"total_orders_placed"` // Every order the bot has placed, checked against MAX_TOTAL_ORDERS
 	LastBotRunTimestamp         time.Time  `json:"last_bot_run_timestamp" db:"last_bot_run_timestamp"`
 	// You might want to store specific order IDs that are currently open
 	// This would likely be a slice of IDs or a more complex structure,
@@ -46,6 +56,12 @@ func NewBotState(initialUSDT float64) *BotState {
 	}
 }
 
+// MarkInitialized flags the state as configured from the bot's configuration.
+func (bs *BotState) MarkInitialized() {
+	bs.Initialized = true
+	bs.UpdatedAt = time.Now()
+}
+
 // UpdateBalances updates the bot's USDT and BTC balances.
 func (bs *BotState) UpdateBalances(usdt, btc float64) {
 	bs.CurrentUSDTBalance = usdt
@@ -53,6 +69,26 @@ func (bs *BotState) UpdateBalances(usdt, btc float64) {
 	bs.UpdatedAt = time.Now()
 }
 
+// AvailableUSDT returns the USDT balance not committed to open buy orders.
+func (bs *BotState) AvailableUSDT() float64 {
+	return bs.CurrentUSDTBalance - bs.ReservedUSDT
+}
+
+// ReserveUSDT commits an amount of USDT to a newly placed buy order.
+func (bs *BotState) ReserveUSDT(amount float64) {
+	bs.ReservedUSDT += amount
+	bs.UpdatedAt = time.Now()
+}
+
+// ReleaseUSDT frees USDT previously reserved for a buy order that has closed.
+func (bs *BotState) ReleaseUSDT(amount float64) {
+	bs.ReservedUSDT -= amount
+	if bs.ReservedUSDT < 0 {
+		bs.ReservedUSDT = 0
+	}
+	bs.UpdatedAt = time.Now()
+}
+
 // IncrementInitialBuyOrdersCount increments the counter and updates timestamp.
 func (bs *BotState) IncrementInitialBuyOrdersCount() {
 	bs.InitialBuyOrdersPlacedCount++
@@ -64,6 +100,24 @@ func (bs *BotState) IncrementInitialBuyOrdersCount() {
 	bs.UpdatedAt = now
 }
 
+// IncrementTWAPSlicesCount increments the TWAP slice counter and updates the timestamp
+// shared with the initial buy interval gating. The initial phase completes after totalSlices.
+func (bs *BotState) IncrementTWAPSlicesCount(totalSlices int) {
+	bs.TWAPSlicesPlacedCount++
+	now := time.Now()
+	bs.LastInitialBuyOrderPlacedAt = &now
+	if bs.TWAPSlicesPlacedCount >= totalSlices {
+		bs.IsInitialBuyingComplete = true
+	}
+	bs.UpdatedAt = now
+}
+
+// SetLastInitialBuyOrderID records the Binance ID of the most recent initial buy order.
+func (bs *BotState) SetLastInitialBuyOrderID(binanceID int64) {
+	bs.LastInitialBuyOrderID = &binanceID
+	bs.UpdatedAt = time.Now()
+}
+
 // UpdateInvestedAndProfit updates the total invested and profit.
 func (bs *BotState) UpdateInvestedAndProfit(usdtInvested, usdtProfit float64) {
 	bs.TotalUSDTInvested += usdtInvested
@@ -71,6 +125,17 @@ func (bs *BotState) UpdateInvestedAndProfit(usdtInvested, usdtProfit float64) {
 	bs.UpdatedAt = time.Now()
 }
 
+// WithdrawableProfit returns the realized profit that has not been withdrawn yet.
+func (bs *BotState) WithdrawableProfit() float64 {
+	return bs.TotalUSDTProfit - bs.TotalUSDTWithdrawn
+}
+
+// RecordProfitWithdrawal adds a completed profit transfer to the withdrawn total.
+func (bs *BotState) RecordProfitWithdrawal(amount float64) {
+	bs.TotalUSDTWithdrawn += amount
+	bs.UpdatedAt = time.Now()
+}
+
 // SetInitialBuyingComplete marks the initial buying phase as complete.
 func (bs *BotState) SetInitialBuyingComplete() {
 	bs.IsInitialBuyingComplete = true
@@ -82,3 +147,66 @@ func (bs *BotState) UpdateLastBotRunTimestamp() {
 	bs.LastBotRunTimestamp = time.Now()
 	bs.UpdatedAt = time.Now()
 }
+
+// AddToPosition records a filled buy of quantity base asset for cost USDT.
+func (bs *BotState) AddToPosition(quantity, cost float64) {
+	bs.OpenPositionQuantity += quantity
+	bs.OpenPositionCostBasis += cost
+	bs.UpdatedAt = time.Now()
+}
+
+// ReduceFromPosition records a sale of quantity base asset, removing its share of the cost basis
+// at the position's average cost. It returns the cost basis removed.
+func (bs *BotState) ReduceFromPosition(quantity float64) float64 {
+	if bs.OpenPositionQuantity <= 0 {
+		return 0
+	}
+	if quantity >= bs.OpenPositionQuantity {
+		removed := bs.OpenPositionCostBasis
+		bs.OpenPositionQuantity = 0
+		bs.OpenPositionCostBasis = 0
+		bs.UpdatedAt = time.Now()
+		return removed
+	}
+	removed := bs.OpenPositionCostBasis * quantity / bs.OpenPositionQuantity
+	bs.OpenPositionQuantity -= quantity
+	bs.OpenPositionCostBasis -= removed
+	bs.UpdatedAt = time.Now()
+	return removed
+}
+
+// AverageCost returns the weighted-average price paid for the open position, or 0 if there is none.
+func (bs *BotState) AverageCost() float64 {
+	if bs.OpenPositionQuantity <= 0 {
+		return 0
+	}
+	return bs.OpenPositionCostBasis / bs.OpenPositionQuantity
+}
+
+// Equity returns the USDT value of the bot's balances at price.
+func (bs *BotState) Equity(price float64) float64 {
+	return bs.CurrentUSDTBalance + bs.CurrentBTCBalance*price
+}
+
+// PositionPnL returns the unrealized profit of the open position at price, measured against its cost basis.
+func (bs *BotState) PositionPnL(price float64) float64 {
+	return bs.OpenPositionQuantity*price - bs.OpenPositionCostBasis
+}
+
+// SetFundsDepleted enters or leaves the "funds depleted" mode.
+func (bs *BotState) SetFundsDepleted(depleted bool) {
+	bs.FundsDepleted = depleted
+	bs.UpdatedAt = time.Now()
+}
+
+// SetPaused pauses or resumes the placement of new orders.
+func (bs *BotState) SetPaused(paused bool) {
+	bs.Paused = paused
+	bs.UpdatedAt = time.Now()
+}
+
+// IncrementOrdersPlaced counts one more order placed by the bot.
+func (bs *BotState) IncrementOrdersPlaced() {
+	bs.TotalOrdersPlaced++
+	bs.UpdatedAt = time.Now()
+}
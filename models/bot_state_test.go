@@ -0,0 +1,40 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPositionCostBasis(t *testing.T) {
+	bs := NewBotState(1000)
+
+	// Two buys at different prices: 0.001 @ 30000 and 0.003 @ 28000
+	bs.AddToPosition(0.001, 30)
+	bs.AddToPosition(0.003, 84)
+	if bs.OpenPositionQuantity != 0.004 || bs.OpenPositionCostBasis != 114 {
+		t.Fatalf("position = %v for %v, want 0.004 for 114", bs.OpenPositionQuantity, bs.OpenPositionCostBasis)
+	}
+
+	// Selling a quarter removes a quarter of the cost basis at the 28500 average, whatever the sell price
+	removed := bs.ReduceFromPosition(0.001)
+	if math.Abs(removed-28.5) > 1e-9 || math.Abs(bs.OpenPositionCostBasis-85.5) > 1e-9 {
+		t.Errorf("removed %v leaving %v, want 28.5 leaving 85.5", removed, bs.OpenPositionCostBasis)
+	}
+	if math.Abs(bs.OpenPositionQuantity-0.003) > 1e-12 {
+		t.Errorf("quantity = %v, want 0.003", bs.OpenPositionQuantity)
+	}
+	// 0.003 at 29000 is worth 87, against 85.5 paid
+	if pnl := bs.PositionPnL(29000); math.Abs(pnl-1.5) > 1e-9 {
+		t.Errorf("PositionPnL(29000) = %v, want 1.5", pnl)
+	}
+
+	// Selling more than is held closes the position without going negative
+	removed = bs.ReduceFromPosition(0.01)
+	if math.Abs(removed-85.5) > 1e-9 || bs.OpenPositionQuantity != 0 || bs.OpenPositionCostBasis != 0 {
+		t.Errorf("removed %v leaving %v for %v, want 85.5 leaving an empty position",
+			removed, bs.OpenPositionQuantity, bs.OpenPositionCostBasis)
+	}
+	if removed := bs.ReduceFromPosition(0.001); removed != 0 {
+		t.Errorf("ReduceFromPosition on an empty position removed %v, want 0", removed)
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// EventType identifies a bot lifecycle event kept for auditing.
+type EventType string
+
+const (
+	EventStart          EventType = "START"           // Bot started and loaded its state
+	EventStop           EventType = "STOP"            // Bot exited (signal, MAX_CYCLES or after a halt)
+	EventHalt           EventType = "HALT"            // Trading loop stopped itself on repeated failures
+	EventConfigReload   EventType = "CONFIG_RELOAD"   // SIGHUP reload applied
+	EventConfigRejected EventType = "CONFIG_REJECTED" // SIGHUP reload failed or was refused
+	EventLiquidation    EventType = "LIQUIDATION"     // A position was closed at market
+	EventPause          EventType = "PAUSE"           // New order placement paused via the HTTP API
+	EventResume         EventType = "RESUME"          // New order placement resumed via the HTTP API
+	EventOrderMissing   EventType = "ORDER_MISSING"   // A placed order was not found on Binance right after placement
+)
+
+// Event is one entry of the persistent lifecycle event log.
+type Event struct {
+	ID        int64     `json:"id" db:"id"`
+	Type      EventType `json:"type" db:"type"`
+	Details   string    `json:"details" db:"details"` // Human-readable context, e.g. the reason or the trade affected
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewEvent creates an Event stamped with the current time.
+func NewEvent(eventType EventType, details string) *Event {
+	return &Event{Type: eventType, Details: details, CreatedAt: time.Now()}
+}
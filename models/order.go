@@ -39,6 +39,9 @@ type Order struct {
 	Status    OrderStatus `json:"status" db:"status"`         // Current status of the order (NEW, FILLED, etc.)
 	IsTest    bool        `json:"is_test" db:"is_test"`       // True if placed on testnet
 
+	// SellProfitPercentage is the profit target of the ladder rung a buy was placed for (0 uses SELL_PROFIT_PERCENTAGE)
+	SellProfitPercentage float64 `json:"sell_profit_percentage,omitempty" db:"sell_profit_percentage"`
+
 	// Timestamps
 	PlacedAt      time.Time  `json:"placed_at" db:"placed_at"`               // When the order was initially placed by the bot
 	ExecutedAt    *time.Time `json:"executed_at,omitempty" db:"executed_at"` // When the order was fully or partially filled
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// PriceSnapshot is the first price the bot saw for a symbol on a UTC day, kept as that day's
+// reference for day-over-day comparisons.
+type PriceSnapshot struct {
+	ID           int64     `json:"id" db:"id"`
+	Symbol       string    `json:"symbol" db:"symbol"`               // Trading pair, e.g., "BTCUSDT"
+	SnapshotDate time.Time `json:"snapshot_date" db:"snapshot_date"` // UTC day the snapshot belongs to (midnight)
+	Price        float64   `json:"price" db:"price"`                 // Reference price recorded for that day
+	TakenAt      time.Time `json:"taken_at" db:"taken_at"`           // When the price was actually recorded
+}
+
+// NewPriceSnapshot creates the snapshot for the UTC day of takenAt.
+func NewPriceSnapshot(symbol string, price float64, takenAt time.Time) *PriceSnapshot {
+	takenAt = takenAt.UTC()
+	return &PriceSnapshot{
+		Symbol:       symbol,
+		SnapshotDate: time.Date(takenAt.Year(), takenAt.Month(), takenAt.Day(), 0, 0, 0, 0, time.UTC),
+		Price:        price,
+		TakenAt:      takenAt,
+	}
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// SymbolReport aggregates the trades of one symbol (or of all symbols, for the totals).
+type SymbolReport struct {
+	Symbol             string  `json:"symbol,omitempty"`     // Trading pair; empty in the totals row
+	OpenTrades         int     `json:"open_trades"`          // Trades still waiting for their sell to fill
+	ClosedTrades       int     `json:"closed_trades"`        // SOLD trades
+	WinningTrades      int     `json:"winning_trades"`       // SOLD trades with a positive profit
+	LosingTrades       int     `json:"losing_trades"`        // SOLD trades with a zero or negative profit
+	RealizedProfitUSDT float64 `json:"realized_profit_usdt"` // Sum of profit_usdt over SOLD trades
+	ClosedCostUSDT     float64 `json:"closed_cost_usdt"`     // Cost basis (buy price × quantity) of the SOLD trades
+	ROIPercentage      float64 `json:"roi_percentage"`       // RealizedProfitUSDT / ClosedCostUSDT * 100 (0 with no closed trades)
+	AvgProfitUSDT      float64 `json:"avg_profit_usdt"`      // RealizedProfitUSDT / ClosedTrades (0 with no closed trades)
+	WinRatePercentage  float64 `json:"win_rate_percentage"`  // WinningTrades / ClosedTrades * 100 (0 with no closed trades)
+}
+
+// ReportResult is the output of the --report command: per-symbol statistics and their totals.
+type ReportResult struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	StrategyTag string         `json:"strategy_tag,omitempty"` // Set when the report was narrowed with --tag
+	Symbols     []SymbolReport `json:"symbols"`
+	Totals      SymbolReport   `json:"totals"`
+}
+
+// NewReportResult builds a ReportResult from per-symbol counts and profits, filling in the
+// derived averages and the totals.
+func NewReportResult(symbols []SymbolReport, strategyTag string) *ReportResult {
+	result := &ReportResult{
+		GeneratedAt: time.Now().UTC(),
+		StrategyTag: strategyTag,
+		Symbols:     make([]SymbolReport, 0, len(symbols)),
+	}
+	for _, s := range symbols {
+		s.fillDerived()
+		result.Symbols = append(result.Symbols, s)
+		result.Totals.OpenTrades += s.OpenTrades
+		result.Totals.ClosedTrades += s.ClosedTrades
+		result.Totals.WinningTrades += s.WinningTrades
+		result.Totals.LosingTrades += s.LosingTrades
+		result.Totals.RealizedProfitUSDT += s.RealizedProfitUSDT
+		result.Totals.ClosedCostUSDT += s.ClosedCostUSDT
+	}
+	result.Totals.fillDerived()
+	return result
+}
+
+// fillDerived computes the average profit, win rate and ROI from the counts and sums.
+func (s *SymbolReport) fillDerived() {
+	s.AvgProfitUSDT = 0
+	s.WinRatePercentage = 0
+	s.ROIPercentage = 0
+	if s.ClosedTrades > 0 {
+		s.AvgProfitUSDT = s.RealizedProfitUSDT / float64(s.ClosedTrades)
+		s.WinRatePercentage = float64(s.WinningTrades) / float64(s.ClosedTrades) * 100
+	}
+	if s.ClosedCostUSDT > 0 {
+		s.ROIPercentage = s.RealizedProfitUSDT / s.ClosedCostUSDT * 100
+	}
+}
+
+// PositionReport consolidates the OPEN trades of one symbol into a single position.
+type PositionReport struct {
+	Symbol                     string  `json:"symbol"`
+	OpenTrades                 int     `json:"open_trades"`                   // OPEN trades making up the position
+	Quantity                   float64 `json:"quantity"`                      // Sum of buy_quantity, in base asset
+	CostUSDT                   float64 `json:"cost_usdt"`                     // Sum of buy price × quantity
+	TargetValueUSDT            float64 `json:"target_value_usdt"`             // Sum of sell target × quantity: what the position fetches if every sell fills
+	AvgEntryPrice              float64 `json:"avg_entry_price"`               // CostUSDT / Quantity (0 with no quantity)
+	AvgTargetPrice             float64 `json:"avg_target_price"`              // TargetValueUSDT / Quantity (0 with no quantity)
+	DistanceToTargetPercentage float64 `json:"distance_to_target_percentage"` // How far AvgTargetPrice lies above AvgEntryPrice (0 with no cost)
+}
+
+// PositionsResult is the output of the --positions command: the consolidated open position per symbol.
+type PositionsResult struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	StrategyTag string           `json:"strategy_tag,omitempty"` // Set when the report was narrowed with --tag
+	Positions   []PositionReport `json:"positions"`
+}
+
+// NewPositionsResult builds a PositionsResult from per-symbol sums, filling in the weighted averages.
+func NewPositionsResult(positions []PositionReport, strategyTag string) *PositionsResult {
+	result := &PositionsResult{
+		GeneratedAt: time.Now().UTC(),
+		StrategyTag: strategyTag,
+		Positions:   make([]PositionReport, 0, len(positions)),
+	}
+	for _, p := range positions {
+		p.AvgEntryPrice, p.AvgTargetPrice, p.DistanceToTargetPercentage = 0, 0, 0
+		if p.Quantity > 0 {
+			p.AvgEntryPrice = p.CostUSDT / p.Quantity
+			p.AvgTargetPrice = p.TargetValueUSDT / p.Quantity
+		}
+		if p.CostUSDT > 0 {
+			p.DistanceToTargetPercentage = (p.TargetValueUSDT - p.CostUSDT) / p.CostUSDT * 100
+		}
+		result.Positions = append(result.Positions, p)
+	}
+	return result
+}
+
+// ReconciliationResult is the output of the --reconcile command: the realized profit recorded on trades
+// compared with how the tracked USDT balance actually changed since the initial investment.
+type ReconciliationResult struct {
+	GeneratedAt           time.Time `json:"generated_at"`
+	BalanceAsOf           time.Time `json:"balance_as_of"`           // When the bot last refreshed its balances
+	InitialUSDTInvestment float64   `json:"initial_usdt_investment"` // INITIAL_USDT the bot state was set up with
+	CurrentUSDTBalance    float64   `json:"current_usdt_balance"`    // Last refreshed USDT balance (free + locked)
+	OpenPositionCostUSDT  float64   `json:"open_position_cost_usdt"` // USDT still invested in base asset not yet sold
+	WithdrawnUSDT         float64   `json:"withdrawn_usdt"`          // Profit moved to the funding wallet
+	BalanceChangeUSDT     float64   `json:"balance_change_usdt"`     // Current + open cost + withdrawn - initial investment
+	TradeProfitUSDT       float64   `json:"trade_profit_usdt"`       // Sum of profit_usdt over SOLD trades
+	DiscrepancyUSDT       float64   `json:"discrepancy_usdt"`        // BalanceChangeUSDT - TradeProfitUSDT
+	ToleranceUSDT         float64   `json:"tolerance_usdt"`          // Largest discrepancy not flagged
+	Flagged               bool      `json:"flagged"`                 // |DiscrepancyUSDT| > ToleranceUSDT
+}
+
+// NewReconciliationResult compares the realized trade profit with the balance change recorded in state.
+func NewReconciliationResult(state *BotState, tradeProfit, tolerance float64) *ReconciliationResult {
+	result := &ReconciliationResult{
+		GeneratedAt:           time.Now().UTC(),
+		BalanceAsOf:           state.LastBotRunTimestamp,
+		InitialUSDTInvestment: state.InitialUSDTInvestment,
+		CurrentUSDTBalance:    state.CurrentUSDTBalance,
+		OpenPositionCostUSDT:  state.OpenPositionCostBasis,
+		WithdrawnUSDT:         state.TotalUSDTWithdrawn,
+		TradeProfitUSDT:       tradeProfit,
+		ToleranceUSDT:         tolerance,
+	}
+	result.BalanceChangeUSDT = state.CurrentUSDTBalance + state.OpenPositionCostBasis + state.TotalUSDTWithdrawn - state.InitialUSDTInvestment
+	result.DiscrepancyUSDT = result.BalanceChangeUSDT - tradeProfit
+	result.Flagged = math.Abs(result.DiscrepancyUSDT) > tolerance
+	return result
+}
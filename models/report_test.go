@@ -0,0 +1,62 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewReconciliationResult(t *testing.T) {
+	tests := []struct {
+		name            string
+		currentUSDT     float64
+		openCost        float64
+		withdrawn       float64
+		tradeProfit     float64
+		wantDiscrepancy float64
+		wantFlagged     bool
+	}{
+		// 1000 in: 990 left, 20 still in the position, 5 withdrawn is a 15 USDT change
+		{"matches the trade profit", 990, 20, 5, 15, 0, false},
+		{"fee drift within tolerance", 989.4, 20, 5, 15, -0.6, false},
+		{"untracked sale", 1010, 20, 5, 15, 20, true},
+		{"untracked loss", 960, 20, 5, 15, -30, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := NewBotState(1000)
+			state.CurrentUSDTBalance = tt.currentUSDT
+			state.OpenPositionCostBasis = tt.openCost
+			state.TotalUSDTWithdrawn = tt.withdrawn
+
+			result := NewReconciliationResult(state, tt.tradeProfit, 1)
+			if math.Abs(result.DiscrepancyUSDT-tt.wantDiscrepancy) > 1e-9 {
+				t.Errorf("discrepancy = %v, want %v", result.DiscrepancyUSDT, tt.wantDiscrepancy)
+			}
+			if result.Flagged != tt.wantFlagged {
+				t.Errorf("flagged = %v, want %v (discrepancy %v, tolerance 1)", result.Flagged, tt.wantFlagged, result.DiscrepancyUSDT)
+			}
+			if math.Abs(result.BalanceChangeUSDT-(tt.tradeProfit+tt.wantDiscrepancy)) > 1e-9 {
+				t.Errorf("balance change = %v, want %v", result.BalanceChangeUSDT, tt.tradeProfit+tt.wantDiscrepancy)
+			}
+		})
+	}
+}
+
+func TestNewPositionsResultWeightsByQuantity(t *testing.T) {
+	// 0.001 bought at 30000 targeting 30600, and 0.003 at 28000 targeting 28560
+	result := NewPositionsResult([]PositionReport{
+		{Symbol: "BTCUSDT", OpenTrades: 2, Quantity: 0.004, CostUSDT: 114, TargetValueUSDT: 116.28},
+		{Symbol: "ETHUSDT"},
+	}, "")
+	btc := result.Positions[0]
+	if math.Abs(btc.AvgEntryPrice-28500) > 1e-6 || math.Abs(btc.AvgTargetPrice-29070) > 1e-6 {
+		t.Errorf("averages = %v entry, %v target, want 28500 and 29070", btc.AvgEntryPrice, btc.AvgTargetPrice)
+	}
+	if math.Abs(btc.DistanceToTargetPercentage-2) > 1e-9 {
+		t.Errorf("distance to target = %v%%, want 2%%", btc.DistanceToTargetPercentage)
+	}
+	// A position with no quantity or cost has no averages rather than NaN
+	if eth := result.Positions[1]; eth.AvgEntryPrice != 0 || eth.AvgTargetPrice != 0 || eth.DistanceToTargetPercentage != 0 {
+		t.Errorf("empty position = %+v, want zero averages", eth)
+	}
+}
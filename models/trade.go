@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 // TradeStatus represents the overall status of a trade (buy + sell).
 type TradeStatus string
@@ -12,6 +16,10 @@ const (
 	TradeStatusError    TradeStatus = "ERROR"    // Trade encountered an irrecoverable error
 )
 
+// DefaultQuotePrecision is the number of decimals profit is stored with when the quote asset's
+// precision is unknown; Binance never reports amounts finer than this.
+const DefaultQuotePrecision = 8
+
 // Trade represents a complete trading operation: a successful buy order
 // and its corresponding anticipated or executed sell order.
 // This is the core unit the bot tracks for profit/loss.
@@ -26,9 +34,14 @@ type Trade struct {
 	ActualSellPrice  *float64    `json:"actual_sell_price,omitempty" db:"actual_sell_price"` // Actual execution price of the sell
 	Status           TradeStatus `json:"status" db:"status"`                                 // Current status of this trade
 	ProfitUSDT       *float64    `json:"profit_usdt,omitempty" db:"profit_usdt"`             // Calculated profit in USDT
+	ROIPercent       *float64    `json:"roi_percent,omitempty" db:"roi_percent"`             // ProfitUSDT / cost basis (buy price × quantity) × 100
 	OpenedAt         time.Time   `json:"opened_at" db:"opened_at"`                           // When the buy order was filled
 	ClosedAt         *time.Time  `json:"closed_at,omitempty" db:"closed_at"`                 // When the sell order was filled or trade completed
 	LastStatusUpdate time.Time   `json:"last_status_update" db:"last_status_update"`         // Timestamp of last status change
+	ErrorReason      *string     `json:"error_reason,omitempty" db:"error_reason"`           // Why the trade was marked ERROR
+	RepriceCount     int         `json:"reprice_count" db:"reprice_count"`                   // Times the sell target was lowered because it did not fill
+	StrategyTag      string      `json:"strategy_tag,omitempty" db:"strategy_tag"`           // STRATEGY_TAG of the bot that opened the trade (empty if untagged)
+	SellProfitPct    float64     `json:"sell_profit_percentage" db:"sell_profit_percentage"` // Profit target the sell was priced with (0 until the sell is placed)
 }
 
 // NewTrade creates a new Trade instance when a buy order is filled.
@@ -46,17 +59,41 @@ func NewTrade(buyOrderID int64, symbol string, buyPrice, buyQuantity, sellPriceT
 	}
 }
 
-// MarkAsSold updates the trade status to SOLD and calculates profit.
-func (t *Trade) MarkAsSold(actualSellPrice float64) {
+// MarkAsSold updates the trade status to SOLD and calculates profit, rounded to quotePrecision decimals.
+func (t *Trade) MarkAsSold(actualSellPrice float64, quotePrecision int) {
 	t.Status = TradeStatusSold
 	t.ActualSellPrice = &actualSellPrice
-	profit := (actualSellPrice - t.BuyPrice) * t.BuyQuantity
-	t.ProfitUSDT = &profit
+	t.setProfit((actualSellPrice-t.BuyPrice)*t.BuyQuantity, quotePrecision)
 	now := time.Now()
 	t.ClosedAt = &now
 	t.LastStatusUpdate = now
 }
 
+// DeductFees subtracts trading commissions (in USDT) from the realized profit, rounding the result
+// to quotePrecision decimals.
+func (t *Trade) DeductFees(feesUSDT float64, quotePrecision int) {
+	if t.ProfitUSDT == nil {
+		return
+	}
+	t.setProfit(*t.ProfitUSDT-feesUSDT, quotePrecision)
+	t.LastStatusUpdate = time.Now()
+}
+
+// setProfit records the realized profit, rounded to quotePrecision decimals so float artifacts are not
+// stored, and the ROI it represents on the trade's cost basis. ROI is left unset when the cost basis is zero.
+func (t *Trade) setProfit(profit float64, quotePrecision int) {
+	if quotePrecision < 0 {
+		quotePrecision = DefaultQuotePrecision
+	}
+	profit = decimal.NewFromFloat(profit).Round(int32(quotePrecision)).InexactFloat64()
+	t.ProfitUSDT = &profit
+	t.ROIPercent = nil
+	if costBasis := t.BuyPrice * t.BuyQuantity; costBasis > 0 {
+		roi := profit / costBasis * 100
+		t.ROIPercent = &roi
+	}
+}
+
 // MarkAsCanceled updates the trade status to CANCELED.
 func (t *Trade) MarkAsCanceled() {
 	t.Status = TradeStatusCanceled
@@ -65,6 +102,23 @@ func (t *Trade) MarkAsCanceled() {
 	t.LastStatusUpdate = now
 }
 
+// MarkAsError updates the trade status to ERROR, recording why it cannot be completed.
+func (t *Trade) MarkAsError(reason string) {
+	t.Status = TradeStatusError
+	t.ErrorReason = &reason
+	now := time.Now()
+	t.ClosedAt = &now
+	t.LastStatusUpdate = now
+}
+
+// Reprice replaces the sell target with a new order placed at a lower price.
+func (t *Trade) Reprice(sellOrderID int64, newTarget float64) {
+	t.SellOrderID = &sellOrderID
+	t.SellPriceTarget = newTarget
+	t.RepriceCount++
+	t.LastStatusUpdate = time.Now()
+}
+
 // SetSellOrder sets the ID for the associated sell order.
 func (t *Trade) SetSellOrder(sellOrderID int64) {
 	t.SellOrderID = &sellOrderID
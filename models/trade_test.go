@@ -0,0 +1,85 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMarkAsSoldROI(t *testing.T) {
+	tests := []struct {
+		name       string
+		buyPrice   float64
+		quantity   float64
+		sellPrice  float64
+		feesUSDT   float64
+		wantProfit float64
+		wantROI    float64
+	}{
+		{"2% gain", 30000, 0.001, 30600, 0, 0.6, 2},
+		{"loss", 30000, 0.001, 29400, 0, -0.6, -2},
+		{"gain net of fees", 25000, 0.002, 25500, 0.2, 0.8, 1.6},
+		{"break-even", 100, 1, 100, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trade := NewTrade(28, "BTCUSDT", tt.buyPrice, tt.quantity, 2)
+			trade.MarkAsSold(tt.sellPrice, DefaultQuotePrecision)
+			if tt.feesUSDT > 0 {
+				trade.DeductFees(tt.feesUSDT, DefaultQuotePrecision)
+			}
+			if trade.ProfitUSDT == nil || math.Abs(*trade.ProfitUSDT-tt.wantProfit) > 1e-9 {
+				t.Errorf("ProfitUSDT = %v, want %v", deref(trade.ProfitUSDT), tt.wantProfit)
+			}
+			if trade.ROIPercent == nil || math.Abs(*trade.ROIPercent-tt.wantROI) > 1e-9 {
+				t.Errorf("ROIPercent = %v, want %v", deref(trade.ROIPercent), tt.wantROI)
+			}
+		})
+	}
+}
+
+func TestMarkAsSoldROIWithoutCostBasis(t *testing.T) {
+	trade := NewTrade(28, "BTCUSDT", 0, 0.001, 2)
+	trade.MarkAsSold(30000, DefaultQuotePrecision)
+	if trade.ROIPercent != nil {
+		t.Errorf("ROIPercent = %v, want it unset with a zero cost basis", *trade.ROIPercent)
+	}
+}
+
+func TestProfitRoundedToQuotePrecision(t *testing.T) {
+	tests := []struct {
+		name           string
+		buyPrice       float64
+		sellPrice      float64
+		quotePrecision int
+		want           float64
+	}{
+		// (0.4 - 0.1) * 1 is 0.30000000000000004 in float64
+		{"float artifact dropped", 0.1, 0.4, 8, 0.3},
+		{"coarse quote asset", 0.1, 0.40678, 2, 0.31},
+		{"negative precision uses the default", 0.1, 0.123456789, -1, 0.02345679},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trade := NewTrade(28, "BTCUSDT", tt.buyPrice, 1, 2)
+			trade.MarkAsSold(tt.sellPrice, tt.quotePrecision)
+			if trade.ProfitUSDT == nil || *trade.ProfitUSDT != tt.want {
+				t.Errorf("ProfitUSDT = %v, want exactly %v", deref(trade.ProfitUSDT), tt.want)
+			}
+		})
+	}
+
+	// Fees are deducted from the rounded profit and the result rounded again
+	trade := NewTrade(28, "BTCUSDT", 0.1, 1, 2)
+	trade.MarkAsSold(0.4, 8)
+	trade.DeductFees(0.1, 8)
+	if *trade.ProfitUSDT != 0.2 {
+		t.Errorf("ProfitUSDT after fees = %v, want exactly 0.2", *trade.ProfitUSDT)
+	}
+}
+
+func deref(p *float64) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"binance-trader-bot/models"
+	"binance-trader-bot/repositories"
+)
+
+// runReport aggregates the trades per symbol and writes the result to w, as a table or, with
+// asJSON, as a single ReportResult JSON object. A non-empty strategyTag narrows it to that tag.
+func runReport(ctx context.Context, tradeRepo *repositories.TradeRepository, strategyTag string, asJSON bool, w io.Writer) error {
+	stats, err := tradeRepo.GetTradeStatsBySymbol(ctx, strategyTag)
+	if err != nil {
+		return err
+	}
+	result := models.NewReportResult(stats, strategyTag)
+
+	if asJSON {
+		return json.NewEncoder(w).Encode(result)
+	}
+
+	if strategyTag != "" {
+		fmt.Fprintf(w, "Strategy tag: %s\n", strategyTag)
+	}
+	fmt.Fprintf(w, "%-12s %6s %6s %6s %6s %9s %16s %14s %9s\n", "SYMBOL", "OPEN", "CLOSED", "WINS", "LOSSES", "WIN RATE", "PROFIT USDT", "AVG USDT", "ROI")
+	for _, s := range result.Symbols {
+		printReportRow(w, s.Symbol, s)
+	}
+	printReportRow(w, "TOTAL", result.Totals)
+	return nil
+}
+
+func printReportRow(w io.Writer, label string, s models.SymbolReport) {
+	fmt.Fprintf(w, "%-12s %6d %6d %6d %6d %8.2f%% %16.4f %14.4f %8.2f%%\n",
+		label, s.OpenTrades, s.ClosedTrades, s.WinningTrades, s.LosingTrades, s.WinRatePercentage, s.RealizedProfitUSDT, s.AvgProfitUSDT, s.ROIPercentage)
+}
+
+// runPositions consolidates the OPEN trades of each symbol into one position and writes it to w, as a
+// table or, with asJSON, as a single PositionsResult JSON object. A non-empty strategyTag narrows it to that tag.
+func runPositions(ctx context.Context, tradeRepo *repositories.TradeRepository, strategyTag string, asJSON bool, w io.Writer) error {
+	positions, err := tradeRepo.GetOpenPositionsBySymbol(ctx, strategyTag)
+	if err != nil {
+		return err
+	}
+	result := models.NewPositionsResult(positions, strategyTag)
+
+	if asJSON {
+		return json.NewEncoder(w).Encode(result)
+	}
+
+	if strategyTag != "" {
+		fmt.Fprintf(w, "Strategy tag: %s\n", strategyTag)
+	}
+	fmt.Fprintf(w, "%-12s %6s %16s %14s %16s %16s %9s\n", "SYMBOL", "TRADES", "QUANTITY", "COST USDT", "AVG ENTRY", "AVG TARGET", "TO TARGET")
+	for _, p := range result.Positions {
+		fmt.Fprintf(w, "%-12s %6d %16.8f %14.4f %16.8f %16.8f %8.2f%%\n",
+			p.Symbol, p.OpenTrades, p.Quantity, p.CostUSDT, p.AvgEntryPrice, p.AvgTargetPrice, p.DistanceToTargetPercentage)
+	}
+	return nil
+}
+
+// runReconcile compares the realized profit of SOLD trades with the change of the USDT balance the bot
+// last recorded, and writes the result to w. It returns flagged=true when they differ by more than
+// tolerance USDT, which points at trades made outside the bot or at fee drift.
+func runReconcile(ctx context.Context, tradeRepo *repositories.TradeRepository, tolerance float64, asJSON bool, w io.Writer) (bool, error) {
+	state, err := tradeRepo.GetBotState(ctx)
+	if err != nil {
+		return false, err
+	}
+	stats, err := tradeRepo.GetTradeStatsBySymbol(ctx, "")
+	if err != nil {
+		return false, err
+	}
+	result := models.NewReconciliationResult(state, models.NewReportResult(stats, "").Totals.RealizedProfitUSDT, tolerance)
+
+	if asJSON {
+		return result.Flagged, json.NewEncoder(w).Encode(result)
+	}
+
+	fmt.Fprintf(w, "Balance as of:          %s\n", result.BalanceAsOf.Format(time.RFC3339))
+	fmt.Fprintf(w, "Initial investment:     %16.4f USDT\n", result.InitialUSDTInvestment)
+	fmt.Fprintf(w, "Current balance:        %16.4f USDT\n", result.CurrentUSDTBalance)
+	fmt.Fprintf(w, "Open position cost:     %16.4f USDT\n", result.OpenPositionCostUSDT)
+	fmt.Fprintf(w, "Withdrawn profit:       %16.4f USDT\n", result.WithdrawnUSDT)
+	fmt.Fprintf(w, "Balance change:         %16.4f USDT\n", result.BalanceChangeUSDT)
+	fmt.Fprintf(w, "Realized trade profit:  %16.4f USDT\n", result.TradeProfitUSDT)
+	fmt.Fprintf(w, "Discrepancy:            %16.4f USDT\n", result.DiscrepancyUSDT)
+	if result.Flagged {
+		fmt.Fprintf(w, "MISMATCH: discrepancy exceeds the %.4f USDT tolerance (untracked trades or fee drift?)\n", result.ToleranceUSDT)
+	} else {
+		fmt.Fprintf(w, "OK: within the %.4f USDT tolerance\n", result.ToleranceUSDT)
+	}
+	return result.Flagged, nil
+}
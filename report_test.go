@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"binance-trader-bot/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// seedTradeStats answers the per-symbol aggregation with two symbols: BTCUSDT with 3 SOLD trades
+// (2 winning) for 3 USDT on 60 USDT, and ETHUSDT with 1 OPEN and 1 losing SOLD trade.
+func seedTradeStats(t *testing.T, strategyTag string) *repositories.TradeRepository {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	mock.ExpectQuery("GROUP BY symbol").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), strategyTag).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "open", "sold", "wins", "losses", "profit", "invested"}).
+			AddRow("BTCUSDT", 0, 3, 2, 1, 3.0, 60.0).
+			AddRow("ETHUSDT", 1, 1, 0, 1, -0.5, 20.0))
+	return repositories.NewTradeRepository(db)
+}
+
+func TestRunReportJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := runReport(context.Background(), seedTradeStats(t, "grid-a"), "grid-a", true, &out); err != nil {
+		t.Fatalf("runReport returned error: %v", err)
+	}
+	if strings.Count(strings.TrimSpace(out.String()), "\n") != 0 {
+		t.Errorf("output = %q, want a single JSON object on one line", out.String())
+	}
+
+	var report map[string]any
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON %s: %v", out.String(), err)
+	}
+	for _, key := range []string{"generated_at", "strategy_tag", "symbols", "totals"} {
+		if _, ok := report[key]; !ok {
+			t.Errorf("report is missing %q: %s", key, out.String())
+		}
+	}
+	symbols, _ := report["symbols"].([]any)
+	if len(symbols) != 2 {
+		t.Fatalf("symbols = %v, want BTCUSDT and ETHUSDT", report["symbols"])
+	}
+	btc := symbols[0].(map[string]any)
+	for key, want := range map[string]float64{
+		"closed_trades": 3, "winning_trades": 2, "realized_profit_usdt": 3, "avg_profit_usdt": 1, "roi_percentage": 5,
+	} {
+		if got, _ := btc[key].(float64); got != want {
+			t.Errorf("BTCUSDT %s = %v, want %v", key, btc[key], want)
+		}
+	}
+	totals := report["totals"].(map[string]any)
+	for key, want := range map[string]float64{
+		"open_trades": 1, "closed_trades": 4, "winning_trades": 2, "losing_trades": 2, "realized_profit_usdt": 2.5, "win_rate_percentage": 50,
+	} {
+		if got, _ := totals[key].(float64); got != want {
+			t.Errorf("totals %s = %v, want %v", key, totals[key], want)
+		}
+	}
+	if _, ok := totals["symbol"]; ok {
+		t.Errorf("totals has a symbol: %v", totals)
+	}
+}
+
+func TestRunReportTable(t *testing.T) {
+	var out bytes.Buffer
+	if err := runReport(context.Background(), seedTradeStats(t, ""), "", false, &out); err != nil {
+		t.Fatalf("runReport returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "SYMBOL") || !strings.HasPrefix(lines[3], "TOTAL") {
+		t.Errorf("table = %q, want a header, one row per symbol and a TOTAL row", out.String())
+	}
+}
+
+func TestRunPositionsJSON(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	mock.ExpectQuery("GROUP BY symbol").WithArgs(sqlmock.AnyArg(), "grid-a").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "count", "quantity", "cost", "target"}).
+			AddRow("BTCUSDT", 2, 0.004, 114.0, 116.28))
+
+	var out bytes.Buffer
+	if err := runPositions(context.Background(), repositories.NewTradeRepository(db), "grid-a", true, &out); err != nil {
+		t.Fatalf("runPositions returned error: %v", err)
+	}
+	var result struct {
+		StrategyTag string `json:"strategy_tag"`
+		Positions   []struct {
+			Symbol        string  `json:"symbol"`
+			OpenTrades    int     `json:"open_trades"`
+			AvgEntryPrice float64 `json:"avg_entry_price"`
+		} `json:"positions"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON %s: %v", out.String(), err)
+	}
+	if result.StrategyTag != "grid-a" || len(result.Positions) != 1 || result.Positions[0].OpenTrades != 2 ||
+		math.Abs(result.Positions[0].AvgEntryPrice-28500) > 1e-6 {
+		t.Errorf("positions = %s, want BTCUSDT with 2 trades averaging 28500", out.String())
+	}
+}
@@ -3,14 +3,20 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"binance-trader-bot/models" // Importar los modelos
+
+	"github.com/lib/pq"
 )
 
 // TradeRepository handles database operations for Orders, Trades, and BotState.
 type TradeRepository struct {
+	mu sync.RWMutex
 	db *sql.DB
 }
 
@@ -19,16 +25,38 @@ func NewTradeRepository(db *sql.DB) *TradeRepository {
 	return &TradeRepository{db: db}
 }
 
+// conn returns the connection pool currently in use.
+func (r *TradeRepository) conn() *sql.DB {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.db
+}
+
+// DB returns the connection pool currently in use, e.g. to close it on shutdown.
+func (r *TradeRepository) DB() *sql.DB {
+	return r.conn()
+}
+
+// ReplaceDB swaps in a freshly opened connection pool and returns the previous one, which the
+// caller should close. Queries already running keep the pool they started on.
+func (r *TradeRepository) ReplaceDB(db *sql.DB) *sql.DB {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.db
+	r.db = db
+	return old
+}
+
 // --- Order Operations ---
 
 // CreateOrder inserts a new Order into the database.
 func (r *TradeRepository) CreateOrder(ctx context.Context, order *models.Order) error {
 	query := `
-		INSERT INTO orders (binance_id, symbol, type, price, quantity, quote_qty, status, is_test, placed_at, last_updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO orders (binance_id, symbol, type, price, quantity, quote_qty, status, is_test, placed_at, last_updated_at, sell_profit_percentage)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id;
 	`
-	err := r.db.QueryRowContext(
+	err := r.conn().QueryRowContext(
 		ctx,
 		query,
 		order.BinanceID,
@@ -41,14 +69,41 @@ func (r *TradeRepository) CreateOrder(ctx context.Context, order *models.Order)
 		order.IsTest,
 		order.PlacedAt,
 		order.LastUpdatedAt,
+		order.SellProfitPercentage,
 	).Scan(&order.ID) // Populate the internal ID back into the struct
 
+	if isUniqueViolation(err) {
+		// Saved already, e.g. by an attempt retried after a reconnect: refresh the existing row instead
+		return r.refreshExistingOrder(ctx, order)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create order in DB: %w", err)
 	}
 	return nil
 }
 
+// refreshExistingOrder updates the mutable fields of an order already stored under its binance_id
+// and reads its internal ID back into the struct.
+func (r *TradeRepository) refreshExistingOrder(ctx context.Context, order *models.Order) error {
+	query := `
+		UPDATE orders
+		SET status = $1, executed_at = $2, last_updated_at = $3
+		WHERE binance_id = $4
+		RETURNING id;
+	`
+	err := r.conn().QueryRowContext(ctx, query, order.Status, order.ExecutedAt, order.LastUpdatedAt, order.BinanceID).Scan(&order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update already stored order %d in DB: %w", order.BinanceID, err)
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is Postgres rejecting a row for a UNIQUE constraint (23505).
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 // UpdateOrder updates an existing Order in the database.
 func (r *TradeRepository) UpdateOrder(ctx context.Context, order *models.Order) error {
 	query := `
@@ -56,7 +111,7 @@ func (r *TradeRepository) UpdateOrder(ctx context.Context, order *models.Order)
 		SET status = $1, executed_at = $2, last_updated_at = $3
 		WHERE binance_id = $4;
 	`
-	res, err := r.db.ExecContext(
+	res, err := r.conn().ExecContext(
 		ctx,
 		query,
 		order.Status,
@@ -77,18 +132,125 @@ func (r *TradeRepository) UpdateOrder(ctx context.Context, order *models.Order)
 	return nil
 }
 
+// upsertOrdersChunk is how many orders one multi-row INSERT of UpsertOrders carries, keeping its
+// bind parameters well under PostgreSQL's limit of 65535.
+const upsertOrdersChunk = 1000
+
+// UpsertOrders saves a batch of orders in one transaction: orders not stored yet are inserted, and for
+// orders already stored under their binance_id the mutable fields (status, executed_at, last_updated_at)
+// are updated, as refreshExistingOrder does. Internal IDs are read back into the structs. If an order
+// appears more than once in the batch, its last occurrence wins.
+func (r *TradeRepository) UpsertOrders(ctx context.Context, orders []*models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	// A single INSERT ... ON CONFLICT cannot touch the same row twice
+	latest := make(map[int64]*models.Order, len(orders))
+	unique := make([]*models.Order, 0, len(orders))
+	for _, order := range orders {
+		if _, seen := latest[order.BinanceID]; !seen {
+			unique = append(unique, order)
+		}
+		latest[order.BinanceID] = order
+	}
+	for i, order := range unique {
+		unique[i] = latest[order.BinanceID]
+	}
+
+	tx, err := r.conn().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin order upsert transaction: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	for start := 0; start < len(unique); start += upsertOrdersChunk {
+		end := min(start+upsertOrdersChunk, len(unique))
+		if err := upsertOrderRows(ctx, tx, unique[start:end]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit order upsert: %w", err)
+	}
+	for _, order := range orders {
+		order.ID = latest[order.BinanceID].ID
+	}
+	return nil
+}
+
+// upsertOrderRows runs one multi-row INSERT ... ON CONFLICT for orders, which must have distinct binance_ids.
+func upsertOrderRows(ctx context.Context, tx *sql.Tx, orders []*models.Order) error {
+	const columnsPerRow = 12
+	placeholders := make([]string, 0, len(orders))
+	args := make([]interface{}, 0, len(orders)*columnsPerRow)
+	for i, order := range orders {
+		row := make([]string, columnsPerRow)
+		for j := range row {
+			row[j] = fmt.Sprintf("$%d", i*columnsPerRow+j+1)
+		}
+		placeholders = append(placeholders, "("+strings.Join(row, ", ")+")")
+		args = append(args,
+			order.BinanceID,
+			order.Symbol,
+			order.Type,
+			order.Price,
+			order.Quantity,
+			order.QuoteQty,
+			order.Status,
+			order.IsTest,
+			order.PlacedAt,
+			order.ExecutedAt,
+			order.LastUpdatedAt,
+			order.SellProfitPercentage,
+		)
+	}
+
+	query := `
+		INSERT INTO orders (binance_id, symbol, type, price, quantity, quote_qty, status, is_test, placed_at, executed_at, last_updated_at, sell_profit_percentage)
+		VALUES ` + strings.Join(placeholders, ", ") + `
+		ON CONFLICT (binance_id) DO UPDATE
+		SET status = EXCLUDED.status, executed_at = EXCLUDED.executed_at, last_updated_at = EXCLUDED.last_updated_at
+		RETURNING id, binance_id;
+	`
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to upsert %d orders in DB: %w", len(orders), err)
+	}
+	defer rows.Close()
+
+	byBinanceID := make(map[int64]*models.Order, len(orders))
+	for _, order := range orders {
+		byBinanceID[order.BinanceID] = order
+	}
+	for rows.Next() {
+		var id, binanceID int64
+		if err := rows.Scan(&id, &binanceID); err != nil {
+			return fmt.Errorf("failed to scan upserted order row: %w", err)
+		}
+		if order, ok := byBinanceID[binanceID]; ok {
+			order.ID = id
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating over upserted order rows: %w", err)
+	}
+	return nil
+}
+
 // GetOrderByBinanceID fetches an Order by its BinanceID.
 func (r *TradeRepository) GetOrderByBinanceID(ctx context.Context, binanceID int64) (*models.Order, error) {
 	order := &models.Order{}
 	query := `
-		SELECT id, binance_id, symbol, type, price, quantity, quote_qty, status, is_test, placed_at, executed_at, last_updated_at
+		SELECT id, binance_id, symbol, type, price, quantity, quote_qty, status, is_test, placed_at, executed_at, last_updated_at, sell_profit_percentage
 		FROM orders
 		WHERE binance_id = $1;
 	`
 	// Use sql.NullTime for nullable fields
 	var executedAt sql.NullTime
 
-	err := r.db.QueryRowContext(ctx, query, binanceID).Scan(
+	err := r.conn().QueryRowContext(ctx, query, binanceID).Scan(
 		&order.ID,
 		&order.BinanceID,
 		&order.Symbol,
@@ -101,6 +263,7 @@ func (r *TradeRepository) GetOrderByBinanceID(ctx context.Context, binanceID int
 		&order.PlacedAt,
 		&executedAt,
 		&order.LastUpdatedAt,
+		&order.SellProfitPercentage,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -116,13 +279,107 @@ func (r *TradeRepository) GetOrderByBinanceID(ctx context.Context, binanceID int
 	return order, nil
 }
 
+// GetOrdersByStatus fetches all Orders in any of the given statuses.
+func (r *TradeRepository) GetOrdersByStatus(ctx context.Context, statuses ...models.OrderStatus) ([]*models.Order, error) {
+	query := `
+		SELECT id, binance_id, symbol, type, price, quantity, quote_qty, status, is_test, placed_at, executed_at, last_updated_at, sell_profit_percentage
+		FROM orders
+		WHERE status = ANY($1);
+	`
+	statusValues := make([]string, len(statuses))
+	for i, st := range statuses {
+		statusValues[i] = string(st)
+	}
+
+	rows, err := r.conn().QueryContext(ctx, query, pq.Array(statusValues))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders by status %v: %w", statuses, err)
+	}
+	defer rows.Close()
+
+	var orders []*models.Order
+	for rows.Next() {
+		order := &models.Order{}
+		var executedAt sql.NullTime
+
+		err := rows.Scan(
+			&order.ID,
+			&order.BinanceID,
+			&order.Symbol,
+			&order.Type,
+			&order.Price,
+			&order.Quantity,
+			&order.QuoteQty,
+			&order.Status,
+			&order.IsTest,
+			&order.PlacedAt,
+			&executedAt,
+			&order.LastUpdatedAt,
+			&order.SellProfitPercentage,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order row: %w", err)
+		}
+
+		if executedAt.Valid {
+			order.ExecutedAt = &executedAt.Time
+		}
+
+		orders = append(orders, order)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over order rows: %w", err)
+	}
+
+	return orders, nil
+}
+
+// ArchiveOrdersBefore moves orders in a terminal status (FILLED, CANCELED, REJECTED, EXPIRED)
+// placed before cutoff into the orders_archive table. Orders still referenced by a trade are
+// kept so the trades foreign key stays valid. It returns the number of archived orders.
+func (r *TradeRepository) ArchiveOrdersBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `
+		WITH moved AS (
+			DELETE FROM orders o
+			WHERE o.placed_at < $1
+			  AND o.status IN ($2, $3, $4, $5)
+			  AND NOT EXISTS (
+				SELECT 1 FROM trades t
+				WHERE t.buy_order_id = o.binance_id OR t.sell_order_id = o.binance_id
+			  )
+			RETURNING o.id, o.binance_id, o.symbol, o.type, o.price, o.quantity, o.quote_qty, o.status, o.is_test, o.placed_at, o.executed_at, o.last_updated_at, o.sell_profit_percentage
+		)
+		INSERT INTO orders_archive (id, binance_id, symbol, type, price, quantity, quote_qty, status, is_test, placed_at, executed_at, last_updated_at, sell_profit_percentage)
+		SELECT id, binance_id, symbol, type, price, quantity, quote_qty, status, is_test, placed_at, executed_at, last_updated_at, sell_profit_percentage
+		FROM moved;
+	`
+	res, err := r.conn().ExecContext(
+		ctx,
+		query,
+		cutoff,
+		models.OrderStatusFilled,
+		models.OrderStatusCanceled,
+		models.OrderStatusRejected,
+		models.OrderStatusExpired,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive orders before %s: %w", cutoff.Format(time.RFC3339), err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected for order archive: %w", err)
+	}
+	return rowsAffected, nil
+}
+
 // --- Trade Operations ---
 
 // CreateTrade inserts a new Trade into the database.
 func (r *TradeRepository) CreateTrade(ctx context.Context, trade *models.Trade) error {
 	query := `
-		INSERT INTO trades (buy_order_id, sell_order_id, symbol, buy_price, buy_quantity, sell_price_target, actual_sell_price, status, profit_usdt, opened_at, closed_at, last_status_update)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO trades (buy_order_id, sell_order_id, symbol, buy_price, buy_quantity, sell_price_target, actual_sell_price, status, profit_usdt, opened_at, closed_at, last_status_update, strategy_tag, sell_profit_percentage)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id;
 	`
 	var sellOrderID sql.NullInt64
@@ -149,7 +406,7 @@ func (r *TradeRepository) CreateTrade(ctx context.Context, trade *models.Trade)
 		closedAt.Valid = true
 	}
 
-	err := r.db.QueryRowContext(
+	err := r.conn().QueryRowContext(
 		ctx,
 		query,
 		trade.BuyOrderID,
@@ -164,6 +421,8 @@ func (r *TradeRepository) CreateTrade(ctx context.Context, trade *models.Trade)
 		trade.OpenedAt,
 		closedAt,
 		trade.LastStatusUpdate,
+		trade.StrategyTag,
+		trade.SellProfitPct,
 	).Scan(&trade.ID)
 
 	if err != nil {
@@ -176,8 +435,9 @@ func (r *TradeRepository) CreateTrade(ctx context.Context, trade *models.Trade)
 func (r *TradeRepository) UpdateTrade(ctx context.Context, trade *models.Trade) error {
 	query := `
 		UPDATE trades
-		SET sell_order_id = $1, actual_sell_price = $2, status = $3, profit_usdt = $4, closed_at = $5, last_status_update = $6
-		WHERE id = $7;
+		SET sell_order_id = $1, actual_sell_price = $2, status = $3, profit_usdt = $4, closed_at = $5, last_status_update = $6, error_reason = $7,
+			sell_price_target = $8, reprice_count = $9, sell_profit_percentage = $10, roi_percent = $11
+		WHERE id = $12;
 	`
 	var sellOrderID sql.NullInt64
 	if trade.SellOrderID != nil {
@@ -197,13 +457,25 @@ func (r *TradeRepository) UpdateTrade(ctx context.Context, trade *models.Trade)
 		profitUSDT.Valid = true
 	}
 
+	var roiPercent sql.NullFloat64
+	if trade.ROIPercent != nil {
+		roiPercent.Float64 = *trade.ROIPercent
+		roiPercent.Valid = true
+	}
+
 	var closedAt sql.NullTime
 	if trade.ClosedAt != nil {
 		closedAt.Time = *trade.ClosedAt
 		closedAt.Valid = true
 	}
 
-	res, err := r.db.ExecContext(
+	var errorReason sql.NullString
+	if trade.ErrorReason != nil {
+		errorReason.String = *trade.ErrorReason
+		errorReason.Valid = true
+	}
+
+	res, err := r.conn().ExecContext(
 		ctx,
 		query,
 		sellOrderID,
@@ -212,6 +484,11 @@ func (r *TradeRepository) UpdateTrade(ctx context.Context, trade *models.Trade)
 		profitUSDT,
 		closedAt,
 		trade.LastStatusUpdate,
+		errorReason,
+		trade.SellPriceTarget,
+		trade.RepriceCount,
+		trade.SellProfitPct,
+		roiPercent,
 		trade.ID,
 	)
 	if err != nil {
@@ -227,17 +504,180 @@ func (r *TradeRepository) UpdateTrade(ctx context.Context, trade *models.Trade)
 	return nil
 }
 
+// CountBuyOrdersPlacedSince counts the BUY orders for symbol placed at or after since whose Binance ID
+// is greater than afterBinanceID, whatever their status. Binance order IDs grow per symbol, so passing
+// the ID of the last initial buy leaves the initial buys out of the count.
+func (r *TradeRepository) CountBuyOrdersPlacedSince(ctx context.Context, symbol string, since time.Time, afterBinanceID int64) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM orders
+		WHERE symbol = $1 AND type = $2 AND placed_at >= $3 AND binance_id > $4;
+	`
+	var count int
+	if err := r.conn().QueryRowContext(ctx, query, symbol, models.OrderTypeBuy, since, afterBinanceID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count buy orders for %s placed since %s: %w", symbol, since.Format(time.RFC3339), err)
+	}
+	return count, nil
+}
+
+// tradeColumns is the column list scanned by scanTrades.
+const tradeColumns = `id, buy_order_id, sell_order_id, symbol, buy_price, buy_quantity, sell_price_target, actual_sell_price, status, profit_usdt, roi_percent, opened_at, closed_at, last_status_update, error_reason, reprice_count, strategy_tag, sell_profit_percentage`
+
 // GetTradesByStatus fetches all Trades with a specific status.
 func (r *TradeRepository) GetTradesByStatus(ctx context.Context, status models.TradeStatus) ([]*models.Trade, error) {
 	query := `
-		SELECT id, buy_order_id, sell_order_id, symbol, buy_price, buy_quantity, sell_price_target, actual_sell_price, status, profit_usdt, opened_at, closed_at, last_status_update
+		SELECT ` + tradeColumns + `
 		FROM trades
 		WHERE status = $1;
 	`
-	rows, err := r.db.QueryContext(ctx, query, status)
+	rows, err := r.conn().QueryContext(ctx, query, status)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trades by status '%s': %w", status, err)
 	}
+	return scanTrades(rows)
+}
+
+// CountTradesByStatus returns how many Trades are in a specific status without loading them.
+func (r *TradeRepository) CountTradesByStatus(ctx context.Context, status models.TradeStatus) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM trades WHERE status = $1;`
+	if err := r.conn().QueryRowContext(ctx, query, status).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count trades by status '%s': %w", status, err)
+	}
+	return count, nil
+}
+
+// GetOpenTradeCount returns how many trades are OPEN without loading them.
+func (r *TradeRepository) GetOpenTradeCount(ctx context.Context) (int, error) {
+	return r.CountTradesByStatus(ctx, models.TradeStatusOpen)
+}
+
+// GetSymbolsWithOpenTrades returns the distinct symbols that have at least one OPEN trade, sorted.
+func (r *TradeRepository) GetSymbolsWithOpenTrades(ctx context.Context) ([]string, error) {
+	query := `SELECT DISTINCT symbol FROM trades WHERE status = $1 ORDER BY symbol;`
+	rows, err := r.conn().QueryContext(ctx, query, models.TradeStatusOpen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get symbols with open trades: %w", err)
+	}
+	defer rows.Close()
+
+	symbols := []string{}
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan symbol row: %w", err)
+		}
+		symbols = append(symbols, symbol)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over symbol rows: %w", err)
+	}
+	return symbols, nil
+}
+
+// GetTradesByStatusAndTag fetches all Trades with a specific status opened under the given strategy tag.
+func (r *TradeRepository) GetTradesByStatusAndTag(ctx context.Context, status models.TradeStatus, strategyTag string) ([]*models.Trade, error) {
+	query := `
+		SELECT ` + tradeColumns + `
+		FROM trades
+		WHERE status = $1 AND strategy_tag = $2;
+	`
+	rows, err := r.conn().QueryContext(ctx, query, status, strategyTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trades by status '%s' and tag '%s': %w", status, strategyTag, err)
+	}
+	return scanTrades(rows)
+}
+
+// GetRecentTrades fetches the limit most recently updated Trades, whatever their status, newest first.
+func (r *TradeRepository) GetRecentTrades(ctx context.Context, limit int) ([]*models.Trade, error) {
+	query := `
+		SELECT ` + tradeColumns + `
+		FROM trades
+		ORDER BY last_status_update DESC, id DESC
+		LIMIT $1;
+	`
+	rows, err := r.conn().QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %d recent trades: %w", limit, err)
+	}
+	return scanTrades(rows)
+}
+
+// GetTradeStatsBySymbol aggregates open and SOLD trades per symbol, ordered by symbol.
+// A non-empty strategyTag narrows the aggregation to trades opened under that tag.
+// The derived averages are left for models.NewReportResult to fill in.
+func (r *TradeRepository) GetTradeStatsBySymbol(ctx context.Context, strategyTag string) ([]models.SymbolReport, error) {
+	query := `
+		SELECT symbol,
+			COUNT(*) FILTER (WHERE status = $1),
+			COUNT(*) FILTER (WHERE status = $2),
+			COUNT(*) FILTER (WHERE status = $2 AND profit_usdt > 0),
+			COUNT(*) FILTER (WHERE status = $2 AND COALESCE(profit_usdt, 0) <= 0),
+			COALESCE(SUM(profit_usdt) FILTER (WHERE status = $2), 0),
+			COALESCE(SUM(buy_price * buy_quantity) FILTER (WHERE status = $2), 0)
+		FROM trades
+		WHERE status IN ($1, $2) AND ($3 = '' OR strategy_tag = $3)
+		GROUP BY symbol
+		ORDER BY symbol;
+	`
+	rows, err := r.conn().QueryContext(ctx, query, models.TradeStatusOpen, models.TradeStatusSold, strategyTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate trades by symbol: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []models.SymbolReport
+	for rows.Next() {
+		var s models.SymbolReport
+		if err := rows.Scan(&s.Symbol, &s.OpenTrades, &s.ClosedTrades, &s.WinningTrades, &s.LosingTrades, &s.RealizedProfitUSDT, &s.ClosedCostUSDT); err != nil {
+			return nil, fmt.Errorf("failed to scan trade stats row: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over trade stats rows: %w", err)
+	}
+	return stats, nil
+}
+
+// GetOpenPositionsBySymbol aggregates OPEN trades per symbol into their total quantity, cost and
+// target value, ordered by symbol. A non-empty strategyTag narrows it to trades opened under that tag.
+// The weighted averages are left for models.NewPositionsResult to fill in.
+func (r *TradeRepository) GetOpenPositionsBySymbol(ctx context.Context, strategyTag string) ([]models.PositionReport, error) {
+	query := `
+		SELECT symbol,
+			COUNT(*),
+			COALESCE(SUM(buy_quantity), 0),
+			COALESCE(SUM(buy_price * buy_quantity), 0),
+			COALESCE(SUM(sell_price_target * buy_quantity), 0)
+		FROM trades
+		WHERE status = $1 AND ($2 = '' OR strategy_tag = $2)
+		GROUP BY symbol
+		ORDER BY symbol;
+	`
+	rows, err := r.conn().QueryContext(ctx, query, models.TradeStatusOpen, strategyTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate open trades by symbol: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []models.PositionReport
+	for rows.Next() {
+		var p models.PositionReport
+		if err := rows.Scan(&p.Symbol, &p.OpenTrades, &p.Quantity, &p.CostUSDT, &p.TargetValueUSDT); err != nil {
+			return nil, fmt.Errorf("failed to scan open position row: %w", err)
+		}
+		positions = append(positions, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over open position rows: %w", err)
+	}
+	return positions, nil
+}
+
+// scanTrades reads Trades selected with tradeColumns and closes rows.
+func scanTrades(rows *sql.Rows) ([]*models.Trade, error) {
 	defer rows.Close()
 
 	var trades []*models.Trade
@@ -246,7 +686,9 @@ func (r *TradeRepository) GetTradesByStatus(ctx context.Context, status models.T
 		var sellOrderID sql.NullInt64
 		var actualSellPrice sql.NullFloat64
 		var profitUSDT sql.NullFloat64
+		var roiPercent sql.NullFloat64
 		var closedAt sql.NullTime
+		var errorReason sql.NullString
 
 		err := rows.Scan(
 			&trade.ID,
@@ -259,9 +701,14 @@ func (r *TradeRepository) GetTradesByStatus(ctx context.Context, status models.T
 			&actualSellPrice,
 			&trade.Status,
 			&profitUSDT,
+			&roiPercent,
 			&trade.OpenedAt,
 			&closedAt,
 			&trade.LastStatusUpdate,
+			&errorReason,
+			&trade.RepriceCount,
+			&trade.StrategyTag,
+			&trade.SellProfitPct,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan trade row: %w", err)
@@ -276,20 +723,118 @@ func (r *TradeRepository) GetTradesByStatus(ctx context.Context, status models.T
 		if profitUSDT.Valid {
 			trade.ProfitUSDT = &profitUSDT.Float64
 		}
+		if roiPercent.Valid {
+			trade.ROIPercent = &roiPercent.Float64
+		}
 		if closedAt.Valid {
 			trade.ClosedAt = &closedAt.Time
 		}
+		if errorReason.Valid {
+			trade.ErrorReason = &errorReason.String
+		}
 
 		trades = append(trades, trade)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating over trade rows: %w", err)
 	}
-
 	return trades, nil
 }
 
+// --- Price Snapshot Operations ---
+
+// SavePriceSnapshot inserts the daily price snapshot. A snapshot already recorded for the same
+// symbol and day is kept, so the first price of the day wins across restarts. It reports whether
+// the snapshot was inserted.
+func (r *TradeRepository) SavePriceSnapshot(ctx context.Context, snapshot *models.PriceSnapshot) (bool, error) {
+	query := `
+		INSERT INTO price_snapshots (symbol, snapshot_date, price, taken_at)
+		VALUES ($1, $2::date, $3, $4)
+		ON CONFLICT (symbol, snapshot_date) DO NOTHING
+		RETURNING id;
+	`
+	err := r.conn().QueryRowContext(ctx, query, snapshot.Symbol, snapshot.SnapshotDate.Format(time.DateOnly), snapshot.Price, snapshot.TakenAt).Scan(&snapshot.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to save price snapshot for %s on %s: %w", snapshot.Symbol, snapshot.SnapshotDate.Format(time.DateOnly), err)
+	}
+	return true, nil
+}
+
+// GetPriceSnapshots fetches the daily snapshots of a symbol from the UTC day of since onwards, oldest first.
+func (r *TradeRepository) GetPriceSnapshots(ctx context.Context, symbol string, since time.Time) ([]*models.PriceSnapshot, error) {
+	query := `
+		SELECT id, symbol, snapshot_date, price, taken_at
+		FROM price_snapshots
+		WHERE symbol = $1 AND snapshot_date >= $2::date
+		ORDER BY snapshot_date;
+	`
+	rows, err := r.conn().QueryContext(ctx, query, symbol, since.UTC().Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price snapshots for %s: %w", symbol, err)
+	}
+	defer rows.Close()
+
+	var snapshots []*models.PriceSnapshot
+	for rows.Next() {
+		snapshot := &models.PriceSnapshot{}
+		if err := rows.Scan(&snapshot.ID, &snapshot.Symbol, &snapshot.SnapshotDate, &snapshot.Price, &snapshot.TakenAt); err != nil {
+			return nil, fmt.Errorf("failed to scan price snapshot row: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over price snapshot rows: %w", err)
+	}
+	return snapshots, nil
+}
+
+// --- Event Operations ---
+
+// CreateEvent appends an entry to the lifecycle event log.
+func (r *TradeRepository) CreateEvent(ctx context.Context, event *models.Event) error {
+	query := `
+		INSERT INTO events (type, details, created_at)
+		VALUES ($1, $2, $3)
+		RETURNING id;
+	`
+	if err := r.conn().QueryRowContext(ctx, query, event.Type, event.Details, event.CreatedAt).Scan(&event.ID); err != nil {
+		return fmt.Errorf("failed to create %s event in DB: %w", event.Type, err)
+	}
+	return nil
+}
+
+// GetRecentEvents fetches the limit most recent lifecycle events, newest first.
+func (r *TradeRepository) GetRecentEvents(ctx context.Context, limit int) ([]*models.Event, error) {
+	query := `
+		SELECT id, type, details, created_at
+		FROM events
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1;
+	`
+	rows, err := r.conn().QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %d recent events: %w", limit, err)
+	}
+	defer rows.Close()
+
+	var events []*models.Event
+	for rows.Next() {
+		event := &models.Event{}
+		if err := rows.Scan(&event.ID, &event.Type, &event.Details, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan event row: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over event rows: %w", err)
+	}
+	return events, nil
+}
+
 // --- BotState Operations ---
 
 // GetBotState fetches the single bot state row from the database.
@@ -301,11 +846,21 @@ func (r *TradeRepository) GetBotState(ctx context.Context) (*models.BotState, er
 			initial_usdt_investment,
 			current_usdt_balance,
 			current_btc_balance,
+			reserved_usdt,
+			open_position_quantity,
+			open_position_cost_basis,
 			total_usdt_invested,
 			total_usdt_profit,
+			total_usdt_withdrawn,
 			initial_buy_orders_placed_count,
 			last_initial_buy_order_placed_at,
+			last_initial_buy_order_id,
+			twap_slices_placed_count,
 			is_initial_buying_complete,
+			initialized,
+			funds_depleted,
+			paused,
+			total_orders_placed,
 			last_bot_run_timestamp,
 			created_at,
 			updated_at
@@ -313,17 +868,28 @@ func (r *TradeRepository) GetBotState(ctx context.Context) (*models.BotState, er
 		WHERE id = 1; -- We assume only one row with ID = 1
 	`
 	var lastInitialBuyOrderPlacedAt sql.NullTime
+	var lastInitialBuyOrderID sql.NullInt64
 
-	err := r.db.QueryRowContext(ctx, query).Scan(
+	err := r.conn().QueryRowContext(ctx, query).Scan(
 		&state.ID,
 		&state.InitialUSDTInvestment,
 		&state.CurrentUSDTBalance,
 		&state.CurrentBTCBalance,
+		&state.ReservedUSDT,
+		&state.OpenPositionQuantity,
+		&state.OpenPositionCostBasis,
 		&state.TotalUSDTInvested,
 		&state.TotalUSDTProfit,
+		&state.TotalUSDTWithdrawn,
 		&state.InitialBuyOrdersPlacedCount,
 		&lastInitialBuyOrderPlacedAt,
+		&lastInitialBuyOrderID,
+		&state.TWAPSlicesPlacedCount,
 		&state.IsInitialBuyingComplete,
+		&state.Initialized,
+		&state.FundsDepleted,
+		&state.Paused,
+		&state.TotalOrdersPlaced,
 		&state.LastBotRunTimestamp,
 		&state.CreatedAt,
 		&state.UpdatedAt,
@@ -338,10 +904,26 @@ func (r *TradeRepository) GetBotState(ctx context.Context) (*models.BotState, er
 	if lastInitialBuyOrderPlacedAt.Valid {
 		state.LastInitialBuyOrderPlacedAt = &lastInitialBuyOrderPlacedAt.Time
 	}
+	if lastInitialBuyOrderID.Valid {
+		state.LastInitialBuyOrderID = &lastInitialBuyOrderID.Int64
+	}
 
 	return state, nil
 }
 
+// GetTotalProfit reads the realized profit from the bot state row without loading the rest of it.
+func (r *TradeRepository) GetTotalProfit(ctx context.Context) (float64, error) {
+	var profit float64
+	query := `SELECT total_usdt_profit FROM bot_states WHERE id = 1;`
+	if err := r.conn().QueryRowContext(ctx, query).Scan(&profit); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("bot state not found (ID=1). Run migrations to initialize it")
+		}
+		return 0, fmt.Errorf("failed to get total profit: %w", err)
+	}
+	return profit, nil
+}
+
 // SaveBotState updates the existing bot state row in the database.
 // This function performs an UPSERT (UPDATE if exists, INSERT if not),
 // leveraging the `ON CONFLICT` clause in PostgreSQL for the bot_states table
@@ -353,26 +935,46 @@ func (r *TradeRepository) SaveBotState(ctx context.Context, state *models.BotSta
 			initial_usdt_investment,
 			current_usdt_balance,
 			current_btc_balance,
+			reserved_usdt,
+			open_position_quantity,
+			open_position_cost_basis,
 			total_usdt_invested,
 			total_usdt_profit,
+			total_usdt_withdrawn,
 			initial_buy_orders_placed_count,
 			last_initial_buy_order_placed_at,
+			last_initial_buy_order_id,
+			twap_slices_placed_count,
 			is_initial_buying_complete,
+			initialized,
+			funds_depleted,
+			paused,
+			total_orders_placed,
 			last_bot_run_timestamp,
 			created_at,
 			updated_at
 		) VALUES (
-			1, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+			1, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21
 		)
 		ON CONFLICT (id) DO UPDATE SET
 			initial_usdt_investment = EXCLUDED.initial_usdt_investment,
 			current_usdt_balance = EXCLUDED.current_usdt_balance,
 			current_btc_balance = EXCLUDED.current_btc_balance,
+			reserved_usdt = EXCLUDED.reserved_usdt,
+			open_position_quantity = EXCLUDED.open_position_quantity,
+			open_position_cost_basis = EXCLUDED.open_position_cost_basis,
 			total_usdt_invested = EXCLUDED.total_usdt_invested,
 			total_usdt_profit = EXCLUDED.total_usdt_profit,
+			total_usdt_withdrawn = EXCLUDED.total_usdt_withdrawn,
 			initial_buy_orders_placed_count = EXCLUDED.initial_buy_orders_placed_count,
 			last_initial_buy_order_placed_at = EXCLUDED.last_initial_buy_order_placed_at,
+			last_initial_buy_order_id = EXCLUDED.last_initial_buy_order_id,
+			twap_slices_placed_count = EXCLUDED.twap_slices_placed_count,
 			is_initial_buying_complete = EXCLUDED.is_initial_buying_complete,
+			initialized = EXCLUDED.initialized,
+			funds_depleted = EXCLUDED.funds_depleted,
+			paused = EXCLUDED.paused,
+			total_orders_placed = EXCLUDED.total_orders_placed,
 			last_bot_run_timestamp = EXCLUDED.last_bot_run_timestamp,
 			updated_at = EXCLUDED.updated_at;
 	`
@@ -382,22 +984,38 @@ func (r *TradeRepository) SaveBotState(ctx context.Context, state *models.BotSta
 		lastInitialBuyOrderPlacedAt.Valid = true
 	}
 
+	var lastInitialBuyOrderID sql.NullInt64
+	if state.LastInitialBuyOrderID != nil {
+		lastInitialBuyOrderID.Int64 = *state.LastInitialBuyOrderID
+		lastInitialBuyOrderID.Valid = true
+	}
+
 	// For the initial insert (if state.CreatedAt is zero), set it to NOW()
 	// For updates, use the existing state.CreatedAt
 	// However, the `ON CONFLICT` clause ensures `created_at` is only set once by the `INSERT`.
 	// The `updated_at` should always be set to `time.Now()` before calling this.
 
-	_, err := r.db.ExecContext(
+	_, err := r.conn().ExecContext(
 		ctx,
 		query,
 		state.InitialUSDTInvestment,
 		state.CurrentUSDTBalance,
 		state.CurrentBTCBalance,
+		state.ReservedUSDT,
+		state.OpenPositionQuantity,
+		state.OpenPositionCostBasis,
 		state.TotalUSDTInvested,
 		state.TotalUSDTProfit,
+		state.TotalUSDTWithdrawn,
 		state.InitialBuyOrdersPlacedCount,
 		lastInitialBuyOrderPlacedAt,
+		lastInitialBuyOrderID,
+		state.TWAPSlicesPlacedCount,
 		state.IsInitialBuyingComplete,
+		state.Initialized,
+		state.FundsDepleted,
+		state.Paused,
+		state.TotalOrdersPlaced,
 		state.LastBotRunTimestamp,
 		state.CreatedAt, // Use the existing CreatedAt
 		time.Now(),      // Always update UpdatedAt on save
@@ -407,3 +1025,20 @@ func (r *TradeRepository) SaveBotState(ctx context.Context, state *models.BotSta
 	}
 	return nil
 }
+
+// --- Schema Operations ---
+
+// GetMigrationVersion reads the schema version golang-migrate recorded in schema_migrations, over the
+// pool already in use. An empty table (no migration applied yet) reports version 0.
+func (r *TradeRepository) GetMigrationVersion(ctx context.Context) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := r.conn().QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1;`).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
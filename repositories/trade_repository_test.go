@@ -0,0 +1,457 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"binance-trader-bot/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+// newMockRepository returns a TradeRepository on a sqlmock database whose expectations match SQL by
+// regular expression and may be met in any order.
+func newMockRepository(t *testing.T) (*TradeRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	mock.MatchExpectationsInOrder(false)
+	t.Cleanup(func() { db.Close() })
+	return NewTradeRepository(db), mock
+}
+
+// botStateRows returns the state as the row GetBotState selects.
+func botStateRows(state *models.BotState) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "initial_usdt_investment", "current_usdt_balance", "current_btc_balance", "reserved_usdt",
+		"open_position_quantity", "open_position_cost_basis", "total_usdt_invested", "total_usdt_profit",
+		"total_usdt_withdrawn", "initial_buy_orders_placed_count", "last_initial_buy_order_placed_at",
+		"last_initial_buy_order_id", "twap_slices_placed_count", "is_initial_buying_complete", "initialized",
+		"funds_depleted", "paused", "total_orders_placed", "last_bot_run_timestamp", "created_at", "updated_at",
+	}).AddRow(1, state.InitialUSDTInvestment, state.CurrentUSDTBalance, state.CurrentBTCBalance, state.ReservedUSDT,
+		state.OpenPositionQuantity, state.OpenPositionCostBasis, state.TotalUSDTInvested, state.TotalUSDTProfit,
+		state.TotalUSDTWithdrawn, state.InitialBuyOrdersPlacedCount, nil,
+		nil, state.TWAPSlicesPlacedCount, state.IsInitialBuyingComplete, state.Initialized,
+		state.FundsDepleted, state.Paused, state.TotalOrdersPlaced, state.LastBotRunTimestamp, state.CreatedAt, state.UpdatedAt)
+}
+
+func TestGetTotalProfitMatchesBotState(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	ctx := context.Background()
+
+	saved := models.NewBotState(1000)
+	saved.UpdateInvestedAndProfit(0, 12.345678)
+	mock.ExpectQuery("SELECT total_usdt_profit FROM bot_states").
+		WillReturnRows(sqlmock.NewRows([]string{"total_usdt_profit"}).AddRow(saved.TotalUSDTProfit))
+	mock.ExpectQuery("FROM bot_states").WillReturnRows(botStateRows(saved))
+
+	profit, err := repo.GetTotalProfit(ctx)
+	if err != nil {
+		t.Fatalf("GetTotalProfit returned error: %v", err)
+	}
+	state, err := repo.GetBotState(ctx)
+	if err != nil {
+		t.Fatalf("GetBotState returned error: %v", err)
+	}
+	if profit != state.TotalUSDTProfit {
+		t.Errorf("GetTotalProfit = %v, want the bot state's %v", profit, state.TotalUSDTProfit)
+	}
+}
+
+func TestGetOpenTradeCountMatchesOpenTrades(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	ctx := context.Background()
+
+	tradeRows := sqlmock.NewRows([]string{
+		"id", "buy_order_id", "sell_order_id", "symbol", "buy_price", "buy_quantity", "sell_price_target",
+		"actual_sell_price", "status", "profit_usdt", "roi_percent", "opened_at", "closed_at", "last_status_update",
+		"error_reason", "reprice_count", "strategy_tag", "sell_profit_percentage",
+	})
+	now := time.Now()
+	for id := 1; id <= 3; id++ {
+		tradeRows.AddRow(id, int64(100+id), nil, "BTCUSDT", 29000.0, 0.00034, 29580.0,
+			nil, models.TradeStatusOpen, nil, nil, now, nil, now, nil, 0, "", 2.0)
+	}
+	mock.ExpectQuery("SELECT COUNT").WithArgs(models.TradeStatusOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("FROM trades").WithArgs(models.TradeStatusOpen).WillReturnRows(tradeRows)
+
+	count, err := repo.GetOpenTradeCount(ctx)
+	if err != nil {
+		t.Fatalf("GetOpenTradeCount returned error: %v", err)
+	}
+	trades, err := repo.GetTradesByStatus(ctx, models.TradeStatusOpen)
+	if err != nil {
+		t.Fatalf("GetTradesByStatus returned error: %v", err)
+	}
+	if count != len(trades) {
+		t.Errorf("GetOpenTradeCount = %d, want the %d open trades", count, len(trades))
+	}
+}
+
+func TestGetTotalProfitMissingState(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	mock.ExpectQuery("SELECT total_usdt_profit FROM bot_states").
+		WillReturnRows(sqlmock.NewRows([]string{"total_usdt_profit"}))
+
+	if _, err := repo.GetTotalProfit(context.Background()); err == nil {
+		t.Error("GetTotalProfit returned no error without a bot state row")
+	}
+}
+
+func TestArchiveOrdersBefore(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	cutoff := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Only terminal orders placed before the cutoff and referenced by no trade are moved
+	mock.ExpectExec(`DELETE FROM orders o\s+WHERE o.placed_at < \$1\s+AND o.status IN \(\$2, \$3, \$4, \$5\)\s+AND NOT EXISTS \(\s+SELECT 1 FROM trades t\s+WHERE t.buy_order_id = o.binance_id OR t.sell_order_id = o.binance_id`).
+		WithArgs(cutoff, models.OrderStatusFilled, models.OrderStatusCanceled, models.OrderStatusRejected, models.OrderStatusExpired).
+		WillReturnResult(sqlmock.NewResult(0, 7))
+
+	archived, err := repo.ArchiveOrdersBefore(context.Background(), cutoff)
+	if err != nil {
+		t.Fatalf("ArchiveOrdersBefore returned error: %v", err)
+	}
+	if archived != 7 {
+		t.Errorf("archived = %d, want 7", archived)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// capturedArg is a sqlmock argument matcher that accepts any value and remembers it.
+type capturedArg struct {
+	value driver.Value
+}
+
+func (c *capturedArg) Match(v driver.Value) bool {
+	c.value = v
+	return true
+}
+
+func TestErrorReasonRoundTrip(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	ctx := context.Background()
+
+	trade := models.NewTrade(28, "BTCUSDT", 29000, 0.001, 2)
+	trade.ID = 7
+	trade.MarkAsError("insufficient balance to place sell order")
+
+	reason := &capturedArg{}
+	anyArg := sqlmock.AnyArg()
+	mock.ExpectExec("UPDATE trades").
+		WithArgs(anyArg, anyArg, models.TradeStatusError, anyArg, anyArg, anyArg, reason, anyArg, anyArg, anyArg, anyArg, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.UpdateTrade(ctx, trade); err != nil {
+		t.Fatalf("UpdateTrade returned error: %v", err)
+	}
+	stored := reason.value
+	if stored != "insufficient balance to place sell order" {
+		t.Fatalf("stored error_reason = %v, want the reason", stored)
+	}
+
+	// Read the row back with the stored value
+	mock.ExpectQuery("FROM trades").WithArgs(models.TradeStatusError).WillReturnRows(sqlmock.NewRows([]string{
+		"id", "buy_order_id", "sell_order_id", "symbol", "buy_price", "buy_quantity", "sell_price_target",
+		"actual_sell_price", "status", "profit_usdt", "roi_percent", "opened_at", "closed_at", "last_status_update",
+		"error_reason", "reprice_count", "strategy_tag", "sell_profit_percentage",
+	}).AddRow(7, 28, nil, "BTCUSDT", 29000.0, 0.001, 29580.0, nil, models.TradeStatusError, nil, nil,
+		trade.OpenedAt, *trade.ClosedAt, trade.LastStatusUpdate, stored, 0, "", 2.0))
+	trades, err := repo.GetTradesByStatus(ctx, models.TradeStatusError)
+	if err != nil {
+		t.Fatalf("GetTradesByStatus returned error: %v", err)
+	}
+	if len(trades) != 1 || trades[0].ErrorReason == nil || *trades[0].ErrorReason != *trade.ErrorReason {
+		t.Errorf("read back trades = %+v, want the stored error reason", trades)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUpdateTradeWithoutErrorReasonStoresNull(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	trade := models.NewTrade(28, "BTCUSDT", 29000, 0.001, 2)
+	trade.ID = 7
+
+	reason := &capturedArg{}
+	anyArg := sqlmock.AnyArg()
+	mock.ExpectExec("UPDATE trades").
+		WithArgs(anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, reason, anyArg, anyArg, anyArg, anyArg, anyArg).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.UpdateTrade(context.Background(), trade); err != nil {
+		t.Fatalf("UpdateTrade returned error: %v", err)
+	}
+	if reason.value != nil {
+		t.Errorf("stored error_reason = %v, want NULL", reason.value)
+	}
+}
+
+func TestGetRecentTrades(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{
+		"id", "buy_order_id", "sell_order_id", "symbol", "buy_price", "buy_quantity", "sell_price_target",
+		"actual_sell_price", "status", "profit_usdt", "roi_percent", "opened_at", "closed_at", "last_status_update",
+		"error_reason", "reprice_count", "strategy_tag", "sell_profit_percentage",
+	}).
+		AddRow(9, 103, 203, "BTCUSDT", 29000.0, 0.001, 29580.0, 29580.0, models.TradeStatusSold, 0.58, 2.0,
+			now.Add(-time.Hour), now, now, nil, 0, "", 2.0).
+		AddRow(8, 102, nil, "BTCUSDT", 29100.0, 0.001, 29682.0, nil, models.TradeStatusOpen, nil, nil,
+			now.Add(-time.Hour), nil, now.Add(-time.Minute), nil, 0, "", 2.0)
+	mock.ExpectQuery(`FROM trades\s+ORDER BY last_status_update DESC, id DESC\s+LIMIT \$1`).
+		WithArgs(2).
+		WillReturnRows(rows)
+
+	trades, err := repo.GetRecentTrades(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("GetRecentTrades returned error: %v", err)
+	}
+	if len(trades) != 2 || trades[0].ID != 9 || trades[1].ID != 8 {
+		t.Fatalf("trades = %+v, want trades 9 and 8, most recently updated first", trades)
+	}
+	if trades[0].Status != models.TradeStatusSold || trades[1].Status != models.TradeStatusOpen {
+		t.Errorf("statuses = %s, %s, want every status included", trades[0].Status, trades[1].Status)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetTradeStatsBySymbolFiltersByTag(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	mock.ExpectQuery(`FROM trades\s+WHERE status IN \(\$1, \$2\) AND \(\$3 = '' OR strategy_tag = \$3\)`).
+		WithArgs(models.TradeStatusOpen, models.TradeStatusSold, "grid-a").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "open", "sold", "wins", "losses", "profit", "invested"}).
+			AddRow("BTCUSDT", 1, 2, 2, 0, 1.5, 60.0))
+
+	stats, err := repo.GetTradeStatsBySymbol(context.Background(), "grid-a")
+	if err != nil {
+		t.Fatalf("GetTradeStatsBySymbol returned error: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Symbol != "BTCUSDT" || stats[0].ClosedTrades != 2 {
+		t.Errorf("stats = %+v, want the BTCUSDT row for the tag", stats)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSavePriceSnapshot(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	takenAt := time.Date(2024, 3, 5, 0, 0, 7, 0, time.UTC)
+	snapshot := models.NewPriceSnapshot("BTCUSDT", 61000.5, takenAt)
+
+	mock.ExpectQuery("INSERT INTO price_snapshots").WithArgs("BTCUSDT", "2024-03-05", 61000.5, takenAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	inserted, err := repo.SavePriceSnapshot(context.Background(), snapshot)
+	if err != nil || !inserted || snapshot.ID != 3 {
+		t.Fatalf("SavePriceSnapshot = %v, %v with ID %d, want the snapshot inserted as 3", inserted, err, snapshot.ID)
+	}
+
+	// A second snapshot for the same day hits the conflict and returns no row
+	later := models.NewPriceSnapshot("BTCUSDT", 62000, takenAt.Add(time.Hour))
+	mock.ExpectQuery("ON CONFLICT \\(symbol, snapshot_date\\) DO NOTHING").WithArgs("BTCUSDT", "2024-03-05", 62000.0, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	inserted, err = repo.SavePriceSnapshot(context.Background(), later)
+	if err != nil || inserted {
+		t.Errorf("SavePriceSnapshot for the same day = %v, %v, want not inserted without error", inserted, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetPriceSnapshots(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	day1 := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	mock.ExpectQuery("FROM price_snapshots").WithArgs("BTCUSDT", "2024-03-04").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "symbol", "snapshot_date", "price", "taken_at"}).
+			AddRow(2, "BTCUSDT", day1, 60000.0, day1.Add(time.Minute)).
+			AddRow(3, "BTCUSDT", day2, 61000.5, day2.Add(7*time.Second)))
+
+	snapshots, err := repo.GetPriceSnapshots(context.Background(), "BTCUSDT", day1.Add(15*time.Hour))
+	if err != nil {
+		t.Fatalf("GetPriceSnapshots returned error: %v", err)
+	}
+	if len(snapshots) != 2 || !snapshots[0].SnapshotDate.Equal(day1) || snapshots[1].Price != 61000.5 {
+		t.Errorf("snapshots = %+v, want the days 2024-03-04 and 2024-03-05, oldest first", snapshots)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateEvent(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	event := models.NewEvent(models.EventHalt, "3 consecutive trading cycles failed")
+
+	mock.ExpectQuery("INSERT INTO events").WithArgs(models.EventHalt, "3 consecutive trading cycles failed", event.CreatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+	if err := repo.CreateEvent(context.Background(), event); err != nil {
+		t.Fatalf("CreateEvent returned error: %v", err)
+	}
+	if event.ID != 12 {
+		t.Errorf("event ID = %d, want 12", event.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetRecentEvents(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	now := time.Now()
+	mock.ExpectQuery(`FROM events\s+ORDER BY created_at DESC, id DESC\s+LIMIT \$1`).WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "details", "created_at"}).
+			AddRow(13, models.EventStop, "shutdown signal received", now).
+			AddRow(12, models.EventStart, "BTCUSDT started", now.Add(-time.Hour)))
+
+	events, err := repo.GetRecentEvents(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("GetRecentEvents returned error: %v", err)
+	}
+	if len(events) != 2 || events[0].Type != models.EventStop || events[1].Type != models.EventStart {
+		t.Errorf("events = %+v, want STOP then START, newest first", events)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUpdateTradeStoresROI(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	trade := models.NewTrade(28, "BTCUSDT", 30000, 0.001, 2)
+	trade.ID = 7
+	trade.MarkAsSold(30600, models.DefaultQuotePrecision)
+
+	roi := &capturedArg{}
+	anyArg := sqlmock.AnyArg()
+	mock.ExpectExec("roi_percent = \\$11").
+		WithArgs(anyArg, anyArg, models.TradeStatusSold, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, roi, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.UpdateTrade(context.Background(), trade); err != nil {
+		t.Fatalf("UpdateTrade returned error: %v", err)
+	}
+	if stored, ok := roi.value.(float64); !ok || stored != *trade.ROIPercent {
+		t.Errorf("stored roi_percent = %v, want %v", roi.value, *trade.ROIPercent)
+	}
+}
+
+func TestCreateOrderDuplicateBinanceID(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	order := models.NewOrder(28, "BTCUSDT", models.OrderTypeBuy, 29700, 0.00067, 20, models.OrderStatusFilled, false)
+
+	mock.ExpectQuery("INSERT INTO orders").
+		WillReturnError(&pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "orders_binance_id_key"`})
+	mock.ExpectQuery("UPDATE orders").
+		WithArgs(models.OrderStatusFilled, sqlmock.AnyArg(), sqlmock.AnyArg(), int64(28)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+
+	if err := repo.CreateOrder(context.Background(), order); err != nil {
+		t.Fatalf("CreateOrder returned error for an already stored order: %v", err)
+	}
+	if order.ID != 5 {
+		t.Errorf("order ID = %d, want the stored row's 5", order.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateOrderOtherErrorsFail(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	order := models.NewOrder(28, "BTCUSDT", models.OrderTypeBuy, 29700, 0.00067, 20, models.OrderStatusNew, false)
+
+	mock.ExpectQuery("INSERT INTO orders").
+		WillReturnError(&pq.Error{Code: "23502", Message: `null value in column "symbol" violates not-null constraint`})
+	err := repo.CreateOrder(context.Background(), order)
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23502" {
+		t.Errorf("CreateOrder error = %v, want the not-null violation", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetSymbolsWithOpenTrades(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	mock.ExpectQuery("SELECT DISTINCT symbol FROM trades WHERE status = \\$1 ORDER BY symbol").WithArgs(models.TradeStatusOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("BTCUSDT").AddRow("ETHUSDT"))
+	mock.ExpectQuery("SELECT DISTINCT symbol").WithArgs(models.TradeStatusOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}))
+
+	symbols, err := repo.GetSymbolsWithOpenTrades(context.Background())
+	if err != nil {
+		t.Fatalf("GetSymbolsWithOpenTrades returned error: %v", err)
+	}
+	if len(symbols) != 2 || symbols[0] != "BTCUSDT" || symbols[1] != "ETHUSDT" {
+		t.Errorf("symbols = %v, want [BTCUSDT ETHUSDT]", symbols)
+	}
+
+	// No open trades is an empty list, which /status encodes as [] rather than null
+	symbols, err = repo.GetSymbolsWithOpenTrades(context.Background())
+	if err != nil || symbols == nil || len(symbols) != 0 {
+		t.Errorf("symbols = %#v (%v), want an empty list", symbols, err)
+	}
+}
+
+func TestUpsertOrdersMixedBatch(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	stored := models.NewOrder(28, "BTCUSDT", models.OrderTypeBuy, 29700, 0.00067, 20, models.OrderStatusNew, false)
+	placed := models.NewOrder(30, "BTCUSDT", models.OrderTypeSell, 30300, 0.00067, 0, models.OrderStatusNew, false)
+	filled := models.NewOrder(28, "BTCUSDT", models.OrderTypeBuy, 29700, 0.00067, 20, models.OrderStatusFilled, false)
+
+	// Order 28 appears twice: one row carrying its last (FILLED) state, plus the new order 30
+	anyArg := sqlmock.AnyArg()
+	row := func(binanceID int64, status models.OrderStatus) []driver.Value {
+		return []driver.Value{binanceID, anyArg, anyArg, anyArg, anyArg, anyArg, status, anyArg, anyArg, anyArg, anyArg, anyArg}
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO orders .* ON CONFLICT \\(binance_id\\) DO UPDATE").
+		WithArgs(append(row(28, models.OrderStatusFilled), row(30, models.OrderStatusNew)...)...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "binance_id"}).AddRow(5, 28).AddRow(9, 30))
+	mock.ExpectCommit()
+
+	if err := repo.UpsertOrders(context.Background(), []*models.Order{stored, placed, filled}); err != nil {
+		t.Fatalf("UpsertOrders returned error: %v", err)
+	}
+	if stored.ID != 5 || filled.ID != 5 || placed.ID != 9 {
+		t.Errorf("IDs = %d, %d, %d, want 5 for both copies of order 28 and 9 for order 30", stored.ID, filled.ID, placed.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUpsertOrdersChunksAndRollsBack(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	orders := make([]*models.Order, upsertOrdersChunk+1)
+	for i := range orders {
+		orders[i] = models.NewOrder(int64(i+1), "BTCUSDT", models.OrderTypeBuy, 29700, 0.00067, 20, models.OrderStatusNew, false)
+	}
+
+	// The first statement is full, the second fails and the whole batch is rolled back
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id", "binance_id"}))
+	mock.ExpectQuery("INSERT INTO orders").WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	if err := repo.UpsertOrders(context.Background(), orders); err == nil {
+		t.Fatal("UpsertOrders returned no error for a failed statement")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
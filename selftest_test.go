@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"binance-trader-bot/repositories"
+	"binance-trader-bot/services"
+	"binance-trader-bot/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const selfTestExchangeInfo = `{"symbols":[{"symbol":"BTCUSDT","status":"TRADING","baseAsset":"BTC","quoteAsset":"USDT",
+"filters":[{"filterType":"LOT_SIZE","minQty":"0.00001000","maxQty":"9000.00000000","stepSize":"0.00001000"},
+{"filterType":"NOTIONAL","minNotional":"5.00000000"},{"filterType":"MAX_NUM_ORDERS","maxNumOrders":200}]}]}`
+
+// newSelfTestBinance returns a BinanceService backed by a fake server answering each path in
+// responses with its body, and every other path with a Binance API error.
+func newSelfTestBinance(t *testing.T, responses map[string]string) *services.BinanceService {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			body = `{"code":-1121,"msg":"Invalid symbol."}`
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	s := services.NewBinanceService("test-key", "test-secret", false, false, utils.NewLogger())
+	s.SetBaseURL(server.URL)
+	return s
+}
+
+func TestCheckDatabase(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    *sqlmock.Rows
+		err     error
+		wantErr bool
+		want    string
+	}{
+		{"migrated", sqlmock.NewRows([]string{"version", "dirty"}).AddRow(12, false), nil, false, "migration 12"},
+		{"fresh", sqlmock.NewRows([]string{"version", "dirty"}), nil, false, "no migrations applied"},
+		{"dirty", sqlmock.NewRows([]string{"version", "dirty"}).AddRow(12, true), nil, true, ""},
+		{"query fails", nil, errors.New("connection refused"), true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %v", err)
+			}
+			defer db.Close()
+			query := mock.ExpectQuery("FROM schema_migrations")
+			if tt.err != nil {
+				query.WillReturnError(tt.err)
+			} else {
+				query.WillReturnRows(tt.rows)
+			}
+
+			detail, err := checkDatabase(context.Background(), repositories.NewTradeRepository(db))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkDatabase error = %v, want error %t", err, tt.wantErr)
+			}
+			if !strings.Contains(detail, tt.want) {
+				t.Errorf("checkDatabase detail = %q, want it to mention %q", detail, tt.want)
+			}
+		})
+	}
+}
+
+func TestBinanceChecks(t *testing.T) {
+	ok := newSelfTestBinance(t, map[string]string{
+		"/api/v3/ping":         `{}`,
+		"/api/v3/account":      `{"balances":[{"asset":"USDT","free":"100.00000000","locked":"0.00000000"}]}`,
+		"/api/v3/ticker/price": `{"symbol":"BTCUSDT","price":"30000.00000000"}`,
+		"/api/v3/exchangeInfo": selfTestExchangeInfo,
+	})
+	failing := newSelfTestBinance(t, nil)
+
+	tests := []struct {
+		name  string
+		check func(ctx context.Context, s *services.BinanceService) (string, error)
+		want  string
+	}{
+		{"ping", checkBinancePing, "reachable"},
+		{"credentials", checkCredentials, "USDT balance 100"},
+		{"price", func(ctx context.Context, s *services.BinanceService) (string, error) {
+			return checkPrice(ctx, s, "BTCUSDT")
+		}, "BTCUSDT at 30000"},
+		{"symbol filters", func(ctx context.Context, s *services.BinanceService) (string, error) {
+			return checkSymbolFilters(ctx, s, "BTCUSDT")
+		}, "max orders 200"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detail, err := tt.check(context.Background(), ok)
+			if err != nil {
+				t.Fatalf("check returned error: %v", err)
+			}
+			if !strings.Contains(detail, tt.want) {
+				t.Errorf("detail = %q, want it to mention %q", detail, tt.want)
+			}
+			if _, err := tt.check(context.Background(), failing); err == nil {
+				t.Error("check passed against a failing Binance")
+			}
+		})
+	}
+}
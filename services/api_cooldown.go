@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"binance-trader-bot/utils"
+)
+
+// ErrAPICooldown is returned, without contacting Binance, for REST requests made while the bot is
+// cooling down after a rate-limit, ban or authentication error.
+var ErrAPICooldown = errors.New("binance API cooldown in effect")
+
+// cooldownTransport pauses all REST traffic after Binance answers 429 (rate limited), 418 (IP banned)
+// or 401 (key rejected). Retrying straight away only extends a ban or risks locking the key, so
+// requests fail fast until the cooldown, or the Retry-After Binance sent if longer, has passed.
+type cooldownTransport struct {
+	base     http.RoundTripper
+	cooldown time.Duration
+	logger   *utils.Logger
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (t *cooldownTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if remaining := t.remaining(); remaining > 0 {
+		return nil, fmt.Errorf("%w for another %s", ErrAPICooldown, remaining.Round(time.Second))
+	}
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusTeapot, http.StatusUnauthorized:
+		wait := t.cooldown
+		if seconds, parseErr := strconv.Atoi(res.Header.Get("Retry-After")); parseErr == nil && time.Duration(seconds)*time.Second > wait {
+			wait = time.Duration(seconds) * time.Second
+		}
+		t.mu.Lock()
+		if until := time.Now().Add(wait); until.After(t.until) {
+			t.until = until
+		}
+		t.mu.Unlock()
+		t.logger.Errorf("Binance answered %d to %s. Pausing all API requests for %s.", res.StatusCode, req.URL.Path, wait)
+	}
+	return res, nil
+}
+
+// remaining returns how long the cooldown still lasts, or 0 if requests may be sent.
+func (t *cooldownTransport) remaining() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if remaining := time.Until(t.until); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
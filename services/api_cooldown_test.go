@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAPICooldownPausesRequests(t *testing.T) {
+	fake := newFakeBinance(t)
+	fake.respond("GET /api/v3/ticker/price", http.StatusTooManyRequests, `{"code":-1003,"msg":"Too many requests."}`)
+	service := fake.service()
+	service.SetAPICooldown(100 * time.Millisecond)
+	ctx := context.Background()
+
+	if _, err := service.GetCurrentPrice(ctx, "BTCUSDT"); err == nil {
+		t.Fatal("GetCurrentPrice returned no error for a rate-limited request")
+	}
+	if remaining := service.CooldownRemaining(); remaining <= 0 || remaining > 100*time.Millisecond {
+		t.Fatalf("CooldownRemaining = %s, want the 100ms cooldown running", remaining)
+	}
+
+	// During the cooldown every request fails fast without reaching Binance
+	fake.fixture("GET /api/v3/ticker/price", "ticker_price.json", http.StatusOK)
+	if _, err := service.GetCurrentPrice(ctx, "BTCUSDT"); !errors.Is(err, ErrAPICooldown) {
+		t.Errorf("GetCurrentPrice during cooldown error = %v, want ErrAPICooldown", err)
+	}
+	if _, err := service.GetAccountBalance(ctx, "USDT"); !errors.Is(err, ErrAPICooldown) {
+		t.Errorf("GetAccountBalance during cooldown error = %v, want ErrAPICooldown", err)
+	}
+	if calls := len(fake.calls("GET /api/v3/ticker/price")) + len(fake.calls("GET /api/v3/account")); calls != 1 {
+		t.Errorf("Binance received %d requests, want only the rate-limited one", calls)
+	}
+
+	// Activity resumes once the cooldown has passed
+	time.Sleep(150 * time.Millisecond)
+	if price, err := service.GetCurrentPrice(ctx, "BTCUSDT"); err != nil || price != 30000 {
+		t.Errorf("GetCurrentPrice after cooldown = %v, %v, want 30000", price, err)
+	}
+}
+
+func TestAPICooldownHonoursRetryAfter(t *testing.T) {
+	fake := newFakeBinance(t)
+	fake.handle("GET /api/v3/ticker/price", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte(`{"code":-1003,"msg":"Way too many requests; IP banned."}`))
+	})
+	service := fake.service()
+	service.SetAPICooldown(time.Second)
+
+	service.GetCurrentPrice(context.Background(), "BTCUSDT")
+	if remaining := service.CooldownRemaining(); remaining < 119*time.Second {
+		t.Errorf("CooldownRemaining = %s, want the 120s ban expiry", remaining)
+	}
+}
+
+func TestAPICooldownIgnoresOtherErrors(t *testing.T) {
+	fake := newFakeBinance(t)
+	fake.fixture("GET /api/v3/ticker/price", "error_invalid_symbol.json", http.StatusBadRequest)
+	service := fake.service()
+	service.SetAPICooldown(time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.GetCurrentPrice(context.Background(), "BTCUSDT"); errors.Is(err, ErrAPICooldown) {
+			t.Fatalf("request %d refused by a cooldown after a non-throttling error", i+1)
+		}
+	}
+	if remaining := service.CooldownRemaining(); remaining != 0 {
+		t.Errorf("CooldownRemaining = %s, want no cooldown", remaining)
+	}
+}
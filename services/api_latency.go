@@ -0,0 +1,32 @@
+package services
+
+import (
+	"net/http"
+	"time"
+
+	"binance-trader-bot/metrics"
+	"binance-trader-bot/utils"
+)
+
+// latencyTransport times every REST request sent to Binance, logging it at DEBUG and recording it
+// in the metrics registry under its operation, the HTTP method and path (e.g. "POST /api/v3/order").
+type latencyTransport struct {
+	base    http.RoundTripper
+	metrics *metrics.Registry
+	logger  *utils.Logger
+}
+
+func (t *latencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation := req.Method + " " + req.URL.Path
+	start := time.Now()
+	res, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	t.metrics.ObserveBinanceLatency(operation, elapsed)
+	if err != nil {
+		t.logger.Debugf("Binance %s failed after %s: %v", operation, elapsed, err)
+	} else {
+		t.logger.Debugf("Binance %s answered %d in %s", operation, res.StatusCode, elapsed)
+	}
+	return res, err
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"binance-trader-bot/metrics"
+)
+
+func TestSetMetricsTimesRequests(t *testing.T) {
+	fake := newFakeBinance(t)
+	service := fake.service()
+	registry := metrics.NewRegistry()
+	service.SetMetrics(registry)
+	service.SetAPICooldown(time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.GetCurrentPrice(ctx, "BTCUSDT"); err != nil {
+			t.Fatalf("GetCurrentPrice returned error: %v", err)
+		}
+	}
+	// A rate-limited request is timed; the ones refused during the cooldown never reach Binance
+	fake.respond("GET /api/v3/account", http.StatusTooManyRequests, `{"code":-1003,"msg":"Too many requests."}`)
+	for i := 0; i < 2; i++ {
+		if _, err := service.GetAccountBalance(ctx, "USDT"); err == nil {
+			t.Fatal("GetAccountBalance returned no error for a rate-limited request")
+		}
+	}
+
+	var out strings.Builder
+	if err := registry.WriteText(&out); err != nil {
+		t.Fatalf("WriteText returned error: %v", err)
+	}
+	for _, want := range []string{
+		`binance_request_duration_seconds_count{operation="GET /api/v3/ticker/price"} 2`,
+		`binance_request_duration_seconds_count{operation="GET /api/v3/account"} 1`,
+	} {
+		if !strings.Contains(out.String(), want+"\n") {
+			t.Errorf("metrics missing line %q:\n%s", want, out.String())
+		}
+	}
+}
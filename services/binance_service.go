@@ -2,26 +2,107 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"binance-trader-bot/indicators"
+	"binance-trader-bot/metrics"
 	"binance-trader-bot/models" // Importar los modelos definidos
 	"binance-trader-bot/utils"  // Importar el logger
 
 	"github.com/adshao/go-binance/v2" // Cliente de Binance para Spot trading
-	"github.com/shopspring/decimal"   // Para manejar floats de forma precisa en cálculos financieros
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/shopspring/decimal" // Para manejar floats de forma precisa en cálculos financieros
 )
 
 // BinanceService provides an interface for interacting with the Binance API.
 type BinanceService struct {
-	client  *binance.Client // Changed to *binance.Client
-	testnet bool
-	logger  *utils.Logger
+	client               *binance.Client // Changed to *binance.Client
+	testnet              bool
+	conservativeRounding bool // Round buy prices down and sell prices up instead of to the nearest tick
+	logger               *utils.Logger
+
+	validateBeforePlacing bool    // Run each order through the test endpoint before placing it
+	retryLotSize          bool    // Retry a limit order rejected for LOT_SIZE once with a re-floored quantity
+	maxPriceDeviation     float64 // Reject buys above / sells below market by more than this percentage (0 disables)
+	defaultPricePrecision int     // Decimals used for prices when PRICE_FILTER is missing (-1 fails instead)
+	defaultQtyPrecision   int     // Decimals used for quantities when LOT_SIZE is missing (-1 fails instead)
+
+	symbolInfoMu    sync.Mutex
+	symbolInfoCache map[string]*cachedSymbolInfo // Exchange info (filters, precision) per symbol
+
+	commissionMu    sync.Mutex
+	commissionRates *CommissionRates // Fetched once from the account, nil until then
+
+	cooldown *cooldownTransport // Pauses REST requests after rate-limit, ban or auth errors (nil disables)
+}
+
+// MispricedOrderError is returned by PlaceLimitOrder when a buy is priced above the market, or a sell
+// below it, by more than the configured deviation. Such an order would fill at once at a worse price
+// and usually means a calculation bug.
+type MispricedOrderError struct {
+	Side           models.OrderType
+	Price          float64
+	MarketPrice    float64
+	MaxDeviationPc float64
 }
 
-func NewBinanceService(apiKey, secretKey string, useTestnet bool, logger *utils.Logger) *BinanceService {
+func (e *MispricedOrderError) Error() string {
+	return fmt.Sprintf("%s limit price %f is more than %.2f%% on the wrong side of market price %f",
+		e.Side, e.Price, e.MaxDeviationPc, e.MarketPrice)
+}
+
+// PriceOutsideBandError is returned by PlaceLimitOrder when the price falls outside the symbol's
+// PERCENT_PRICE band around the average price. Binance would reject the order, so it is skipped.
+type PriceOutsideBandError struct {
+	Side         models.OrderType
+	Price        float64
+	AveragePrice float64
+	Low          float64
+	High         float64
+}
+
+func (e *PriceOutsideBandError) Error() string {
+	return fmt.Sprintf("%s limit price %f is outside the allowed band [%f, %f] around average price %f",
+		e.Side, e.Price, e.Low, e.High, e.AveragePrice)
+}
+
+// percentPriceBand holds the PERCENT_PRICE / PERCENT_PRICE_BY_SIDE multipliers applied to the
+// average price to get the range of prices Binance accepts for each side.
+type percentPriceBand struct {
+	bidUp, bidDown float64
+	askUp, askDown float64
+}
+
+// CommissionRates are the account's trading fees, as percentages of the traded amount.
+type CommissionRates struct {
+	Maker float64
+	Taker float64
+}
+
+// cachedSymbolInfo is an exchange info entry with the time it was fetched.
+type cachedSymbolInfo struct {
+	info      *binance.Symbol
+	priceBand *percentPriceBand // Parsed PERCENT_PRICE filter, nil if the symbol has none
+	fetchedAt time.Time
+}
+
+// symbolInfoCacheTTL is how long exchange info is reused before being fetched again.
+const symbolInfoCacheTTL = time.Hour
+
+// symbolStatusMaxAge is how old cached exchange info may be when checking whether a symbol is trading,
+// so a maintenance break is noticed within minutes rather than after symbolInfoCacheTTL.
+const symbolStatusMaxAge = 5 * time.Minute
+
+// symbolStatusTrading is the exchange info status of a symbol open for trading.
+const symbolStatusTrading = "TRADING"
+
+func NewBinanceService(apiKey, secretKey string, useTestnet bool, conservativeRounding bool, logger *utils.Logger) *BinanceService {
 	var client *binance.Client
 	if useTestnet {
 		client = binance.NewClient(apiKey, secretKey)
@@ -31,10 +112,137 @@ func NewBinanceService(apiKey, secretKey string, useTestnet bool, logger *utils.
 	}
 
 	return &BinanceService{
-		client:  client,
-		testnet: useTestnet,
-		logger:  logger,
+		client:                client,
+		testnet:               useTestnet,
+		conservativeRounding:  conservativeRounding,
+		logger:                logger,
+		defaultPricePrecision: -1,
+		defaultQtyPrecision:   -1,
+		symbolInfoCache:       make(map[string]*cachedSymbolInfo),
+	}
+}
+
+// SetDefaultPrecision sets the number of decimals used for prices and quantities when exchange info
+// lacks PRICE_FILTER or LOT_SIZE. A negative value keeps failing the order in that case.
+func (s *BinanceService) SetDefaultPrecision(pricePrecision, qtyPrecision int) {
+	s.defaultPricePrecision = pricePrecision
+	s.defaultQtyPrecision = qtyPrecision
+}
+
+// fallbackIncrement returns the increment for a configured precision, e.g. 2 -> "0.01", warning loudly
+// because orders may be rejected if the guess is wrong. It returns "" when no fallback is configured.
+func (s *BinanceService) fallbackIncrement(symbol, filterType string, precision int) string {
+	if precision < 0 {
+		return ""
+	}
+	increment := decimal.New(1, -int32(precision)).String()
+	s.logger.Warnf("!!! %s missing from exchange info for %s. Falling back to configured precision %d (increment %s). !!!",
+		filterType, symbol, precision, increment)
+	return increment
+}
+
+// SetMaxPriceDeviation sets how far, in percent, a limit buy may be above the market or a limit sell
+// below it before PlaceLimitOrder rejects it. Zero disables the check.
+func (s *BinanceService) SetMaxPriceDeviation(percentage float64) {
+	s.maxPriceDeviation = percentage
+}
+
+// checkLimitPrice rejects limit orders priced on the wrong side of the market beyond maxPriceDeviation.
+func (s *BinanceService) checkLimitPrice(ctx context.Context, symbol string, orderType models.OrderType, price float64) error {
+	if s.maxPriceDeviation <= 0 {
+		return nil
+	}
+	marketPrice, err := s.GetCurrentPrice(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to check limit price against market: %w", err)
+	}
+
+	deviation := s.maxPriceDeviation / 100
+	if (orderType == models.OrderTypeBuy && price > marketPrice*(1+deviation)) ||
+		(orderType == models.OrderTypeSell && price < marketPrice*(1-deviation)) {
+		err := &MispricedOrderError{Side: orderType, Price: price, MarketPrice: marketPrice, MaxDeviationPc: s.maxPriceDeviation}
+		s.logger.Errorf("Rejecting order: %v", err)
+		return err
+	}
+	return nil
+}
+
+// SetValidateBeforePlacing makes every order be sent to Binance's test endpoint first, and only be
+// placed for real if the exchange accepts it.
+func (s *BinanceService) SetValidateBeforePlacing(enabled bool) {
+	s.validateBeforePlacing = enabled
+}
+
+// SetRetryLotSizeRejections makes PlaceLimitOrder retry an order Binance rejects for LOT_SIZE once,
+// with the quantity floored onto the step grid counted from minQty.
+func (s *BinanceService) SetRetryLotSizeRejections(enabled bool) {
+	s.retryLotSize = enabled
+}
+
+// TestOrder validates an order against Binance's /api/v3/order/test endpoint. The exchange checks
+// it as if it were placed (filters, balance-independent rules, signature) but does not send it to
+// the matching engine.
+func (s *BinanceService) TestOrder(ctx context.Context, orderService *binance.CreateOrderService) error {
+	if err := orderService.Test(ctx); err != nil {
+		return fmt.Errorf("order rejected by Binance test endpoint: %w", err)
+	}
+	return nil
+}
+
+// createOrder places the order, running it through TestOrder first when VALIDATE_BEFORE_PLACING is set.
+func (s *BinanceService) createOrder(ctx context.Context, orderService *binance.CreateOrderService) (*binance.CreateOrderResponse, error) {
+	if s.validateBeforePlacing {
+		if err := s.TestOrder(ctx, orderService); err != nil {
+			return nil, err
+		}
+	}
+	return orderService.Do(ctx)
+}
+
+// SetMetrics times every REST request to Binance and records the latency in registry. Call it before
+// SetAPICooldown so requests refused during a cooldown, which never reach Binance, are not timed.
+func (s *BinanceService) SetMetrics(registry *metrics.Registry) {
+	base := http.DefaultTransport
+	if s.client.HTTPClient != nil && s.client.HTTPClient.Transport != nil {
+		base = s.client.HTTPClient.Transport
+	}
+	s.client.HTTPClient = &http.Client{Transport: &latencyTransport{base: base, metrics: registry, logger: s.logger}}
+}
+
+// SetAPICooldown pauses all REST requests for at least cooldown after Binance rejects one for rate
+// limiting, an IP ban or a bad key, honouring a longer Retry-After. Zero disables the pause.
+func (s *BinanceService) SetAPICooldown(cooldown time.Duration) {
+	if cooldown <= 0 {
+		return
+	}
+	base := http.DefaultTransport
+	if s.client.HTTPClient != nil && s.client.HTTPClient.Transport != nil {
+		base = s.client.HTTPClient.Transport
+	}
+	s.cooldown = &cooldownTransport{base: base, cooldown: cooldown, logger: s.logger}
+	s.client.HTTPClient = &http.Client{Transport: s.cooldown}
+}
+
+// CooldownRemaining returns how long REST requests stay paused after a rate-limit, ban or auth error.
+func (s *BinanceService) CooldownRemaining() time.Duration {
+	if s.cooldown == nil {
+		return 0
+	}
+	return s.cooldown.remaining()
+}
+
+// SetBaseURL points the client at a different REST endpoint, such as a local fake of the Binance API.
+func (s *BinanceService) SetBaseURL(baseURL string) {
+	s.logger.Warnf("Using custom Binance REST endpoint: %s", baseURL)
+	s.client.BaseURL = baseURL
+}
+
+// Ping checks that the Binance REST API is reachable.
+func (s *BinanceService) Ping(ctx context.Context) error {
+	if err := s.client.NewPingService().Do(ctx); err != nil {
+		return fmt.Errorf("failed to ping Binance: %w", err)
 	}
+	return nil
 }
 
 // GetCurrentPrice fetches the current market price for a given symbol.
@@ -60,23 +268,46 @@ func (s *BinanceService) GetCurrentPrice(ctx context.Context, symbol string) (fl
 	return price, nil
 }
 
-// PlaceLimitOrder places a limit order on Binance.
-func (s *BinanceService) PlaceLimitOrder(ctx context.Context, symbol string, orderType models.OrderType, price float64, quantity float64) (*models.Order, error) {
-	s.logger.Infof("Attempting to place %s limit order for %f %s at price %f", orderType, quantity, symbol, price)
+// GetAveragePrice fetches Binance's current average price (5-minute weighted average) for a given symbol.
+func (s *BinanceService) GetAveragePrice(ctx context.Context, symbol string) (float64, error) {
+	s.logger.Debugf("Fetching average price for %s...", symbol)
+	res, err := s.client.NewAveragePriceService().Symbol(symbol).Do(ctx)
+	if err != nil {
+		s.logger.Errorf("Failed to get average price for %s: %v", symbol, err)
+		return 0, fmt.Errorf("failed to get average price: %w", err)
+	}
+
+	price, err := strconv.ParseFloat(res.Price, 64)
+	if err != nil {
+		s.logger.Errorf("Failed to parse average price '%s': %v", res.Price, err)
+		return 0, fmt.Errorf("failed to parse average price: %w", err)
+	}
+
+	s.logger.Debugf("Average price for %s over %d minutes: %f", symbol, res.Mins, price)
+	return price, nil
+}
+
+// PlaceLimitOrder places a limit order on Binance with the given time in force ("GTC", "IOC" or "FOK";
+// empty uses GTC).
+func (s *BinanceService) PlaceLimitOrder(ctx context.Context, symbol string, orderType models.OrderType, price float64, quantity float64, timeInForce string) (*models.Order, error) {
+	if timeInForce == "" {
+		timeInForce = string(binance.TimeInForceTypeGTC)
+	}
+	s.logger.Infof("Attempting to place %s %s limit order for %f %s at price %f", orderType, timeInForce, quantity, symbol, price)
+
+	if err := s.checkLimitPrice(ctx, symbol, orderType, price); err != nil {
+		return nil, err
+	}
 
 	// Convert price and quantity to Decimal for precision
 	priceDec := decimal.NewFromFloat(price)
 	quantityDec := decimal.NewFromFloat(quantity)
 
 	// Retrieve exchange info to get lot size and price filter rules for the symbol
-	exchangeInfo, err := s.client.NewExchangeInfoService().Symbol(symbol).Do(ctx)
+	symbolInfo, err := s.getSymbolInfo(ctx, symbol)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get exchange info for %s: %w", symbol, err)
+		return nil, err
 	}
-	if len(exchangeInfo.Symbols) == 0 {
-		return nil, fmt.Errorf("exchange info not found for symbol %s", symbol)
-	}
-	symbolInfo := exchangeInfo.Symbols[0]
 
 	// Apply Filters
 	var tickSize, stepSize string
@@ -104,37 +335,69 @@ func (s *BinanceService) PlaceLimitOrder(ctx context.Context, symbol string, ord
 		}
 	}
 
+	if tickSize == "" {
+		tickSize = s.fallbackIncrement(symbol, "PRICE_FILTER", s.defaultPricePrecision)
+	}
+	if stepSize == "" {
+		stepSize = s.fallbackIncrement(symbol, "LOT_SIZE", s.defaultQtyPrecision)
+	}
 	if tickSize == "" || stepSize == "" {
 		return nil, fmt.Errorf("could not find PRICE_FILTER or LOT_SIZE filter for symbol %s", symbol)
 	}
 
-	// Calculate decimal places for rounding
-	pricePrecision := countDecimalPlaces(tickSize)
-	quantityPrecision := countDecimalPlaces(stepSize)
+	tickSizeDec, err := decimal.NewFromString(tickSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tickSize '%s' for symbol %s: %w", tickSize, symbol, err)
+	}
+	stepSizeDec, err := decimal.NewFromString(stepSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stepSize '%s' for symbol %s: %w", stepSize, symbol, err)
+	}
 
 	// --- ESTAS SON LAS LÍNEAS CLAVE QUE DEBEN ESTAR DECLARADAS AQUÍ ---
-	// Round price and quantity according to exchange rules
-	roundedPrice := priceDec.Round(int32(pricePrecision))
-	roundedQuantity := quantityDec.Round(int32(quantityPrecision))
+	// Round price and quantity according to exchange rules.
+	// Quantity is always rounded down so we never ask for more than the balance covers.
+	priceMode := roundNearest
+	if s.conservativeRounding {
+		priceMode = roundDown
+		if orderType == models.OrderTypeSell {
+			priceMode = roundUp
+		}
+	}
+	roundedPrice := roundToIncrement(priceDec, tickSizeDec, priceMode)
+	roundedQuantity := roundToIncrement(quantityDec, stepSizeDec, roundDown)
 	// --- FIN LÍNEAS CLAVE ---
 
+	if err := s.checkPriceBand(ctx, symbol, orderType, roundedPrice.InexactFloat64()); err != nil {
+		return nil, err
+	}
+
 	// Check if rounded quantity is less than minimum allowed by lot size filter
-	lotSizeFilter := symbolInfo.LotSizeFilter()
-	if lotSizeFilter == nil {
-		return nil, fmt.Errorf("LotSize filter not found for symbol %s", symbol)
+	minQtyDec := decimal.Zero // No minimum known when falling back to the default precision
+	if lotSizeFilter := symbolInfo.LotSizeFilter(); lotSizeFilter != nil {
+		minQtyDec, _ = decimal.NewFromString(lotSizeFilter.MinQuantity)
 	}
-	minQtyDec, _ := decimal.NewFromString(lotSizeFilter.MinQuantity)
 
 	if roundedQuantity.LessThan(minQtyDec) {
 		s.logger.Warnf("Calculated quantity %s is less than minimum allowed %s for %s. Adjusting to minimum.", roundedQuantity, minQtyDec, symbol)
 		roundedQuantity = minQtyDec // Use minimum quantity if calculated is too small
 	}
 
+	// Binance rejects a price or quantity off the filter grid, so assert the rounding landed on it
+	if aligned, residue := alignToIncrement(roundedPrice, tickSizeDec); !residue.IsZero() {
+		s.logger.Warnf("Rounded price %s for %s is off the tick size %s by %s. Correcting to %s.", roundedPrice, symbol, tickSizeDec, residue, aligned)
+		roundedPrice = aligned
+	}
+	if aligned, residue := alignToIncrement(roundedQuantity, stepSizeDec); !residue.IsZero() {
+		s.logger.Warnf("Rounded quantity %s for %s is off the step size %s by %s. Correcting to %s.", roundedQuantity, symbol, stepSizeDec, residue, aligned)
+		roundedQuantity = aligned
+	}
+
 	orderService := s.client.NewCreateOrderService().
 		Symbol(symbol).
 		Quantity(roundedQuantity.String()). // Use rounded quantity string
 		Price(roundedPrice.String()).       // Use rounded price string
-		TimeInForce(binance.TimeInForceTypeGTC)
+		TimeInForce(binance.TimeInForceType(timeInForce))
 
 	// Set order type (BUY/SELL)
 	switch orderType {
@@ -147,7 +410,17 @@ func (s *BinanceService) PlaceLimitOrder(ctx context.Context, symbol string, ord
 	}
 
 	// Execute the order
-	binanceOrder, err := orderService.Do(ctx)
+	binanceOrder, err := s.createOrder(ctx, orderService)
+	if err != nil && s.retryLotSize && isLotSizeRejection(err) {
+		// Binance counts steps from minQty, so a quantity on the grid from zero can still be rejected
+		retryQuantity := floorToLotStep(roundedQuantity, minQtyDec, stepSizeDec)
+		if retryQuantity.GreaterThanOrEqual(minQtyDec) && retryQuantity.IsPositive() && !retryQuantity.Equal(roundedQuantity) {
+			s.logger.Warnf("%s order for %s %s rejected for LOT_SIZE (%v). Retrying once with quantity %s.",
+				orderType, roundedQuantity, symbol, err, retryQuantity)
+			orderService.Quantity(retryQuantity.String())
+			binanceOrder, err = s.createOrder(ctx, orderService)
+		}
+	}
 	if err != nil {
 		s.logger.Errorf("Failed to place order on Binance: %v", err)
 		return nil, fmt.Errorf("failed to place order on Binance: %w", err)
@@ -195,7 +468,7 @@ func (s *BinanceService) PlaceLimitOrder(ctx context.Context, symbol string, ord
 		Type:          ourOrderType,
 		Price:         priceF,
 		Quantity:      origQtyF,
-		QuoteQty:      quoteQtyF,
+		QuoteQty:      roundToPrecision(quoteQtyF, symbolInfo.QuoteAssetPrecision),
 		Status:        orderStatus,
 		IsTest:        isTest,
 		PlacedAt:      placedAt,
@@ -204,6 +477,235 @@ func (s *BinanceService) PlaceLimitOrder(ctx context.Context, symbol string, ord
 	}, nil
 }
 
+// PlaceMarketBuyOrder places a market buy order on Binance spending quoteAmount of the quote asset (e.g., USDT).
+func (s *BinanceService) PlaceMarketBuyOrder(ctx context.Context, symbol string, quoteAmount float64) (*models.Order, error) {
+	s.logger.Infof("Attempting to place market buy order for %f quote on %s", quoteAmount, symbol)
+
+	quoteAmountDec := decimal.NewFromFloat(quoteAmount).Round(8) // Binance accepts up to 8 decimals for quote quantities
+
+	binanceOrder, err := s.createOrder(ctx, s.client.NewCreateOrderService().
+		Symbol(symbol).
+		Side(binance.SideTypeBuy).
+		Type(binance.OrderTypeMarket).
+		QuoteOrderQty(quoteAmountDec.String()))
+	if err != nil {
+		s.logger.Errorf("Failed to place market order on Binance: %v", err)
+		return nil, fmt.Errorf("failed to place market order on Binance: %w", err)
+	}
+
+	s.logger.Infof("Market order placed successfully on Binance: ID %d, Status: %s", binanceOrder.OrderID, binanceOrder.Status)
+	return s.filledOrderToModel(ctx, binanceOrder, models.OrderTypeBuy), nil
+}
+
+// PlaceMarketSellOrder places a market sell order on Binance for the given base asset quantity.
+// The quantity is rounded down to the symbol's LOT_SIZE step.
+func (s *BinanceService) PlaceMarketSellOrder(ctx context.Context, symbol string, quantity float64) (*models.Order, error) {
+	s.logger.Infof("Attempting to place market sell order for %f %s", quantity, symbol)
+
+	symbolInfo, err := s.getSymbolInfo(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	var stepSize string
+	if lotSizeFilter := symbolInfo.LotSizeFilter(); lotSizeFilter != nil {
+		stepSize = lotSizeFilter.StepSize
+	} else {
+		stepSize = s.fallbackIncrement(symbol, "LOT_SIZE", s.defaultQtyPrecision)
+	}
+	if stepSize == "" {
+		return nil, fmt.Errorf("LotSize filter not found for symbol %s", symbol)
+	}
+	stepSizeDec, err := decimal.NewFromString(stepSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stepSize '%s' for symbol %s: %w", stepSize, symbol, err)
+	}
+	roundedQuantity := roundToIncrement(decimal.NewFromFloat(quantity), stepSizeDec, roundDown)
+
+	binanceOrder, err := s.createOrder(ctx, s.client.NewCreateOrderService().
+		Symbol(symbol).
+		Side(binance.SideTypeSell).
+		Type(binance.OrderTypeMarket).
+		Quantity(roundedQuantity.String()))
+	if err != nil {
+		s.logger.Errorf("Failed to place market order on Binance: %v", err)
+		return nil, fmt.Errorf("failed to place market order on Binance: %w", err)
+	}
+
+	s.logger.Infof("Market order placed successfully on Binance: ID %d, Status: %s", binanceOrder.OrderID, binanceOrder.Status)
+	return s.filledOrderToModel(ctx, binanceOrder, models.OrderTypeSell), nil
+}
+
+// filledOrderToModel converts a market or IOC order response into our internal Order model.
+// Price and Quantity reflect what was executed: market orders report price 0, so the average
+// fill price is derived from the executed amounts.
+func (s *BinanceService) filledOrderToModel(ctx context.Context, binanceOrder *binance.CreateOrderResponse, orderType models.OrderType) *models.Order {
+	executedQtyF, _ := strconv.ParseFloat(binanceOrder.ExecutedQuantity, 64)
+	quoteQtyF, _ := strconv.ParseFloat(binanceOrder.CummulativeQuoteQuantity, 64)
+
+	avgPriceF := 0.0
+	if executedQtyF > 0 {
+		avgPriceF = quoteQtyF / executedQtyF
+	}
+
+	orderStatus := models.OrderStatus(binanceOrder.Status)
+	placedAt := time.Unix(0, binanceOrder.TransactTime*int64(time.Millisecond))
+
+	var executedAt *time.Time
+	if orderStatus == models.OrderStatusFilled || orderStatus == models.OrderStatusPartiallyFilled {
+		t := placedAt
+		executedAt = &t
+	}
+
+	return &models.Order{
+		BinanceID:     binanceOrder.OrderID,
+		Symbol:        binanceOrder.Symbol,
+		Type:          orderType,
+		Price:         avgPriceF,
+		Quantity:      executedQtyF,
+		QuoteQty:      s.roundQuoteQty(ctx, binanceOrder.Symbol, quoteQtyF),
+		Status:        orderStatus,
+		IsTest:        s.testnet,
+		PlacedAt:      placedAt,
+		ExecutedAt:    executedAt,
+		LastUpdatedAt: placedAt,
+	}
+}
+
+// LiquidateWithFloor sells quantity immediately with a marketable limit order (IOC) priced
+// maxSlippagePercentage below the best bid, so no fill can happen below that floor.
+// It returns the executed order and the quantity left unfilled.
+func (s *BinanceService) LiquidateWithFloor(ctx context.Context, symbol string, quantity float64, maxSlippagePercentage float64) (*models.Order, float64, error) {
+	bestBid, _, err := s.GetBookTicker(ctx, symbol)
+	if err != nil {
+		return nil, 0, err
+	}
+	floorPrice := utils.CalculateBuyPrice(bestBid, maxSlippagePercentage)
+	s.logger.Infof("Attempting to liquidate %f %s with price floor %f (best bid %f)", quantity, symbol, floorPrice, bestBid)
+
+	symbolInfo, err := s.getSymbolInfo(ctx, symbol)
+	if err != nil {
+		return nil, 0, err
+	}
+	var tickSize, stepSize string
+	if priceFilter := symbolInfo.PriceFilter(); priceFilter != nil {
+		tickSize = priceFilter.TickSize
+	} else {
+		tickSize = s.fallbackIncrement(symbol, "PRICE_FILTER", s.defaultPricePrecision)
+	}
+	if lotSizeFilter := symbolInfo.LotSizeFilter(); lotSizeFilter != nil {
+		stepSize = lotSizeFilter.StepSize
+	} else {
+		stepSize = s.fallbackIncrement(symbol, "LOT_SIZE", s.defaultQtyPrecision)
+	}
+	if tickSize == "" || stepSize == "" {
+		return nil, 0, fmt.Errorf("could not find PRICE_FILTER or LOT_SIZE filter for symbol %s", symbol)
+	}
+	tickSizeDec, err := decimal.NewFromString(tickSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid tickSize '%s' for symbol %s: %w", tickSize, symbol, err)
+	}
+	stepSizeDec, err := decimal.NewFromString(stepSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid stepSize '%s' for symbol %s: %w", stepSize, symbol, err)
+	}
+	// Round the floor up so rounding never lets a fill below it
+	roundedPrice := roundToIncrement(decimal.NewFromFloat(floorPrice), tickSizeDec, roundUp)
+	roundedQuantity := roundToIncrement(decimal.NewFromFloat(quantity), stepSizeDec, roundDown)
+
+	binanceOrder, err := s.createOrder(ctx, s.client.NewCreateOrderService().
+		Symbol(symbol).
+		Side(binance.SideTypeSell).
+		Type(binance.OrderTypeLimit).
+		TimeInForce(binance.TimeInForceTypeIOC).
+		Quantity(roundedQuantity.String()).
+		Price(roundedPrice.String()))
+	if err != nil {
+		s.logger.Errorf("Failed to place liquidation order on Binance: %v", err)
+		return nil, 0, fmt.Errorf("failed to place liquidation order on Binance: %w", err)
+	}
+
+	order := s.filledOrderToModel(ctx, binanceOrder, models.OrderTypeSell)
+	unfilled := roundedQuantity.InexactFloat64() - order.Quantity
+	if unfilled > 0 {
+		s.logger.Warnf("Liquidation order %d filled %f of %f %s above floor %s; %f left unfilled.",
+			order.BinanceID, order.Quantity, roundedQuantity.InexactFloat64(), symbol, roundedPrice, unfilled)
+	} else {
+		s.logger.Infof("Liquidation order %d fully filled at average price %f.", order.BinanceID, order.Price)
+	}
+	return order, unfilled, nil
+}
+
+// GetLastTradePrice fetches the price and execution time of the most recent trade on a symbol.
+func (s *BinanceService) GetLastTradePrice(ctx context.Context, symbol string) (float64, time.Time, error) {
+	s.logger.Debugf("Fetching last trade for %s...", symbol)
+	res, err := s.client.NewRecentTradesService().Symbol(symbol).Limit(1).Do(ctx)
+	if err != nil {
+		s.logger.Errorf("Failed to get last trade for %s: %v", symbol, err)
+		return 0, time.Time{}, fmt.Errorf("failed to get last trade: %w", err)
+	}
+	if len(res) == 0 {
+		return 0, time.Time{}, fmt.Errorf("no trades returned for %s", symbol)
+	}
+
+	price, err := strconv.ParseFloat(res[0].Price, 64)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to parse trade price '%s': %w", res[0].Price, err)
+	}
+	return price, time.UnixMilli(res[0].Time), nil
+}
+
+// GetBookTicker fetches the best bid and ask prices for a given symbol.
+func (s *BinanceService) GetBookTicker(ctx context.Context, symbol string) (float64, float64, error) {
+	s.logger.Debugf("Fetching book ticker for %s...", symbol)
+	res, err := s.client.NewListBookTickersService().Symbol(symbol).Do(ctx)
+	if err != nil {
+		s.logger.Errorf("Failed to get book ticker for %s: %v", symbol, err)
+		return 0, 0, fmt.Errorf("failed to get book ticker: %w", err)
+	}
+	if len(res) == 0 {
+		return 0, 0, fmt.Errorf("no book ticker data returned for %s", symbol)
+	}
+
+	bid, err := strconv.ParseFloat(res[0].BidPrice, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse bid price '%s': %w", res[0].BidPrice, err)
+	}
+	ask, err := strconv.ParseFloat(res[0].AskPrice, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse ask price '%s': %w", res[0].AskPrice, err)
+	}
+	return bid, ask, nil
+}
+
+// GetCandles fetches the last limit klines of symbol at the given interval (e.g. "1h"), oldest first.
+// The last candle is the one still forming.
+func (s *BinanceService) GetCandles(ctx context.Context, symbol, interval string, limit int) ([]indicators.Candle, error) {
+	s.logger.Debugf("Fetching %d %s klines for %s...", limit, interval, symbol)
+	klines, err := s.client.NewKlinesService().Symbol(symbol).Interval(interval).Limit(limit).Do(ctx)
+	if err != nil {
+		s.logger.Errorf("Failed to get klines for %s: %v", symbol, err)
+		return nil, fmt.Errorf("failed to get klines: %w", err)
+	}
+
+	candles := make([]indicators.Candle, 0, len(klines))
+	for _, k := range klines {
+		high, err := strconv.ParseFloat(k.High, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse kline high '%s': %w", k.High, err)
+		}
+		low, err := strconv.ParseFloat(k.Low, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse kline low '%s': %w", k.Low, err)
+		}
+		closePrice, err := strconv.ParseFloat(k.Close, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse kline close '%s': %w", k.Close, err)
+		}
+		candles = append(candles, indicators.Candle{High: high, Low: low, Close: closePrice})
+	}
+	return candles, nil
+}
+
 // GetOrderStatus fetches the status of an order from Binance.
 func (s *BinanceService) GetOrderStatus(ctx context.Context, symbol string, binanceOrderID int64) (*models.Order, error) {
 	s.logger.Debugf("Fetching status for Binance order ID %d on symbol %s", binanceOrderID, symbol)
@@ -249,7 +751,7 @@ func (s *BinanceService) GetOrderStatus(ctx context.Context, symbol string, bina
 		Type:          models.OrderType(orderRes.Side),
 		Price:         priceF,
 		Quantity:      origQtyF,
-		QuoteQty:      quoteQtyF, // Use the (corrected) quoteQtyF
+		QuoteQty:      s.roundQuoteQty(ctx, symbol, quoteQtyF), // Use the (corrected) quoteQtyF
 		Status:        orderStatus,
 		IsTest:        isTest,
 		PlacedAt:      placedAt,
@@ -270,6 +772,50 @@ func (s *BinanceService) CancelOrder(ctx context.Context, symbol string, binance
 	return nil
 }
 
+// CountOpenOrders returns how many orders are currently open on a symbol.
+func (s *BinanceService) CountOpenOrders(ctx context.Context, symbol string) (int, error) {
+	openOrders, err := s.client.NewListOpenOrdersService().Symbol(symbol).Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list open orders for %s: %w", symbol, err)
+	}
+	return len(openOrders), nil
+}
+
+// CancelAllOpenOrders cancels every open order on a symbol and returns how many were cancelled.
+// CANCEL_ORDERS_ON_SHUTDOWN uses it so nothing fills while the bot is down.
+func (s *BinanceService) CancelAllOpenOrders(ctx context.Context, symbol string) (int, error) {
+	s.logger.Infof("Attempting to cancel all open orders for symbol %s...", symbol)
+	res, err := s.client.NewCancelOpenOrdersService().Symbol(symbol).Do(ctx)
+	if err != nil {
+		s.logger.Errorf("Failed to cancel open orders for %s: %v", symbol, err)
+		return 0, fmt.Errorf("failed to cancel open orders: %w", err)
+	}
+	s.logger.Infof("Cancelled %d open orders for symbol %s.", len(res.Orders), symbol)
+	return len(res.Orders), nil
+}
+
+// GetCommissionRates returns the account's maker and taker fees. They are fetched from the account
+// on first use and cached, since Binance only changes them with the account's VIP tier.
+func (s *BinanceService) GetCommissionRates(ctx context.Context) (*CommissionRates, error) {
+	s.commissionMu.Lock()
+	defer s.commissionMu.Unlock()
+	if s.commissionRates != nil {
+		return s.commissionRates, nil
+	}
+
+	account, err := s.client.NewGetAccountService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account info: %w", err)
+	}
+	// The account reports commissions in basis points: 10 means 0.10%
+	s.commissionRates = &CommissionRates{
+		Maker: float64(account.MakerCommission) / 100,
+		Taker: float64(account.TakerCommission) / 100,
+	}
+	s.logger.Infof("Account commission rates: maker %.4f%%, taker %.4f%%", s.commissionRates.Maker, s.commissionRates.Taker)
+	return s.commissionRates, nil
+}
+
 // GetAccountBalance fetches the balance of a specific asset from the user's Binance account.
 func (s *BinanceService) GetAccountBalance(ctx context.Context, asset string) (float64, error) {
 	s.logger.Debugf("Fetching account balance for asset: %s", asset)
@@ -297,6 +843,382 @@ func (s *BinanceService) GetAccountBalance(ctx context.Context, asset string) (f
 	return 0, nil // Return 0 if asset not found, or an error if you prefer
 }
 
+// roundingMode selects how a value is snapped to an exchange increment (tick or step size).
+type roundingMode int
+
+const (
+	roundNearest roundingMode = iota
+	roundDown
+	roundUp
+)
+
+// isLotSizeRejection reports whether err is Binance rejecting an order's quantity for the LOT_SIZE
+// filter (-1013 "Filter failure: LOT_SIZE").
+func isLotSizeRejection(err error) bool {
+	var apiErr *common.APIError
+	return errors.As(err, &apiErr) && apiErr.Code == -1013 && strings.Contains(apiErr.Message, "LOT_SIZE")
+}
+
+// floorToLotStep floors quantity to minQty plus a whole number of steps, the grid LOT_SIZE validates
+// against, and trims it to the step's decimals so no float residue reaches the order.
+func floorToLotStep(quantity, minQty, step decimal.Decimal) decimal.Decimal {
+	if step.IsZero() || quantity.LessThan(minQty) {
+		return quantity
+	}
+	steps := quantity.Sub(minQty).Div(step).Floor()
+	return minQty.Add(steps.Mul(step)).Truncate(-step.Exponent())
+}
+
+// alignToIncrement returns value with any residue left over a multiple of increment removed (rounding
+// toward zero), together with that residue; a zero residue means value was already on the grid. A zero
+// increment leaves the value untouched.
+func alignToIncrement(value, increment decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	if increment.IsZero() {
+		return value, decimal.Zero
+	}
+	residue := value.Mod(increment)
+	if residue.IsZero() {
+		return value, residue
+	}
+	return value.Sub(residue).Truncate(-increment.Exponent()), residue
+}
+
+// roundToIncrement snaps value to a multiple of increment using the given rounding mode.
+// A zero increment leaves the value untouched.
+func roundToIncrement(value, increment decimal.Decimal, mode roundingMode) decimal.Decimal {
+	if increment.IsZero() {
+		return value
+	}
+	steps := value.Div(increment)
+	switch mode {
+	case roundDown:
+		steps = steps.Floor()
+	case roundUp:
+		steps = steps.Ceil()
+	default:
+		steps = steps.Round(0)
+	}
+	return steps.Mul(increment)
+}
+
+// AccountTrade is a single executed fill from the account trade list.
+type AccountTrade struct {
+	ID              int64
+	OrderID         int64
+	Price           float64
+	Quantity        float64
+	QuoteQuantity   float64
+	Commission      float64
+	CommissionAsset string
+	IsBuyer         bool
+	Time            time.Time
+}
+
+// GetAccountTrades fetches executed fills for a symbol, starting at trade ID fromID (0 for the most recent).
+func (s *BinanceService) GetAccountTrades(ctx context.Context, symbol string, fromID int64) ([]*AccountTrade, error) {
+	s.logger.Debugf("Fetching account trades for %s from ID %d...", symbol, fromID)
+	service := s.client.NewListTradesService().Symbol(symbol)
+	if fromID > 0 {
+		service.FromID(fromID)
+	}
+	res, err := service.Do(ctx)
+	if err != nil {
+		s.logger.Errorf("Failed to get account trades for %s: %v", symbol, err)
+		return nil, fmt.Errorf("failed to get account trades: %w", err)
+	}
+	return toAccountTrades(res), nil
+}
+
+// GetOrderFills fetches the executed fills of a single order.
+func (s *BinanceService) GetOrderFills(ctx context.Context, symbol string, binanceOrderID int64) ([]*AccountTrade, error) {
+	s.logger.Debugf("Fetching fills for Binance order ID %d on symbol %s", binanceOrderID, symbol)
+	res, err := s.client.NewListTradesService().Symbol(symbol).OrderId(binanceOrderID).Do(ctx)
+	if err != nil {
+		s.logger.Errorf("Failed to get fills for order ID %d on symbol %s: %v", binanceOrderID, symbol, err)
+		return nil, fmt.Errorf("failed to get order fills: %w", err)
+	}
+	return toAccountTrades(res), nil
+}
+
+// toAccountTrades converts go-binance trade fills into AccountTrade values.
+func toAccountTrades(res []*binance.TradeV3) []*AccountTrade {
+	trades := make([]*AccountTrade, 0, len(res))
+	for _, t := range res {
+		priceF, _ := strconv.ParseFloat(t.Price, 64)
+		qtyF, _ := strconv.ParseFloat(t.Quantity, 64)
+		quoteQtyF, _ := strconv.ParseFloat(t.QuoteQuantity, 64)
+		commissionF, _ := strconv.ParseFloat(t.Commission, 64)
+		trades = append(trades, &AccountTrade{
+			ID:              t.ID,
+			OrderID:         t.OrderID,
+			Price:           priceF,
+			Quantity:        qtyF,
+			QuoteQuantity:   quoteQtyF,
+			Commission:      commissionF,
+			CommissionAsset: t.CommissionAsset,
+			IsBuyer:         t.IsBuyer,
+			Time:            time.Unix(0, t.Time*int64(time.Millisecond)),
+		})
+	}
+	return trades
+}
+
+// FillSummary aggregates the fills of an order.
+type FillSummary struct {
+	Quantity       float64 // Total base asset filled
+	QuoteQuantity  float64 // Total quote asset exchanged
+	AveragePrice   float64 // Quantity-weighted average fill price
+	CommissionUSDT float64 // Commissions paid in the quote or base asset, valued in the quote asset (BNB commissions are not included)
+}
+
+// SummarizeFills computes the weighted average price and quote-valued commission of a set of fills of a
+// symbol trading baseAsset against quoteAsset.
+func SummarizeFills(fills []*AccountTrade, baseAsset, quoteAsset string) FillSummary {
+	var summary FillSummary
+	for _, f := range fills {
+		summary.Quantity += f.Quantity
+		summary.QuoteQuantity += f.QuoteQuantity
+		switch f.CommissionAsset {
+		case quoteAsset:
+			summary.CommissionUSDT += f.Commission
+		case baseAsset:
+			summary.CommissionUSDT += f.Commission * f.Price
+		}
+	}
+	if summary.Quantity > 0 {
+		summary.AveragePrice = summary.QuoteQuantity / summary.Quantity
+	}
+	return summary
+}
+
+// TransferToFunding moves an amount of an asset from the spot wallet to the funding wallet
+// using Binance's universal transfer API.
+func (s *BinanceService) TransferToFunding(ctx context.Context, asset string, amount float64) error {
+	s.logger.Infof("Attempting to transfer %f %s to the funding wallet...", amount, asset)
+	res, err := s.client.NewUserUniversalTransferService().
+		Type(binance.UserUniversalTransferTypeMainToFunding).
+		Asset(asset).
+		Amount(decimal.NewFromFloat(amount).Round(8).String()).
+		Do(ctx)
+	if err != nil {
+		s.logger.Errorf("Failed to transfer %f %s to funding wallet: %v", amount, asset, err)
+		return fmt.Errorf("failed to transfer to funding wallet: %w", err)
+	}
+	s.logger.Infof("Transferred %f %s to funding wallet (transfer ID %d).", amount, asset, res.ID)
+	return nil
+}
+
+// getSymbolInfo returns the exchange info entry (filters, precision, status) for a given symbol,
+// served from the cache while it is younger than symbolInfoCacheTTL.
+func (s *BinanceService) getSymbolInfo(ctx context.Context, symbol string) (*binance.Symbol, error) {
+	return s.getSymbolInfoMaxAge(ctx, symbol, symbolInfoCacheTTL)
+}
+
+// getSymbolInfoMaxAge is getSymbolInfo with a custom cache age. A refresh that finds the symbol's
+// trading status changed logs the transition.
+func (s *BinanceService) getSymbolInfoMaxAge(ctx context.Context, symbol string, maxAge time.Duration) (*binance.Symbol, error) {
+	s.symbolInfoMu.Lock()
+	cached, ok := s.symbolInfoCache[symbol]
+	s.symbolInfoMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < maxAge {
+		return cached.info, nil
+	}
+
+	exchangeInfo, err := s.client.NewExchangeInfoService().Symbol(symbol).Do(ctx)
+	if err == nil && len(exchangeInfo.Symbols) == 0 {
+		err = fmt.Errorf("exchange info not found for symbol %s", symbol)
+	}
+	if err != nil {
+		// Filters rarely change, so stale ones beat failing the order on a transient error
+		if ok {
+			s.logger.Warnf("Failed to refresh exchange info for %s, using cached filters from %s ago: %v",
+				symbol, time.Since(cached.fetchedAt).Round(time.Second), err)
+			return cached.info, nil
+		}
+		return nil, fmt.Errorf("failed to get exchange info for %s: %w", symbol, err)
+	}
+	info := &exchangeInfo.Symbols[0]
+	if ok && cached.info.Status != info.Status {
+		s.logger.Warnf("Exchange info status of %s changed from %s to %s.", symbol, cached.info.Status, info.Status)
+	}
+
+	s.symbolInfoMu.Lock()
+	s.symbolInfoCache[symbol] = &cachedSymbolInfo{info: info, priceBand: parsePercentPriceBand(info), fetchedAt: time.Now()}
+	s.symbolInfoMu.Unlock()
+	return info, nil
+}
+
+// GetSymbolStatus returns the symbol's trading status from exchange info ("TRADING", "BREAK", "HALT", ...),
+// refreshing the cached entry once it is older than symbolStatusMaxAge.
+func (s *BinanceService) GetSymbolStatus(ctx context.Context, symbol string) (string, error) {
+	info, err := s.getSymbolInfoMaxAge(ctx, symbol, symbolStatusMaxAge)
+	if err != nil {
+		return "", err
+	}
+	return info.Status, nil
+}
+
+// IsSymbolTrading reports whether status is the exchange info status of a symbol open for trading.
+func IsSymbolTrading(status string) bool {
+	return status == symbolStatusTrading
+}
+
+// PriceDecimals returns the number of decimals of the symbol's tick size, for display. It only
+// reads exchange info already cached by earlier calls and never hits the API; without it, the
+// configured default precision is used, and -1 (full precision) if there is none.
+func (s *BinanceService) PriceDecimals(symbol string) int {
+	s.symbolInfoMu.Lock()
+	cached, ok := s.symbolInfoCache[symbol]
+	s.symbolInfoMu.Unlock()
+	if ok {
+		if f := cached.info.PriceFilter(); f != nil && f.TickSize != "" {
+			// Binance pads filter values with zeros ("0.01000000"), which are not significant here
+			return countDecimalPlaces(strings.TrimRight(f.TickSize, "0"))
+		}
+	}
+	return s.defaultPricePrecision
+}
+
+// parsePercentPriceBand reads the symbol's PERCENT_PRICE_BY_SIDE filter, or the older PERCENT_PRICE
+// filter that applies the same multipliers to both sides. It returns nil if neither is present.
+func parsePercentPriceBand(info *binance.Symbol) *percentPriceBand {
+	if f := info.PercentPriceBySideFilter(); f != nil {
+		band := &percentPriceBand{}
+		band.bidUp, _ = strconv.ParseFloat(f.BidMultiplierUp, 64)
+		band.bidDown, _ = strconv.ParseFloat(f.BidMultiplierDown, 64)
+		band.askUp, _ = strconv.ParseFloat(f.AskMultiplierUp, 64)
+		band.askDown, _ = strconv.ParseFloat(f.AskMultiplierDown, 64)
+		return band
+	}
+	for _, filter := range info.Filters {
+		if filterType, _ := filter["filterType"].(string); filterType != "PERCENT_PRICE" {
+			continue
+		}
+		up, _ := filter["multiplierUp"].(string)
+		down, _ := filter["multiplierDown"].(string)
+		band := &percentPriceBand{}
+		band.bidUp, _ = strconv.ParseFloat(up, 64)
+		band.bidDown, _ = strconv.ParseFloat(down, 64)
+		band.askUp, band.askDown = band.bidUp, band.bidDown
+		return band
+	}
+	return nil
+}
+
+// checkPriceBand rejects limit orders priced outside the symbol's PERCENT_PRICE band, which Binance
+// would refuse. Symbols without the filter, or with unusable multipliers, are not checked.
+func (s *BinanceService) checkPriceBand(ctx context.Context, symbol string, orderType models.OrderType, price float64) error {
+	if _, err := s.getSymbolInfo(ctx, symbol); err != nil {
+		return err
+	}
+	s.symbolInfoMu.Lock()
+	band := s.symbolInfoCache[symbol].priceBand
+	s.symbolInfoMu.Unlock()
+	if band == nil {
+		return nil
+	}
+
+	up, down := band.bidUp, band.bidDown
+	if orderType == models.OrderTypeSell {
+		up, down = band.askUp, band.askDown
+	}
+	if up <= 0 {
+		return nil
+	}
+
+	avgPrice, err := s.GetAveragePrice(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to check limit price against PERCENT_PRICE band: %w", err)
+	}
+	low, high := avgPrice*down, avgPrice*up
+	if price < low || price > high {
+		err := &PriceOutsideBandError{Side: orderType, Price: price, AveragePrice: avgPrice, Low: low, High: high}
+		s.logger.Warnf("Skipping order: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetQuotePrecision returns the symbol's quoteAssetPrecision, or models.DefaultQuotePrecision if
+// exchange info is unavailable.
+func (s *BinanceService) GetQuotePrecision(ctx context.Context, symbol string) int {
+	symbolInfo, err := s.getSymbolInfo(ctx, symbol)
+	if err != nil {
+		s.logger.Warnf("Could not get quote precision for %s, using %d decimals: %v", symbol, models.DefaultQuotePrecision, err)
+		return models.DefaultQuotePrecision
+	}
+	return symbolInfo.QuoteAssetPrecision
+}
+
+// roundQuoteQty rounds a quote asset amount to the symbol's quoteAssetPrecision, so float
+// products like 10.000000003 are not persisted. If exchange info is unavailable the value is returned as is.
+func (s *BinanceService) roundQuoteQty(ctx context.Context, symbol string, quoteQty float64) float64 {
+	symbolInfo, err := s.getSymbolInfo(ctx, symbol)
+	if err != nil {
+		s.logger.Warnf("Could not round quote quantity for %s: %v", symbol, err)
+		return quoteQty
+	}
+	return roundToPrecision(quoteQty, symbolInfo.QuoteAssetPrecision)
+}
+
+// roundToPrecision rounds value to the given number of decimal places using decimal arithmetic.
+func roundToPrecision(value float64, precision int) float64 {
+	return decimal.NewFromFloat(value).Round(int32(precision)).InexactFloat64()
+}
+
+// SymbolLimits holds the minimum order sizes Binance enforces for a symbol.
+type SymbolLimits struct {
+	MinQuantity float64 // LOT_SIZE minQty, in base asset
+	MinNotional float64 // NOTIONAL minNotional, in quote asset (0 if the symbol has no notional filter)
+	MaxOrders   int     // MAX_NUM_ORDERS maxNumOrders, open orders allowed on the symbol (0 if the symbol has no such filter)
+}
+
+// GetSymbolAssets returns the base and quote assets of a symbol from exchange info, e.g. "BTC" and "USDT" for BTCUSDT.
+func (s *BinanceService) GetSymbolAssets(ctx context.Context, symbol string) (string, string, error) {
+	symbolInfo, err := s.getSymbolInfo(ctx, symbol)
+	if err != nil {
+		return "", "", err
+	}
+	return symbolInfo.BaseAsset, symbolInfo.QuoteAsset, nil
+}
+
+// GetSymbolLimits fetches the LOT_SIZE and NOTIONAL minimums for a given symbol from exchange info.
+func (s *BinanceService) GetSymbolLimits(ctx context.Context, symbol string) (*SymbolLimits, error) {
+	symbolInfo, err := s.getSymbolInfo(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := &SymbolLimits{}
+	if lotSizeFilter := symbolInfo.LotSizeFilter(); lotSizeFilter != nil {
+		limits.MinQuantity, _ = strconv.ParseFloat(lotSizeFilter.MinQuantity, 64)
+	}
+	if notionalFilter := symbolInfo.NotionalFilter(); notionalFilter != nil {
+		limits.MinNotional, _ = strconv.ParseFloat(notionalFilter.MinNotional, 64)
+	}
+	if maxNumOrdersFilter := symbolInfo.MaxNumOrdersFilter(); maxNumOrdersFilter != nil {
+		limits.MaxOrders = maxNumOrdersFilter.MaxNumOrders
+	}
+	return limits, nil
+}
+
+// IsDust reports whether a base asset quantity is too small to be sold at the given price.
+func (l *SymbolLimits) IsDust(quantity, price float64) bool {
+	return quantity < l.MinQuantity || quantity*price < l.MinNotional
+}
+
+// ConvertDust converts a small balance of the given asset to BNB using Binance's dust transfer.
+func (s *BinanceService) ConvertDust(ctx context.Context, asset string) error {
+	s.logger.Infof("Attempting to convert %s dust to BNB...", asset)
+	res, err := s.client.NewDustTransferService().Asset([]string{asset}).Do(ctx)
+	if err != nil {
+		s.logger.Errorf("Failed to convert %s dust: %v", asset, err)
+		return fmt.Errorf("failed to convert dust: %w", err)
+	}
+	s.logger.Infof("Converted %s dust to %s BNB (fee %s BNB).", asset, res.TotalTransfered, res.TotalServiceCharge)
+	return nil
+}
+
 // countDecimalPlaces helper function
 func countDecimalPlaces(s string) int {
 	if !strings.Contains(s, ".") {
@@ -304,3 +1226,18 @@ func countDecimalPlaces(s string) int {
 	}
 	return len(s) - strings.Index(s, ".") - 1
 }
+
+// IsOrderNotFound reports whether err is Binance answering an order lookup with -2013 (order does not exist).
+func IsOrderNotFound(err error) bool {
+	var apiErr *common.APIError
+	return errors.As(err, &apiErr) && apiErr.Code == -2013
+}
+
+// IsInsufficientBalance reports whether err is Binance rejecting an order because the account
+// cannot cover it (-2010 NEW_ORDER_REJECTED with an insufficient balance message). Retrying such an
+// order will not help until funds change.
+func IsInsufficientBalance(err error) bool {
+	var apiErr *common.APIError
+	return errors.As(err, &apiErr) && apiErr.Code == -2010 &&
+		strings.Contains(strings.ToLower(apiErr.Message), "insufficient balance")
+}
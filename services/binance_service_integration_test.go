@@ -0,0 +1,130 @@
+//go:build integration
+
+package services
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"binance-trader-bot/models"
+	"binance-trader-bot/utils"
+)
+
+// These tests exercise the real Binance API contract against the spot testnet. Run them with
+//
+//	BINANCE_API_KEY=... BINANCE_SECRET_KEY=... go test -tags integration ./services/
+//
+// using testnet keys. INTEGRATION_SYMBOL selects the symbol (BTCUSDT by default).
+
+// newTestnetService returns a BinanceService on the testnet, skipping the test when no keys are set.
+func newTestnetService(t *testing.T) (*BinanceService, string) {
+	t.Helper()
+	apiKey, secretKey := os.Getenv("BINANCE_API_KEY"), os.Getenv("BINANCE_SECRET_KEY")
+	if apiKey == "" || secretKey == "" {
+		t.Skip("BINANCE_API_KEY and BINANCE_SECRET_KEY not set; skipping testnet integration test")
+	}
+	symbol := os.Getenv("INTEGRATION_SYMBOL")
+	if symbol == "" {
+		symbol = "BTCUSDT"
+	}
+	return NewBinanceService(apiKey, secretKey, true, false, utils.NewLogger()), symbol
+}
+
+func testnetContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestIntegrationGetCurrentPrice(t *testing.T) {
+	s, symbol := newTestnetService(t)
+
+	price, err := s.GetCurrentPrice(testnetContext(t), symbol)
+	if err != nil {
+		t.Fatalf("GetCurrentPrice(%s) returned error: %v", symbol, err)
+	}
+	if price <= 0 {
+		t.Errorf("GetCurrentPrice(%s) = %v, want a positive price", symbol, price)
+	}
+}
+
+func TestIntegrationGetAccountBalance(t *testing.T) {
+	s, symbol := newTestnetService(t)
+	ctx := testnetContext(t)
+
+	_, quoteAsset, err := s.GetSymbolAssets(ctx, symbol)
+	if err != nil {
+		t.Fatalf("GetSymbolAssets(%s) returned error: %v", symbol, err)
+	}
+	balance, err := s.GetAccountBalance(ctx, quoteAsset)
+	if err != nil {
+		t.Fatalf("GetAccountBalance(%s) returned error: %v", quoteAsset, err)
+	}
+	if balance < 0 {
+		t.Errorf("GetAccountBalance(%s) = %v, want a non-negative balance", quoteAsset, balance)
+	}
+}
+
+func TestIntegrationPlaceAndCancelOrder(t *testing.T) {
+	s, symbol := newTestnetService(t)
+	ctx := testnetContext(t)
+
+	price, err := s.GetCurrentPrice(ctx, symbol)
+	if err != nil {
+		t.Fatalf("GetCurrentPrice(%s) returned error: %v", symbol, err)
+	}
+	limits, err := s.GetSymbolLimits(ctx, symbol)
+	if err != nil {
+		t.Fatalf("GetSymbolLimits(%s) returned error: %v", symbol, err)
+	}
+
+	// A buy 10% below the market rests on the book instead of filling; size it at twice the minimum notional
+	buyPrice := utils.CalculateBuyPrice(price, 10)
+	quantity := max(limits.MinQuantity, 2*max(limits.MinNotional, 10)/buyPrice)
+
+	placed, err := s.PlaceLimitOrder(ctx, symbol, models.OrderTypeBuy, buyPrice, quantity, "")
+	if err != nil {
+		t.Fatalf("PlaceLimitOrder returned error: %v", err)
+	}
+	cancelled := false
+	t.Cleanup(func() {
+		if !cancelled {
+			s.CancelOrder(context.Background(), symbol, placed.BinanceID)
+		}
+	})
+	if placed.Status != models.OrderStatusNew {
+		t.Errorf("placed order status = %s, want NEW", placed.Status)
+	}
+
+	status, err := s.GetOrderStatus(ctx, symbol, placed.BinanceID)
+	if err != nil {
+		t.Fatalf("GetOrderStatus(%d) returned error: %v", placed.BinanceID, err)
+	}
+	if status.Status != models.OrderStatusNew || status.Type != models.OrderTypeBuy {
+		t.Errorf("order %d = %s %s, want NEW BUY", placed.BinanceID, status.Status, status.Type)
+	}
+
+	if err := s.CancelOrder(ctx, symbol, placed.BinanceID); err != nil {
+		t.Fatalf("CancelOrder(%d) returned error: %v", placed.BinanceID, err)
+	}
+	cancelled = true
+
+	status, err = s.GetOrderStatus(ctx, symbol, placed.BinanceID)
+	if err != nil {
+		t.Fatalf("GetOrderStatus(%d) after cancel returned error: %v", placed.BinanceID, err)
+	}
+	if status.Status != models.OrderStatusCanceled {
+		t.Errorf("order %d status after cancel = %s, want CANCELED", placed.BinanceID, status.Status)
+	}
+}
+
+func TestIntegrationGetOrderStatusUnknownOrder(t *testing.T) {
+	s, symbol := newTestnetService(t)
+
+	_, err := s.GetOrderStatus(testnetContext(t), symbol, 1)
+	if !IsOrderNotFound(err) {
+		t.Errorf("GetOrderStatus of an unknown order: IsOrderNotFound(%v) = false, want true", err)
+	}
+}
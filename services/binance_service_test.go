@@ -0,0 +1,877 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"binance-trader-bot/models"
+	"binance-trader-bot/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// fakeBinance is an httptest server standing in for the Binance REST endpoints BinanceService uses.
+// Routes are keyed "METHOD /path" and answer with a fixture from testdata unless a test replaces them.
+type fakeBinance struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu       sync.Mutex
+	routes   map[string]http.HandlerFunc
+	requests map[string][]url.Values // Route -> parameters of every request it received, in order
+}
+
+// newFakeBinance starts a fake Binance server serving a TRADING BTCUSDT symbol, an account holding BTC
+// and USDT, and orders that are accepted as NEW, reported FILLED and cancelled without error.
+func newFakeBinance(t *testing.T) *fakeBinance {
+	t.Helper()
+	f := &fakeBinance{
+		t:        t,
+		routes:   make(map[string]http.HandlerFunc),
+		requests: make(map[string][]url.Values),
+	}
+	f.fixture("GET /api/v3/ping", "", http.StatusOK)
+	f.fixture("GET /api/v3/ticker/price", "ticker_price.json", http.StatusOK)
+	f.fixture("GET /api/v3/exchangeInfo", "exchange_info.json", http.StatusOK)
+	f.fixture("GET /api/v3/account", "account.json", http.StatusOK)
+	f.fixture("POST /api/v3/order", "order_new.json", http.StatusOK)
+	f.fixture("POST /api/v3/order/test", "", http.StatusOK)
+	f.fixture("GET /api/v3/order", "order_filled.json", http.StatusOK)
+	f.fixture("DELETE /api/v3/order", "order_canceled.json", http.StatusOK)
+	f.respond("GET /api/v3/openOrders", http.StatusOK, "[]")
+
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeBinance) serve(w http.ResponseWriter, r *http.Request) {
+	route := r.Method + " " + r.URL.Path
+	if err := r.ParseForm(); err != nil {
+		f.t.Errorf("fake Binance could not parse %s: %v", route, err)
+	}
+	f.mu.Lock()
+	handler, ok := f.routes[route]
+	f.requests[route] = append(f.requests[route], r.Form)
+	f.mu.Unlock()
+	if !ok {
+		f.t.Errorf("fake Binance got unexpected request %s", route)
+		http.NotFound(w, r)
+		return
+	}
+	handler(w, r)
+}
+
+// handle replaces the handler of a route.
+func (f *fakeBinance) handle(route string, handler http.HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.routes[route] = handler
+}
+
+// fixture makes a route answer with the contents of testdata/name and the given status code.
+// An empty name answers with an empty JSON object.
+func (f *fakeBinance) fixture(route, name string, status int) {
+	body := []byte("{}")
+	if name != "" {
+		var err error
+		if body, err = os.ReadFile(filepath.Join("testdata", name)); err != nil {
+			f.t.Fatalf("failed to read fixture %s: %v", name, err)
+		}
+	}
+	f.respond(route, status, string(body))
+}
+
+// respond makes a route answer with a fixed JSON body and status code.
+func (f *fakeBinance) respond(route string, status int, body string) {
+	f.handle(route, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	})
+}
+
+// calls returns the parameters of every request a route received.
+func (f *fakeBinance) calls(route string) []url.Values {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[route]
+}
+
+// service returns a BinanceService pointed at the fake server.
+func (f *fakeBinance) service() *BinanceService {
+	s := NewBinanceService("test-key", "test-secret", false, false, utils.NewLogger())
+	s.SetBaseURL(f.server.URL)
+	return s
+}
+
+func TestGetCurrentPrice(t *testing.T) {
+	fake := newFakeBinance(t)
+
+	price, err := fake.service().GetCurrentPrice(context.Background(), "BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetCurrentPrice returned error: %v", err)
+	}
+	if price != 30000 {
+		t.Errorf("GetCurrentPrice = %v, want 30000", price)
+	}
+	if calls := fake.calls("GET /api/v3/ticker/price"); len(calls) != 1 || calls[0].Get("symbol") != "BTCUSDT" {
+		t.Errorf("ticker requests = %v, want one for BTCUSDT", calls)
+	}
+}
+
+func TestGetCurrentPriceAPIError(t *testing.T) {
+	fake := newFakeBinance(t)
+	fake.fixture("GET /api/v3/ticker/price", "error_invalid_symbol.json", http.StatusBadRequest)
+
+	if _, err := fake.service().GetCurrentPrice(context.Background(), "NOPEUSDT"); err == nil {
+		t.Fatal("GetCurrentPrice returned no error for an invalid symbol")
+	}
+}
+
+func TestGetAccountBalance(t *testing.T) {
+	fake := newFakeBinance(t)
+	s := fake.service()
+
+	tests := []struct {
+		asset string
+		want  float64
+	}{
+		{"USDT", 1050}, // Free plus locked
+		{"BTC", 0.01},
+		{"ETH", 0}, // Not held
+	}
+	for _, tt := range tests {
+		got, err := s.GetAccountBalance(context.Background(), tt.asset)
+		if err != nil {
+			t.Fatalf("GetAccountBalance(%s) returned error: %v", tt.asset, err)
+		}
+		if got != tt.want {
+			t.Errorf("GetAccountBalance(%s) = %v, want %v", tt.asset, got, tt.want)
+		}
+	}
+}
+
+func TestGetAccountBalanceAPIError(t *testing.T) {
+	fake := newFakeBinance(t)
+	fake.handle("GET /api/v3/account", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"code":-2015,"msg":"Invalid API-key, IP, or permissions for action."}`))
+	})
+
+	if _, err := fake.service().GetAccountBalance(context.Background(), "USDT"); err == nil {
+		t.Fatal("GetAccountBalance returned no error for a rejected key")
+	}
+}
+
+func TestPlaceLimitOrder(t *testing.T) {
+	fake := newFakeBinance(t)
+
+	order, err := fake.service().PlaceLimitOrder(context.Background(), "BTCUSDT", models.OrderTypeBuy, 29000.0123, 0.000345678, "")
+	if err != nil {
+		t.Fatalf("PlaceLimitOrder returned error: %v", err)
+	}
+
+	calls := fake.calls("POST /api/v3/order")
+	if len(calls) != 1 {
+		t.Fatalf("got %d order requests, want 1", len(calls))
+	}
+	sent := calls[0]
+	// Price rounds to the 0.01 tick, quantity rounds down to the 0.00001 step
+	for param, want := range map[string]string{
+		"symbol": "BTCUSDT", "side": "BUY", "type": "LIMIT", "timeInForce": "GTC",
+		"price": "29000.01", "quantity": "0.00034",
+	} {
+		if got := sent.Get(param); got != want {
+			t.Errorf("order %s = %q, want %q", param, got, want)
+		}
+	}
+
+	if order.BinanceID != 28 || order.Type != models.OrderTypeBuy || order.Status != models.OrderStatusNew {
+		t.Errorf("order = %+v, want NEW BUY order 28", order)
+	}
+	if order.Price != 29000.01 || order.Quantity != 0.00034 {
+		t.Errorf("order price/quantity = %v/%v, want 29000.01/0.00034", order.Price, order.Quantity)
+	}
+}
+
+func TestPlaceLimitOrderAPIError(t *testing.T) {
+	fake := newFakeBinance(t)
+	fake.fixture("POST /api/v3/order", "error_insufficient_balance.json", http.StatusBadRequest)
+
+	_, err := fake.service().PlaceLimitOrder(context.Background(), "BTCUSDT", models.OrderTypeBuy, 29000, 1, "")
+	if err == nil {
+		t.Fatal("PlaceLimitOrder returned no error for a rejected order")
+	}
+	if !IsInsufficientBalance(err) {
+		t.Errorf("IsInsufficientBalance(%v) = false, want true", err)
+	}
+}
+
+func TestPlaceLimitOrderExchangeInfoError(t *testing.T) {
+	fake := newFakeBinance(t)
+	fake.fixture("GET /api/v3/exchangeInfo", "error_invalid_symbol.json", http.StatusBadRequest)
+
+	if _, err := fake.service().PlaceLimitOrder(context.Background(), "BTCUSDT", models.OrderTypeBuy, 29000, 1, ""); err == nil {
+		t.Fatal("PlaceLimitOrder returned no error without exchange info")
+	}
+	if calls := fake.calls("POST /api/v3/order"); len(calls) != 0 {
+		t.Errorf("order placed without exchange info: %v", calls)
+	}
+}
+
+func TestGetOrderStatus(t *testing.T) {
+	fake := newFakeBinance(t)
+
+	order, err := fake.service().GetOrderStatus(context.Background(), "BTCUSDT", 28)
+	if err != nil {
+		t.Fatalf("GetOrderStatus returned error: %v", err)
+	}
+	if order.Status != models.OrderStatusFilled || order.QuoteQty != 9.8600034 || order.ExecutedAt == nil {
+		t.Errorf("order = %+v, want FILLED with quote quantity 9.8600034 and an execution time", order)
+	}
+	if got := fake.calls("GET /api/v3/order")[0].Get("orderId"); got != "28" {
+		t.Errorf("orderId = %q, want 28", got)
+	}
+}
+
+func TestGetOrderStatusNotFound(t *testing.T) {
+	fake := newFakeBinance(t)
+	fake.fixture("GET /api/v3/order", "error_unknown_order.json", http.StatusBadRequest)
+
+	_, err := fake.service().GetOrderStatus(context.Background(), "BTCUSDT", 99)
+	if !IsOrderNotFound(err) {
+		t.Errorf("IsOrderNotFound(%v) = false, want true", err)
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	fake := newFakeBinance(t)
+	s := fake.service()
+
+	if err := s.CancelOrder(context.Background(), "BTCUSDT", 28); err != nil {
+		t.Fatalf("CancelOrder returned error: %v", err)
+	}
+
+	fake.fixture("DELETE /api/v3/order", "error_unknown_order.json", http.StatusBadRequest)
+	err := s.CancelOrder(context.Background(), "BTCUSDT", 99)
+	if !IsOrderNotFound(err) {
+		t.Errorf("IsOrderNotFound(%v) = false, want true", err)
+	}
+}
+
+func TestGetCommissionRates(t *testing.T) {
+	fake := newFakeBinance(t)
+	fake.respond("GET /api/v3/account", http.StatusOK, `{"makerCommission":2,"takerCommission":4,"balances":[]}`)
+	s := fake.service()
+
+	for range 2 {
+		rates, err := s.GetCommissionRates(context.Background())
+		if err != nil {
+			t.Fatalf("GetCommissionRates returned error: %v", err)
+		}
+		// Basis points: 2 bps is 0.02%
+		if rates.Maker != 0.02 || rates.Taker != 0.04 {
+			t.Errorf("rates = %+v, want maker 0.02%% and taker 0.04%%", rates)
+		}
+	}
+	if calls := fake.calls("GET /api/v3/account"); len(calls) != 1 {
+		t.Errorf("got %d account requests, want the rates fetched once and cached", len(calls))
+	}
+}
+
+func TestGetCommissionRatesAPIError(t *testing.T) {
+	fake := newFakeBinance(t)
+	fake.respond("GET /api/v3/account", http.StatusUnauthorized, `{"code":-2015,"msg":"Invalid API-key, IP, or permissions for action."}`)
+
+	if _, err := fake.service().GetCommissionRates(context.Background()); err == nil {
+		t.Fatal("GetCommissionRates returned no error for a rejected key")
+	}
+}
+
+// referenceDecimalPlaces counts the characters after the first decimal point, one at a time.
+func referenceDecimalPlaces(s string) int {
+	places, seenDot := 0, false
+	for i := 0; i < len(s); i++ {
+		if seenDot {
+			places++
+		} else if s[i] == '.' {
+			seenDot = true
+		}
+	}
+	return places
+}
+
+// FuzzCountDecimalPlaces checks countDecimalPlaces against a reference implementation. The seed corpus
+// in testdata/fuzz holds tick and step sizes as Binance formats them.
+func FuzzCountDecimalPlaces(f *testing.F) {
+	f.Add("0.01000000")
+	f.Fuzz(func(t *testing.T, s string) {
+		if got, want := countDecimalPlaces(s), referenceDecimalPlaces(s); got != want {
+			t.Errorf("countDecimalPlaces(%q) = %d, want %d", s, got, want)
+		}
+	})
+}
+
+func TestRoundAndAlignToIncrement(t *testing.T) {
+	tests := []struct {
+		increment string
+		value     float64 // Float-noisy as computed prices and quantities are
+		mode      roundingMode
+		want      string
+	}{
+		{"0.01000000", 29000.0123, roundDown, "29000.01"},
+		{"0.01000000", 29000.0123, roundUp, "29000.02"},
+		{"0.01000000", 29000.0163, roundNearest, "29000.02"},
+		{"0.01000000", 0.1 + 0.2, roundNearest, "0.3"}, // 0.30000000000000004
+		{"0.01000000", 29000.01, roundDown, "29000.01"},
+		{"0.00001", 0.000345678, roundDown, "0.00034"},
+		{"0.00001", 0.000345678, roundNearest, "0.00035"},
+		{"0.00001", 0.1 * 3, roundDown, "0.3"},
+		{"0.00001", 1.0000099999999999, roundDown, "1"},
+		{"1", 12.999999999999998, roundDown, "12"},
+		{"1", 12.999999999999998, roundNearest, "13"},
+		{"1", 12.5000000001, roundUp, "13"},
+	}
+	for _, tt := range tests {
+		increment := decimal.RequireFromString(tt.increment)
+		rounded := roundToIncrement(decimal.NewFromFloat(tt.value), increment, tt.mode)
+		if !rounded.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("roundToIncrement(%v, %s, %v) = %s, want %s", tt.value, tt.increment, tt.mode, rounded, tt.want)
+		}
+		// A rounded value is on the grid: aligning it leaves it as is, with no residue
+		aligned, residue := alignToIncrement(rounded, increment)
+		if !residue.IsZero() || !aligned.Equal(rounded) {
+			t.Errorf("alignToIncrement(%s, %s) = %s with residue %s, want %s with none", rounded, tt.increment, aligned, residue, rounded)
+		}
+	}
+}
+
+func TestAlignToIncrementResidue(t *testing.T) {
+	tests := []struct {
+		increment, value, wantAligned, wantResidue string
+	}{
+		{"0.01000000", "29000.0123", "29000.01", "0.0023"},
+		{"0.00001", "0.000345678", "0.00034", "0.000005678"},
+		{"1", "12.75", "12", "0.75"},
+		{"1", "12", "12", "0"},
+		{"0", "12.75", "12.75", "0"}, // No increment
+	}
+	for _, tt := range tests {
+		aligned, residue := alignToIncrement(decimal.RequireFromString(tt.value), decimal.RequireFromString(tt.increment))
+		if !aligned.Equal(decimal.RequireFromString(tt.wantAligned)) || !residue.Equal(decimal.RequireFromString(tt.wantResidue)) {
+			t.Errorf("alignToIncrement(%s, %s) = %s, %s, want %s, %s", tt.value, tt.increment, aligned, residue, tt.wantAligned, tt.wantResidue)
+		}
+	}
+}
+
+func TestGetAveragePrice(t *testing.T) {
+	fake := newFakeBinance(t)
+	fake.fixture("GET /api/v3/avgPrice", "avg_price.json", http.StatusOK)
+
+	price, err := fake.service().GetAveragePrice(context.Background(), "BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetAveragePrice returned error: %v", err)
+	}
+	if price != 29950.12345678 {
+		t.Errorf("GetAveragePrice = %v, want 29950.12345678", price)
+	}
+	if calls := fake.calls("GET /api/v3/avgPrice"); len(calls) != 1 || calls[0].Get("symbol") != "BTCUSDT" {
+		t.Errorf("average price requests = %v, want one for BTCUSDT", calls)
+	}
+}
+
+func TestGetAveragePriceErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"API error", http.StatusBadRequest, `{"code":-1121,"msg":"Invalid symbol."}`},
+		{"unparsable price", http.StatusOK, `{"mins":5,"price":"not-a-number"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeBinance(t)
+			fake.respond("GET /api/v3/avgPrice", tt.status, tt.body)
+			if _, err := fake.service().GetAveragePrice(context.Background(), "BTCUSDT"); err == nil {
+				t.Error("GetAveragePrice returned no error")
+			}
+		})
+	}
+}
+
+func TestPlaceLimitOrderRounding(t *testing.T) {
+	tests := []struct {
+		name         string
+		conservative bool
+		side         models.OrderType
+		price        float64
+		wantPrice    string
+	}{
+		{"nearest, half tick", false, models.OrderTypeBuy, 29000.015, "29000.02"},
+		{"nearest, below half tick", false, models.OrderTypeSell, 29000.0149, "29000.01"},
+		{"floor buy, half tick", true, models.OrderTypeBuy, 29000.015, "29000.01"},
+		{"floor buy, just below next tick", true, models.OrderTypeBuy, 29000.0199, "29000.01"},
+		{"ceil sell, just above tick", true, models.OrderTypeSell, 29000.0101, "29000.02"},
+		{"on tick", true, models.OrderTypeBuy, 29000.01, "29000.01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeBinance(t)
+			s := NewBinanceService("test-key", "test-secret", false, tt.conservative, utils.NewLogger())
+			s.SetBaseURL(fake.server.URL)
+
+			// Quantity always rounds down to the 0.00001 step, whatever the price mode
+			if _, err := s.PlaceLimitOrder(context.Background(), "BTCUSDT", tt.side, tt.price, 0.000349999, ""); err != nil {
+				t.Fatalf("PlaceLimitOrder returned error: %v", err)
+			}
+			sent := fake.calls("POST /api/v3/order")[0]
+			if got := sent.Get("price"); got != tt.wantPrice {
+				t.Errorf("price = %s, want %s", got, tt.wantPrice)
+			}
+			if got := sent.Get("quantity"); got != "0.00034" {
+				t.Errorf("quantity = %s, want 0.00034", got)
+			}
+		})
+	}
+}
+
+func TestGetAccountTrades(t *testing.T) {
+	fake := newFakeBinance(t)
+	fake.fixture("GET /api/v3/myTrades", "account_trades.json", http.StatusOK)
+	s := fake.service()
+
+	fills, err := s.GetAccountTrades(context.Background(), "BTCUSDT", 501)
+	if err != nil {
+		t.Fatalf("GetAccountTrades returned error: %v", err)
+	}
+	if calls := fake.calls("GET /api/v3/myTrades"); len(calls) != 1 || calls[0].Get("fromId") != "501" {
+		t.Errorf("myTrades requests = %v, want one from trade 501", calls)
+	}
+	if len(fills) != 3 {
+		t.Fatalf("got %d fills, want 3", len(fills))
+	}
+	first := fills[0]
+	if first.ID != 501 || first.OrderID != 29 || first.Price != 30000 || first.Quantity != 0.0001 ||
+		first.Commission != 0.003 || first.CommissionAsset != "USDT" || first.IsBuyer {
+		t.Errorf("first fill = %+v", first)
+	}
+	if !first.Time.Equal(time.UnixMilli(1760700000000)) {
+		t.Errorf("first fill time = %s", first.Time)
+	}
+
+	summary := SummarizeFills(fills, "BTC", "USDT")
+	if math.Abs(summary.Quantity-0.00034) > 1e-12 || math.Abs(summary.QuoteQuantity-10.228) > 1e-9 {
+		t.Errorf("quantity/quote = %v/%v, want 0.00034/10.228", summary.Quantity, summary.QuoteQuantity)
+	}
+	// (3 + 6.02 + 1.208) / 0.00034
+	if want := 10.228 / 0.00034; math.Abs(summary.AveragePrice-want) > 1e-6 {
+		t.Errorf("average price = %v, want %v", summary.AveragePrice, want)
+	}
+	// The BTC commission of the last fill is valued at its own price
+	if want := 0.003 + 0.00602 + 0.00000004*30200; math.Abs(summary.CommissionUSDT-want) > 1e-12 {
+		t.Errorf("commission = %v, want %v", summary.CommissionUSDT, want)
+	}
+}
+
+func TestSummarizeFillsEmpty(t *testing.T) {
+	if summary := SummarizeFills(nil, "BTC", "USDT"); summary != (FillSummary{}) {
+		t.Errorf("SummarizeFills(nil) = %+v, want zero", summary)
+	}
+}
+
+func TestLiquidateWithFloor(t *testing.T) {
+	fake := newFakeBinance(t)
+	fake.respond("GET /api/v3/ticker/bookTicker", http.StatusOK,
+		`{"symbol":"BTCUSDT","bidPrice":"30000.01000000","bidQty":"0.5","askPrice":"30000.02000000","askQty":"0.5"}`)
+	fake.fixture("POST /api/v3/order", "order_ioc_sell_partial.json", http.StatusOK)
+	s := fake.service()
+
+	order, unfilled, err := s.LiquidateWithFloor(context.Background(), "BTCUSDT", 0.00034, 1)
+	if err != nil {
+		t.Fatalf("LiquidateWithFloor returned error: %v", err)
+	}
+
+	calls := fake.calls("POST /api/v3/order")
+	if len(calls) != 1 {
+		t.Fatalf("got %d orders placed, want 1", len(calls))
+	}
+	req := calls[0]
+	// 1% below the 30000.01 bid is 29700.0099, rounded up to the tick so nothing fills below it
+	if req.Get("side") != "SELL" || req.Get("type") != "LIMIT" || req.Get("timeInForce") != "IOC" ||
+		req.Get("price") != "29700.01" || req.Get("quantity") != "0.00034" {
+		t.Errorf("liquidation order = %v, want an IOC LIMIT SELL of 0.00034 at 29700.01", req)
+	}
+	if order.Quantity != 0.0002 || order.Price < 29700.01 {
+		t.Errorf("filled %v at %v, want 0.0002 at or above the floor", order.Quantity, order.Price)
+	}
+	if math.Abs(unfilled-0.00014) > 1e-12 {
+		t.Errorf("unfilled = %v, want 0.00014", unfilled)
+	}
+}
+
+func TestQuoteQtyRoundedToQuotePrecision(t *testing.T) {
+	exchangeInfo, err := os.ReadFile(filepath.Join("testdata", "exchange_info.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	tests := []struct {
+		precision string
+		want      float64
+	}{
+		{"8", 9.8600034}, // 29000.01 * 0.00034 exactly
+		{"2", 9.86},
+		{"0", 10},
+	}
+	for _, tt := range tests {
+		t.Run("quoteAssetPrecision "+tt.precision, func(t *testing.T) {
+			fake := newFakeBinance(t)
+			fake.respond("GET /api/v3/exchangeInfo", http.StatusOK,
+				strings.Replace(string(exchangeInfo), `"quoteAssetPrecision": 8`, `"quoteAssetPrecision": `+tt.precision, 1))
+			s := fake.service()
+
+			for i := 0; i < 2; i++ {
+				order, err := s.PlaceLimitOrder(context.Background(), "BTCUSDT", models.OrderTypeBuy, 29000.01, 0.00034, "")
+				if err != nil {
+					t.Fatalf("PlaceLimitOrder returned error: %v", err)
+				}
+				if order.QuoteQty != tt.want {
+					t.Errorf("QuoteQty = %v, want %v", order.QuoteQty, tt.want)
+				}
+			}
+			if calls := fake.calls("GET /api/v3/exchangeInfo"); len(calls) != 1 {
+				t.Errorf("exchange info fetched %d times, want once from the cache", len(calls))
+			}
+		})
+	}
+}
+
+func TestRoundToPrecision(t *testing.T) {
+	if got := roundToPrecision(0.1+0.2, 8); got != 0.3 {
+		t.Errorf("roundToPrecision(0.1+0.2, 8) = %v, want 0.3", got)
+	}
+	if got := roundToPrecision(10.000000003, 8); got != 10 {
+		t.Errorf("roundToPrecision(10.000000003, 8) = %v, want 10", got)
+	}
+}
+
+func TestPlaceLimitOrderUsesCachedFilters(t *testing.T) {
+	fake := newFakeBinance(t)
+	s := fake.service()
+	ctx := context.Background()
+
+	if _, err := s.PlaceLimitOrder(ctx, "BTCUSDT", models.OrderTypeBuy, 29000.01, 0.00034, ""); err != nil {
+		t.Fatalf("PlaceLimitOrder returned error: %v", err)
+	}
+	// Expire the cached filters, then make the refresh fail
+	s.symbolInfoCache["BTCUSDT"].fetchedAt = time.Now().Add(-2 * symbolInfoCacheTTL)
+	fake.respond("GET /api/v3/exchangeInfo", http.StatusInternalServerError, `{"code":-1001,"msg":"Internal error; unable to process your request."}`)
+
+	if _, err := s.PlaceLimitOrder(ctx, "BTCUSDT", models.OrderTypeBuy, 29000.0123, 0.000345678, ""); err != nil {
+		t.Fatalf("PlaceLimitOrder with stale cached filters returned error: %v", err)
+	}
+	if calls := fake.calls("GET /api/v3/exchangeInfo"); len(calls) < 2 {
+		t.Errorf("exchange info fetched %d times, want a refresh attempted after the cache expired", len(calls))
+	}
+	calls := fake.calls("POST /api/v3/order")
+	if len(calls) != 2 || calls[1].Get("price") != "29000.01" || calls[1].Get("quantity") != "0.00034" {
+		t.Errorf("order requests = %v, want the second order rounded with the cached filters", calls)
+	}
+
+	// Without cached filters there is nothing to fall back on
+	uncached := fake.service()
+	if _, err := uncached.PlaceLimitOrder(ctx, "BTCUSDT", models.OrderTypeBuy, 29000.01, 0.00034, ""); err == nil {
+		t.Error("PlaceLimitOrder without exchange info or cached filters succeeded, want an error")
+	}
+}
+
+func TestPlaceLimitOrderMissingFilters(t *testing.T) {
+	exchangeInfo, err := os.ReadFile(filepath.Join("testdata", "exchange_info.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	// Drop PRICE_FILTER and LOT_SIZE, as incomplete testnet responses do
+	var incomplete []string
+	for _, line := range strings.Split(string(exchangeInfo), "\n") {
+		if !strings.Contains(line, `"PRICE_FILTER"`) && !strings.Contains(line, `"LOT_SIZE"`) {
+			incomplete = append(incomplete, line)
+		}
+	}
+
+	tests := []struct {
+		name               string
+		pricePrec, qtyPrec int
+		wantPrice, wantQty string
+		wantErr            bool
+	}{
+		{"fallback precision", 2, 5, "29000.01", "0.00034", false},
+		{"coarser fallback", 0, 4, "29000", "0.0003", false},
+		{"no fallback configured", -1, -1, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeBinance(t)
+			fake.respond("GET /api/v3/exchangeInfo", http.StatusOK, strings.Join(incomplete, "\n"))
+			s := fake.service()
+			s.SetDefaultPrecision(tt.pricePrec, tt.qtyPrec)
+
+			_, err := s.PlaceLimitOrder(context.Background(), "BTCUSDT", models.OrderTypeBuy, 29000.0123, 0.000345678, "")
+			if tt.wantErr {
+				if err == nil {
+					t.Error("PlaceLimitOrder succeeded without filters or fallback precision, want an error")
+				}
+				if calls := fake.calls("POST /api/v3/order"); len(calls) != 0 {
+					t.Errorf("placed %d orders, want none", len(calls))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlaceLimitOrder returned error: %v", err)
+			}
+			calls := fake.calls("POST /api/v3/order")
+			if len(calls) != 1 || calls[0].Get("price") != tt.wantPrice || calls[0].Get("quantity") != tt.wantQty {
+				t.Errorf("order requests = %v, want price %s and quantity %s", calls, tt.wantPrice, tt.wantQty)
+			}
+		})
+	}
+}
+
+func TestPlaceLimitOrderMispriced(t *testing.T) {
+	tests := []struct {
+		name      string
+		deviation float64
+		side      models.OrderType
+		price     float64
+		wantErr   bool
+	}{
+		{"buy far above market", 1, models.OrderTypeBuy, 30600, true},
+		{"sell far below market", 1, models.OrderTypeSell, 29600, true},
+		{"buy within deviation", 1, models.OrderTypeBuy, 30200, false},
+		{"sell within deviation", 1, models.OrderTypeSell, 29800, false},
+		{"buy below market", 1, models.OrderTypeBuy, 29000, false},
+		{"check disabled", 0, models.OrderTypeBuy, 30600, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeBinance(t)
+			service := fake.service()
+			service.SetMaxPriceDeviation(tt.deviation)
+
+			_, err := service.PlaceLimitOrder(context.Background(), "BTCUSDT", tt.side, tt.price, 0.00034, "")
+			var mispriced *MispricedOrderError
+			if got := errors.As(err, &mispriced); got != tt.wantErr {
+				t.Fatalf("PlaceLimitOrder error = %v, want MispricedOrderError: %v", err, tt.wantErr)
+			}
+			wantOrders := 1
+			if tt.wantErr {
+				wantOrders = 0
+				if mispriced.Side != tt.side || mispriced.MarketPrice != 30000 {
+					t.Errorf("error = %+v, want %s side against market 30000", mispriced, tt.side)
+				}
+			}
+			if calls := fake.calls("POST /api/v3/order"); len(calls) != wantOrders {
+				t.Errorf("sent %d orders, want %d", len(calls), wantOrders)
+			}
+		})
+	}
+}
+
+func TestPlaceLimitOrderPercentPriceBand(t *testing.T) {
+	exchangeInfo, err := os.ReadFile(filepath.Join("testdata", "exchange_info.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	withFilter := func(filter string) string {
+		return strings.Replace(string(exchangeInfo), `{"filterType": "MAX_NUM_ORDERS"`, filter+`, {"filterType": "MAX_NUM_ORDERS"`, 1)
+	}
+	percentPrice := withFilter(`{"filterType": "PERCENT_PRICE", "multiplierUp": "1.2", "multiplierDown": "0.8", "avgPriceMins": 5}`)
+	bySide := withFilter(`{"filterType": "PERCENT_PRICE_BY_SIDE", "bidMultiplierUp": "1.2", "bidMultiplierDown": "0.9",` +
+		` "askMultiplierUp": "1.1", "askMultiplierDown": "0.8", "avgPriceMins": 5}`)
+
+	// The average price is 29950.12345678
+	tests := []struct {
+		name         string
+		exchangeInfo string
+		side         models.OrderType
+		price        float64
+		wantSkipped  bool
+	}{
+		{"deep rung below the band", percentPrice, models.OrderTypeBuy, 23000, true},
+		{"rung inside the band", percentPrice, models.OrderTypeBuy, 25000, false},
+		{"sell above the band", percentPrice, models.OrderTypeSell, 36000, true},
+		{"bid below its side's band", bySide, models.OrderTypeBuy, 26000, true},
+		{"ask inside its side's band", bySide, models.OrderTypeSell, 26000, false},
+		{"ask above its side's band", bySide, models.OrderTypeSell, 33500, true},
+		{"no band filter", string(exchangeInfo), models.OrderTypeBuy, 23000, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeBinance(t)
+			fake.respond("GET /api/v3/exchangeInfo", http.StatusOK, tt.exchangeInfo)
+			fake.fixture("GET /api/v3/avgPrice", "avg_price.json", http.StatusOK)
+
+			_, err := fake.service().PlaceLimitOrder(context.Background(), "BTCUSDT", tt.side, tt.price, 0.00034, "")
+			var bandErr *PriceOutsideBandError
+			if got := errors.As(err, &bandErr); got != tt.wantSkipped {
+				t.Fatalf("PlaceLimitOrder error = %v, want PriceOutsideBandError: %v", err, tt.wantSkipped)
+			}
+			wantOrders := 1
+			if tt.wantSkipped {
+				wantOrders = 0
+			}
+			if calls := fake.calls("POST /api/v3/order"); len(calls) != wantOrders {
+				t.Errorf("sent %d orders, want %d", len(calls), wantOrders)
+			}
+		})
+	}
+}
+
+func TestValidateBeforePlacing(t *testing.T) {
+	tests := []struct {
+		name       string
+		validate   bool
+		testStatus int
+		testBody   string
+		wantTests  int
+		wantOrders int
+	}{
+		{"validation passes", true, http.StatusOK, "{}", 1, 1},
+		{"validation fails", true, http.StatusBadRequest, `{"code":-1013,"msg":"Filter failure: NOTIONAL"}`, 1, 0},
+		{"validation disabled", false, http.StatusOK, "{}", 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeBinance(t)
+			fake.respond("POST /api/v3/order/test", tt.testStatus, tt.testBody)
+			service := fake.service()
+			service.SetValidateBeforePlacing(tt.validate)
+
+			_, err := service.PlaceLimitOrder(context.Background(), "BTCUSDT", models.OrderTypeBuy, 29000, 0.00034, "")
+			if (err != nil) != (tt.wantOrders == 0) {
+				t.Fatalf("PlaceLimitOrder error = %v, want error: %v", err, tt.wantOrders == 0)
+			}
+			if err != nil && !strings.Contains(err.Error(), "test endpoint") {
+				t.Errorf("error = %v, want it to name the test endpoint", err)
+			}
+			validations := fake.calls("POST /api/v3/order/test")
+			if len(validations) != tt.wantTests {
+				t.Fatalf("sent %d test orders, want %d", len(validations), tt.wantTests)
+			}
+			orders := fake.calls("POST /api/v3/order")
+			if len(orders) != tt.wantOrders {
+				t.Fatalf("placed %d orders, want %d", len(orders), tt.wantOrders)
+			}
+			if tt.wantTests == 1 && tt.wantOrders == 1 && validations[0].Get("price") != orders[0].Get("price") {
+				t.Errorf("validated price %s, placed %s, want the same order", validations[0].Get("price"), orders[0].Get("price"))
+			}
+		})
+	}
+}
+
+func TestPlaceLimitOrderLotSizeRetry(t *testing.T) {
+	exchangeInfo, err := os.ReadFile(filepath.Join("testdata", "exchange_info.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	// A minQty off the step grid: LOT_SIZE accepts 0.000015, 0.000025, ... but not 0.00034
+	offGrid := strings.Replace(string(exchangeInfo), `"minQty": "0.00001000"`, `"minQty": "0.00001500"`, 1)
+	lotSizeRejection := `{"code":-1013,"msg":"Filter failure: LOT_SIZE"}`
+
+	tests := []struct {
+		name      string
+		retry     bool
+		rejection string
+		wantQtys  []string
+		wantErr   bool
+	}{
+		{"retried once with the quantity floored from minQty", true, lotSizeRejection, []string{"0.00034", "0.000335"}, false},
+		{"retry disabled", false, lotSizeRejection, []string{"0.00034"}, true},
+		{"other filter failure not retried", true, `{"code":-1013,"msg":"Filter failure: NOTIONAL"}`, []string{"0.00034"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeBinance(t)
+			fake.respond("GET /api/v3/exchangeInfo", http.StatusOK, offGrid)
+			attempts := 0
+			fake.handle("POST /api/v3/order", func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.Header().Set("Content-Type", "application/json")
+				if attempts == 1 {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(tt.rejection))
+					return
+				}
+				w.Write([]byte(`{"symbol":"BTCUSDT","orderId":28,"price":"29000.00","origQty":"` + r.Form.Get("quantity") + `","status":"NEW","type":"LIMIT","side":"BUY"}`))
+			})
+			service := fake.service()
+			service.SetRetryLotSizeRejections(tt.retry)
+
+			_, err := service.PlaceLimitOrder(context.Background(), "BTCUSDT", models.OrderTypeBuy, 29000, 0.000345678, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PlaceLimitOrder error = %v, wantErr %v", err, tt.wantErr)
+			}
+			calls := fake.calls("POST /api/v3/order")
+			if len(calls) != len(tt.wantQtys) {
+				t.Fatalf("got %d order requests, want %d", len(calls), len(tt.wantQtys))
+			}
+			for i, want := range tt.wantQtys {
+				if got := calls[i].Get("quantity"); got != want {
+					t.Errorf("request %d quantity = %s, want %s", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFloorToLotStep(t *testing.T) {
+	tests := []struct {
+		quantity, minQty, step, want string
+	}{
+		{"0.00034", "0.00001500", "0.00001000", "0.000335"},
+		{"0.00034", "0.00001000", "0.00001000", "0.00034"},
+		{"0.000012", "0.00001500", "0.00001000", "0.000012"}, // below minQty is left alone
+		{"1.5", "0", "0", "1.5"},                             // no step
+	}
+	for _, tt := range tests {
+		got := floorToLotStep(decimal.RequireFromString(tt.quantity), decimal.RequireFromString(tt.minQty), decimal.RequireFromString(tt.step))
+		if !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("floorToLotStep(%s, %s, %s) = %s, want %s", tt.quantity, tt.minQty, tt.step, got, tt.want)
+		}
+	}
+}
+
+func TestGetQuotePrecision(t *testing.T) {
+	exchangeInfo, err := os.ReadFile(filepath.Join("testdata", "exchange_info.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   int
+	}{
+		{"from exchange info", http.StatusOK, strings.Replace(string(exchangeInfo), `"quoteAssetPrecision": 8`, `"quoteAssetPrecision": 2`, 1), 2},
+		{"exchange info unavailable", http.StatusInternalServerError, `{"code":-1000,"msg":"unknown"}`, models.DefaultQuotePrecision},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeBinance(t)
+			fake.respond("GET /api/v3/exchangeInfo", tt.status, tt.body)
+			if got := fake.service().GetQuotePrecision(context.Background(), "BTCUSDT"); got != tt.want {
+				t.Errorf("GetQuotePrecision = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
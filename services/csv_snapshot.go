@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"binance-trader-bot/models"
+	"binance-trader-bot/utils"
+)
+
+// csvSnapshotHeader is the first row of a new CSV_SNAPSHOT_FILE.
+var csvSnapshotHeader = []string{
+	"timestamp", "symbol", "usdt_balance", "reserved_usdt", "available_usdt",
+	"base_balance", "open_position_quantity", "open_trades", "total_profit_usdt",
+}
+
+// CSVSnapshotWriter appends a row with the bot's balances, open trades and profit to a CSV file on an
+// interval, for offline analysis in a spreadsheet without running Prometheus.
+type CSVSnapshotWriter struct {
+	path         string
+	interval     time.Duration
+	symbol       string
+	stateManager *StateManager
+	logger       *utils.Logger
+}
+
+// NewCSVSnapshotWriter creates a CSVSnapshotWriter appending to path every interval.
+func NewCSVSnapshotWriter(path string, interval time.Duration, symbol string, stateManager *StateManager, logger *utils.Logger) *CSVSnapshotWriter {
+	return &CSVSnapshotWriter{
+		path:         path,
+		interval:     interval,
+		symbol:       symbol,
+		stateManager: stateManager,
+		logger:       logger,
+	}
+}
+
+// Run appends a snapshot every interval until ctx is cancelled. A failed write is logged and retried
+// on the next tick.
+func (w *CSVSnapshotWriter) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := w.WriteSnapshot(ctx); err != nil {
+			w.logger.Warnf("Failed to append CSV snapshot to %s: %v", w.path, err)
+		}
+	}
+}
+
+// WriteSnapshot appends one row for the current bot state, writing the header first if the file is new or empty.
+func (w *CSVSnapshotWriter) WriteSnapshot(ctx context.Context) error {
+	openTrades, err := w.stateManager.CountTradesByStatus(ctx, models.TradeStatusOpen)
+	if err != nil {
+		return err
+	}
+	state := w.stateManager.GetBotState()
+	if state == nil {
+		return fmt.Errorf("bot state not loaded")
+	}
+
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	out := csv.NewWriter(file)
+	if info.Size() == 0 {
+		if err := out.Write(csvSnapshotHeader); err != nil {
+			return err
+		}
+	}
+	if err := out.Write([]string{
+		time.Now().UTC().Format(time.RFC3339),
+		w.symbol,
+		formatCSVFloat(state.CurrentUSDTBalance),
+		formatCSVFloat(state.ReservedUSDT),
+		formatCSVFloat(state.AvailableUSDT()),
+		formatCSVFloat(state.CurrentBTCBalance),
+		formatCSVFloat(state.OpenPositionQuantity),
+		strconv.Itoa(openTrades),
+		formatCSVFloat(state.TotalUSDTProfit),
+	}); err != nil {
+		return err
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// formatCSVFloat renders a value in plain decimal notation, never with an exponent, so spreadsheets parse it as a number.
+func formatCSVFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
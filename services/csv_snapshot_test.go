@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"binance-trader-bot/models"
+	"binance-trader-bot/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCSVSnapshotWriterAppendsRows(t *testing.T) {
+	sm, mock := newMockStateManager(t)
+	state := models.NewBotState(1000)
+	state.CurrentUSDTBalance = 950.5
+	state.ReserveUSDT(20)
+	state.CurrentBTCBalance = 0.0000015 // Small enough that %g would print an exponent
+	state.TotalUSDTProfit = 1.25
+	sm.SetBotState(state)
+	for _, count := range []int{2, 3} {
+		mock.ExpectQuery("SELECT COUNT").WithArgs(models.TradeStatusOpen).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+	}
+	path := filepath.Join(t.TempDir(), "snapshots.csv")
+	w := NewCSVSnapshotWriter(path, 0, "BTCUSDT", sm, utils.NewLogger())
+
+	for i := 0; i < 2; i++ {
+		if err := w.WriteSnapshot(context.Background()); err != nil {
+			t.Fatalf("WriteSnapshot returned error: %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open snapshot file: %v", err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != strings.Join(csvSnapshotHeader, ",") {
+		t.Fatalf("records = %v, want the header once followed by two rows", records)
+	}
+	want := []string{"BTCUSDT", "950.5", "20", "930.5", "0.0000015", "0", "2", "1.25"}
+	if got := records[1][1:]; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("first row = %v, want %v", got, want)
+	}
+	if records[2][7] != "3" {
+		t.Errorf("second row open trades = %s, want 3", records[2][7])
+	}
+}
+
+func TestCSVSnapshotWriterCountFailure(t *testing.T) {
+	sm, mock := newMockStateManager(t)
+	sm.SetBotState(models.NewBotState(1000))
+	mock.ExpectQuery("SELECT COUNT").WillReturnError(errors.New("connection refused"))
+	path := filepath.Join(t.TempDir(), "snapshots.csv")
+
+	if err := NewCSVSnapshotWriter(path, 0, "BTCUSDT", sm, utils.NewLogger()).WriteSnapshot(context.Background()); err == nil {
+		t.Fatal("WriteSnapshot returned no error when the open trades could not be counted")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("snapshot file created for a failed snapshot (stat error %v)", err)
+	}
+}
@@ -0,0 +1,21 @@
+package services
+
+import "time"
+
+// priceMoveTracker reports moves larger than a threshold within a sliding window, measured from the
+// oldest price still in the window. It only watches the market; it never influences trading decisions.
+type priceMoveTracker struct {
+	window priceWindow
+}
+
+// observe records price at time at and returns the percentage move from the oldest price within window,
+// and whether its magnitude reached thresholdPercentage. After a move is reported the window restarts
+// from price, so the same move is not reported again on every following observation.
+func (t *priceMoveTracker) observe(at time.Time, price float64, window time.Duration, thresholdPercentage float64) (float64, bool) {
+	move := t.window.observe(at, price, window)
+	if move < thresholdPercentage && move > -thresholdPercentage {
+		return move, false
+	}
+	t.window.restart(at, price)
+	return move, true
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPriceMoveTracker(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		prices     []float64 // One per minute
+		wantAlerts []int     // Indexes of the prices that fire an alert
+	}{
+		{"quiet market", []float64{100, 100.5, 101, 100.2, 99.5}, nil},
+		{"sharp rise", []float64{100, 101, 102, 103.5}, []int{3}},
+		{"sharp drop", []float64{100, 99, 97}, []int{2}},
+		{"slow drift spread over more than the window", []float64{100, 100.9, 101.8, 102.7, 103.6, 104.5}, nil},
+		{"reported once, then measured from the alert", []float64{100, 104, 105, 104, 108}, []int{1, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tracker priceMoveTracker
+			var alerts []int
+			for i, price := range tt.prices {
+				if _, alert := tracker.observe(start.Add(time.Duration(i)*time.Minute), price, 3*time.Minute, 3); alert {
+					alerts = append(alerts, i)
+				}
+			}
+			if len(alerts) != len(tt.wantAlerts) {
+				t.Fatalf("alerts at %v, want %v", alerts, tt.wantAlerts)
+			}
+			for i := range alerts {
+				if alerts[i] != tt.wantAlerts[i] {
+					t.Errorf("alerts at %v, want %v", alerts, tt.wantAlerts)
+				}
+			}
+		})
+	}
+}
+
+func TestPriceMoveTrackerReportsMove(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var tracker priceMoveTracker
+	tracker.observe(start, 200, 5*time.Minute, 2)
+	move, alert := tracker.observe(start.Add(time.Minute), 195, 5*time.Minute, 2)
+	if !alert || math.Abs(move+2.5) > 1e-9 {
+		t.Errorf("observe = %v, %v, want a -2.5%% move reported", move, alert)
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import "time"
+
+// pricePoint is one observed price.
+type pricePoint struct {
+	at    time.Time
+	price float64
+}
+
+// priceWindow keeps the prices observed within a sliding time window and measures the change from the
+// oldest of them. It backs both the price alert and the rise detection of the initial ladder.
+type priceWindow struct {
+	history []pricePoint
+}
+
+// observe records price at time at, drops prices older than window and returns the percentage change
+// from the oldest price still within it, 0 while there is no earlier price to compare against.
+func (w *priceWindow) observe(at time.Time, price float64, window time.Duration) float64 {
+	cutoff := at.Add(-window)
+	kept := w.history[:0]
+	for _, p := range w.history {
+		if !p.at.Before(cutoff) {
+			kept = append(kept, p)
+		}
+	}
+	w.history = append(kept, pricePoint{at: at, price: price})
+
+	oldest := w.history[0].price
+	if oldest <= 0 {
+		return 0
+	}
+	return (price - oldest) / oldest * 100
+}
+
+// restart forgets the history and starts the window again from price.
+func (w *priceWindow) restart(at time.Time, price float64) {
+	w.history = []pricePoint{{at: at, price: price}}
+}
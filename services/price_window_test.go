@@ -0,0 +1,60 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"binance-trader-bot/config"
+)
+
+func TestPriceWindowObserve(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		prices []float64 // One per minute
+		want   float64   // Change over the last five minutes, in percent
+	}{
+		{"rising", []float64{100, 101, 102, 103, 104, 105}, 5},
+		{"falling", []float64{100, 99, 98, 97, 96, 95}, -5},
+		{"flat", []float64{100, 100, 100}, 0},
+		{"old prices dropped", []float64{50, 100, 100, 100, 100, 100, 99}, -1}, // 50 is over 5 minutes old
+		{"single price", []float64{100}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w priceWindow
+			var got float64
+			for i, price := range tt.prices {
+				got = w.observe(start.Add(time.Duration(i)*time.Minute), price, 5*time.Minute)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("observe = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitialBuysPausedOnRise(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		prices []float64 // One per minute
+		want   []bool    // Whether initial buys are paused after each price
+	}{
+		{"rising sharply", []float64{100, 101, 102.5, 103}, []bool{false, false, true, true}},
+		{"falling", []float64{100, 99, 97, 95}, []bool{false, false, false, false}},
+		{"rise then pullback", []float64{100, 103, 101, 100}, []bool{false, true, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, _, _ := newTestStrategy(t, &config.Config{PauseInitialOnRise: 2, PauseInitialRiseWindowMins: 10})
+			for i, price := range tt.prices {
+				ts.recentRise = ts.priceSlope.observe(start.Add(time.Duration(i)*time.Minute), price, 10*time.Minute)
+				if got := ts.initialBuysPausedOnRise(price); got != tt.want[i] {
+					t.Errorf("after price %v (step %d): paused = %t, want %t", price, i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -2,24 +2,39 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"binance-trader-bot/models" // Importar los modelos
 	"binance-trader-bot/repositories"
 	"binance-trader-bot/utils" // Importar el logger
 )
 
+// ErrStateNotSaved is returned by SaveBotStateWithRetry when the bot state could not be persisted.
+var ErrStateNotSaved = errors.New("bot state could not be saved")
+
+const saveBotStateAttempts = 3
+
+// saveBotStateBackoff is the wait before retrying a failed save, doubled after each attempt.
+// A variable so tests can shorten it.
+var saveBotStateBackoff = time.Second
+
 // StateManager handles the persistence and retrieval of the bot's state.
 type StateManager struct {
 	tradeRepo *repositories.TradeRepository // We'll manage trades and bot state via this
+	readRepo  *repositories.TradeRepository // Serves read-only queries for the HTTP API (tradeRepo unless a read replica is set)
 	logger    *utils.Logger
 	botState  *models.BotState // In-memory representation of the bot's state
+
+	strategyTag string // Stamped on new trades that do not carry a tag yet
 }
 
 // NewStateManager creates and returns a new StateManager.
 func NewStateManager(tradeRepo *repositories.TradeRepository, logger *utils.Logger) *StateManager {
 	return &StateManager{
 		tradeRepo: tradeRepo,
+		readRepo:  tradeRepo,
 		logger:    logger,
 	}
 }
@@ -61,6 +76,28 @@ func (sm *StateManager) SaveBotState(ctx context.Context) error {
 	return nil
 }
 
+// SaveBotStateWithRetry saves the bot state, retrying with exponential backoff so a transient
+// database error does not lose the cycle's changes. The error wraps ErrStateNotSaved.
+func (sm *StateManager) SaveBotStateWithRetry(ctx context.Context) error {
+	var err error
+	for attempt := 1; attempt <= saveBotStateAttempts; attempt++ {
+		if err = sm.SaveBotState(ctx); err == nil {
+			return nil
+		}
+		if attempt == saveBotStateAttempts {
+			break
+		}
+		delay := saveBotStateBackoff << (attempt - 1)
+		sm.logger.Warnf("Saving bot state failed (attempt %d/%d), retrying in %s: %v", attempt, saveBotStateAttempts, delay, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrStateNotSaved, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+	return fmt.Errorf("%w after %d attempts: %w", ErrStateNotSaved, saveBotStateAttempts, err)
+}
+
 // GetBotState returns the current in-memory bot state.
 func (sm *StateManager) GetBotState() *models.BotState {
 	return sm.botState
@@ -81,13 +118,38 @@ func (sm *StateManager) UpdateOrder(ctx context.Context, order *models.Order) er
 	return sm.tradeRepo.UpdateOrder(ctx, order) // Assuming UpdateOrder exists
 }
 
+// UpsertOrders saves a batch of new or updated orders in a single transaction.
+func (sm *StateManager) UpsertOrders(ctx context.Context, orders []*models.Order) error {
+	return sm.tradeRepo.UpsertOrders(ctx, orders)
+}
+
 // GetOrder fetches an order by its internal ID or Binance ID.
 func (sm *StateManager) GetOrder(ctx context.Context, binanceID int64) (*models.Order, error) {
 	return sm.tradeRepo.GetOrderByBinanceID(ctx, binanceID) // Assuming GetOrderByBinanceID exists
 }
 
-// AddTrade adds a new trade to the database.
+// GetActiveOrders fetches all orders that are still NEW or PARTIALLY_FILLED locally.
+func (sm *StateManager) GetActiveOrders(ctx context.Context) ([]*models.Order, error) {
+	return sm.tradeRepo.GetOrdersByStatus(ctx, models.OrderStatusNew, models.OrderStatusPartiallyFilled)
+}
+
+// CountBuyOrdersPlacedSince counts the BUY orders for symbol placed since the given time with a
+// Binance ID above afterBinanceID.
+func (sm *StateManager) CountBuyOrdersPlacedSince(ctx context.Context, symbol string, since time.Time, afterBinanceID int64) (int, error) {
+	return sm.tradeRepo.CountBuyOrdersPlacedSince(ctx, symbol, since, afterBinanceID)
+}
+
+// SetStrategyTag sets the tag recorded on trades added from now on, so trades from several bots
+// sharing a database can be told apart.
+func (sm *StateManager) SetStrategyTag(tag string) {
+	sm.strategyTag = tag
+}
+
+// AddTrade adds a new trade to the database, tagged with the strategy tag unless it already has one.
 func (sm *StateManager) AddTrade(ctx context.Context, trade *models.Trade) error {
+	if trade.StrategyTag == "" {
+		trade.StrategyTag = sm.strategyTag
+	}
 	return sm.tradeRepo.CreateTrade(ctx, trade) // Assuming CreateTrade exists
 }
 
@@ -100,3 +162,69 @@ func (sm *StateManager) UpdateTrade(ctx context.Context, trade *models.Trade) er
 func (sm *StateManager) GetOpenTrades(ctx context.Context) ([]*models.Trade, error) {
 	return sm.tradeRepo.GetTradesByStatus(ctx, models.TradeStatusOpen) // Assuming GetTradesByStatus exists
 }
+
+// SetReadRepository routes the read-only trade listings below to a repository on a read replica,
+// so HTTP queries do not compete with the trading loop on the primary. The trading loop itself
+// keeps reading from the primary, where its own writes are always visible.
+func (sm *StateManager) SetReadRepository(readRepo *repositories.TradeRepository) {
+	sm.readRepo = readRepo
+}
+
+// GetTradesByStatus fetches all trades in the given status from the read repository.
+func (sm *StateManager) GetTradesByStatus(ctx context.Context, status models.TradeStatus) ([]*models.Trade, error) {
+	return sm.readRepo.GetTradesByStatus(ctx, status)
+}
+
+// CountTradesByStatus counts the trades in the given status on the read repository.
+func (sm *StateManager) CountTradesByStatus(ctx context.Context, status models.TradeStatus) (int, error) {
+	return sm.readRepo.CountTradesByStatus(ctx, status)
+}
+
+// GetOpenTradeCount counts the OPEN trades on the read repository.
+func (sm *StateManager) GetOpenTradeCount(ctx context.Context) (int, error) {
+	return sm.readRepo.GetOpenTradeCount(ctx)
+}
+
+// GetTotalProfit reads the realized profit last saved with the bot state from the read repository.
+func (sm *StateManager) GetTotalProfit(ctx context.Context) (float64, error) {
+	return sm.readRepo.GetTotalProfit(ctx)
+}
+
+// GetSymbolsWithOpenTrades returns the distinct symbols with OPEN trades from the read repository.
+func (sm *StateManager) GetSymbolsWithOpenTrades(ctx context.Context) ([]string, error) {
+	return sm.readRepo.GetSymbolsWithOpenTrades(ctx)
+}
+
+// GetTradesByStatusAndTag fetches all trades in the given status carrying the given strategy tag
+// from the read repository.
+func (sm *StateManager) GetTradesByStatusAndTag(ctx context.Context, status models.TradeStatus, strategyTag string) ([]*models.Trade, error) {
+	return sm.readRepo.GetTradesByStatusAndTag(ctx, status, strategyTag)
+}
+
+// GetRecentTrades fetches the limit most recently updated trades across all statuses from the read repository.
+func (sm *StateManager) GetRecentTrades(ctx context.Context, limit int) ([]*models.Trade, error) {
+	return sm.readRepo.GetRecentTrades(ctx, limit)
+}
+
+// SavePriceSnapshot records the daily reference price, keeping one already saved for the same day.
+func (sm *StateManager) SavePriceSnapshot(ctx context.Context, snapshot *models.PriceSnapshot) (bool, error) {
+	return sm.tradeRepo.SavePriceSnapshot(ctx, snapshot)
+}
+
+// RecordEvent appends a lifecycle event to the audit log. Failing to record it is logged but never
+// stops the caller: the event log must not get in the way of trading or shutting down.
+func (sm *StateManager) RecordEvent(ctx context.Context, eventType models.EventType, details string) {
+	if err := sm.tradeRepo.CreateEvent(ctx, models.NewEvent(eventType, details)); err != nil {
+		sm.logger.Errorf("Failed to record %s event (%s): %v", eventType, details, err)
+	}
+}
+
+// GetRecentEvents fetches the limit most recent lifecycle events from the read repository.
+func (sm *StateManager) GetRecentEvents(ctx context.Context, limit int) ([]*models.Event, error) {
+	return sm.readRepo.GetRecentEvents(ctx, limit)
+}
+
+// GetMigrationVersion reads the schema migration version and dirty flag from the primary database.
+func (sm *StateManager) GetMigrationVersion(ctx context.Context) (uint, bool, error) {
+	return sm.tradeRepo.GetMigrationVersion(ctx)
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"binance-trader-bot/models"
+	"binance-trader-bot/repositories"
+	"binance-trader-bot/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// newMockStateManager returns a StateManager whose repository runs on a sqlmock database. Expectations
+// match SQL by regular expression and may be met in any order.
+func newMockStateManager(t *testing.T) (*StateManager, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	mock.MatchExpectationsInOrder(false)
+	t.Cleanup(func() { db.Close() })
+	return NewStateManager(repositories.NewTradeRepository(db), utils.NewLogger()), mock
+}
+
+// orderRows returns the orders as rows of the columns the repository selects for orders.
+func orderRows(orders ...*models.Order) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "binance_id", "symbol", "type", "price", "quantity", "quote_qty", "status", "is_test",
+		"placed_at", "executed_at", "last_updated_at", "sell_profit_percentage",
+	})
+	for _, o := range orders {
+		rows.AddRow(o.ID, o.BinanceID, o.Symbol, o.Type, o.Price, o.Quantity, o.QuoteQty, o.Status, o.IsTest,
+			o.PlacedAt, deref(o.ExecutedAt), o.LastUpdatedAt, o.SellProfitPercentage)
+	}
+	return rows
+}
+
+// tradeRows returns the trades as rows of the repository's tradeColumns.
+func tradeRows(trades ...*models.Trade) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "buy_order_id", "sell_order_id", "symbol", "buy_price", "buy_quantity", "sell_price_target",
+		"actual_sell_price", "status", "profit_usdt", "roi_percent", "opened_at", "closed_at", "last_status_update",
+		"error_reason", "reprice_count", "strategy_tag", "sell_profit_percentage",
+	})
+	for _, tr := range trades {
+		rows.AddRow(tr.ID, tr.BuyOrderID, deref(tr.SellOrderID), tr.Symbol, tr.BuyPrice, tr.BuyQuantity, tr.SellPriceTarget,
+			deref(tr.ActualSellPrice), tr.Status, deref(tr.ProfitUSDT), deref(tr.ROIPercent), tr.OpenedAt, deref(tr.ClosedAt), tr.LastStatusUpdate,
+			deref(tr.ErrorReason), tr.RepriceCount, tr.StrategyTag, tr.SellProfitPct)
+	}
+	return rows
+}
+
+// botStateRows returns the state as a row of the columns the repository selects for the bot state.
+func botStateRows(state *models.BotState) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "initial_usdt_investment", "current_usdt_balance", "current_btc_balance", "reserved_usdt",
+		"open_position_quantity", "open_position_cost_basis", "total_usdt_invested", "total_usdt_profit",
+		"total_usdt_withdrawn", "initial_buy_orders_placed_count", "last_initial_buy_order_placed_at",
+		"last_initial_buy_order_id", "twap_slices_placed_count", "is_initial_buying_complete", "initialized",
+		"funds_depleted", "paused", "total_orders_placed", "last_bot_run_timestamp", "created_at", "updated_at",
+	}).AddRow(state.ID, state.InitialUSDTInvestment, state.CurrentUSDTBalance, state.CurrentBTCBalance, state.ReservedUSDT,
+		state.OpenPositionQuantity, state.OpenPositionCostBasis, state.TotalUSDTInvested, state.TotalUSDTProfit,
+		state.TotalUSDTWithdrawn, state.InitialBuyOrdersPlacedCount, deref(state.LastInitialBuyOrderPlacedAt),
+		deref(state.LastInitialBuyOrderID), state.TWAPSlicesPlacedCount, state.IsInitialBuyingComplete, state.Initialized,
+		state.FundsDepleted, state.Paused, state.TotalOrdersPlaced, state.LastBotRunTimestamp, state.CreatedAt, state.UpdatedAt)
+}
+
+// deref returns the value p points to, or nil for a NULL column.
+func deref[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+func TestReadsGoToReadPool(t *testing.T) {
+	sm, primary := newMockStateManager(t)
+	readDB, replica, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	replica.MatchExpectationsInOrder(false)
+	t.Cleanup(func() { readDB.Close() })
+	sm.SetReadRepository(repositories.NewTradeRepository(readDB))
+	ctx := context.Background()
+
+	// Reporting reads are answered by the replica only: the primary has no matching expectations
+	replica.ExpectQuery("FROM trades").WillReturnRows(tradeRows())
+	replica.ExpectQuery("FROM trades").WillReturnRows(tradeRows())
+	replica.ExpectQuery("FROM trades").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	replica.ExpectQuery("FROM events").WillReturnRows(sqlmock.NewRows([]string{"id", "type", "details", "created_at"}))
+	if _, err := sm.GetTradesByStatus(ctx, models.TradeStatusSold); err != nil {
+		t.Errorf("GetTradesByStatus returned error: %v", err)
+	}
+	if _, err := sm.GetRecentTrades(ctx, 10); err != nil {
+		t.Errorf("GetRecentTrades returned error: %v", err)
+	}
+	if _, err := sm.CountTradesByStatus(ctx, models.TradeStatusOpen); err != nil {
+		t.Errorf("CountTradesByStatus returned error: %v", err)
+	}
+	if _, err := sm.GetRecentEvents(ctx, 10); err != nil {
+		t.Errorf("GetRecentEvents returned error: %v", err)
+	}
+
+	// The trading loop reads its own writes from the primary
+	primary.ExpectQuery("FROM trades").WillReturnRows(tradeRows())
+	if _, err := sm.GetOpenTrades(ctx); err != nil {
+		t.Errorf("GetOpenTrades returned error: %v", err)
+	}
+
+	for name, mock := range map[string]sqlmock.Sqlmock{"read pool": replica, "primary": primary} {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestSaveBotStateWithRetry(t *testing.T) {
+	backoff := saveBotStateBackoff
+	saveBotStateBackoff = time.Millisecond
+	t.Cleanup(func() { saveBotStateBackoff = backoff })
+
+	tests := []struct {
+		name     string
+		failures int
+		wantErr  bool
+	}{
+		{"first attempt", 0, false},
+		{"fails twice then succeeds", 2, false},
+		{"fails every attempt", saveBotStateAttempts, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, mock := newMockStateManager(t)
+			sm.SetBotState(models.NewBotState(1000))
+			mock.MatchExpectationsInOrder(true)
+			for i := 0; i < tt.failures; i++ {
+				mock.ExpectExec("INSERT INTO bot_states").WillReturnError(errors.New("connection refused"))
+			}
+			if tt.failures < saveBotStateAttempts {
+				mock.ExpectExec("INSERT INTO bot_states").WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			err := sm.SaveBotStateWithRetry(context.Background())
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("SaveBotStateWithRetry error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrStateNotSaved) {
+				t.Errorf("error = %v, want ErrStateNotSaved", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSaveBotStateWithRetryCancelled(t *testing.T) {
+	sm, mock := newMockStateManager(t)
+	sm.SetBotState(models.NewBotState(1000))
+	mock.ExpectExec("INSERT INTO bot_states").WillReturnError(errors.New("connection refused"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The backoff is not waited out once the context is done
+	err := sm.SaveBotStateWithRetry(ctx)
+	if !errors.Is(err, ErrStateNotSaved) || !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want ErrStateNotSaved wrapping context.Canceled", err)
+	}
+}
+
+func TestAddTradeRecordsStrategyTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		preset  string
+		wantTag string
+	}{
+		{"untagged trade takes the bot's tag", "", "grid-a"},
+		{"existing tag is kept", "manual", "manual"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, mock := newMockStateManager(t)
+			sm.SetStrategyTag("grid-a")
+			trade := models.NewTrade(28, "BTCUSDT", 29000, 0.001, 2)
+			trade.StrategyTag = tt.preset
+
+			anyArg := sqlmock.AnyArg()
+			mock.ExpectQuery("INSERT INTO trades").
+				WithArgs(anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, tt.wantTag, anyArg).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			if err := sm.AddTrade(context.Background(), trade); err != nil {
+				t.Fatalf("AddTrade returned error: %v", err)
+			}
+			if trade.StrategyTag != tt.wantTag {
+				t.Errorf("StrategyTag = %q, want %q", trade.StrategyTag, tt.wantTag)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRecordEvent(t *testing.T) {
+	sm, mock := newMockStateManager(t)
+	mock.ExpectQuery("INSERT INTO events").WithArgs(models.EventConfigReload, "configuration reloaded via SIGHUP", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	sm.RecordEvent(context.Background(), models.EventConfigReload, "configuration reloaded via SIGHUP")
+
+	// A failed write is only logged
+	mock.ExpectQuery("INSERT INTO events").WillReturnError(errors.New("connection refused"))
+	sm.RecordEvent(context.Background(), models.EventStop, "shutdown signal received")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -2,52 +2,178 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"binance-trader-bot/config"
+	"binance-trader-bot/indicators"
+	"binance-trader-bot/metrics"
 	"binance-trader-bot/models"
 	"binance-trader-bot/utils"
 )
 
+// ErrOpenOrderLimit is returned when placing an order would exceed the symbol's MAX_NUM_ORDERS filter.
+var ErrOpenOrderLimit = errors.New("open order limit reached for symbol")
+
+// ErrCycleInProgress is returned by ExecuteTradingCycle when another cycle is still running.
+var ErrCycleInProgress = errors.New("trading cycle already in progress")
+
+// ErrOrderNotFound is returned by CancelOrder when the order is not in the local database.
+var ErrOrderNotFound = errors.New("order not found")
+
 // TradingStrategy implements the core logic of the automated trading bot.
 type TradingStrategy struct {
-	binanceService *BinanceService
-	stateManager   *StateManager
-	config         *config.Config
-	logger         *utils.Logger
+	binanceService      *BinanceService
+	stateManager        *StateManager
+	config              *config.Config
+	metrics             *metrics.Registry
+	configMu            sync.RWMutex // Held for reading during a cycle so reloads never land mid-cycle
+	cycleMu             sync.Mutex   // Serializes cycles, order reconciliation and stream updates so orders are never double-placed
+	cycleRunning        atomic.Bool  // Set while a scheduled cycle runs or waits for cycleMu
+	logger              *utils.Logger
+	stopLossTriggeredAt map[int64]time.Time // Trade ID -> when its price first crossed the stop, pending confirmation
+	sellFailures        map[int64]int       // Trade ID -> consecutive failed attempts to place its sell order
+	triggerReference    float64             // Startup price the INITIAL_TRIGGER_DROP_PERCENTAGE drop is measured from (0 until seen)
+	ladderArmed         bool                // Set once the price has dropped enough to start the initial ladder
+	lastSnapshotDate    string              // UTC day (YYYY-MM-DD) whose price snapshot is already recorded
+	priceMoves          priceMoveTracker    // Recent prices checked against PRICE_ALERT_PERCENTAGE
+	priceSlope          priceWindow         // Recent prices whose rise is checked against PAUSE_INITIAL_ON_RISE
+	recentRise          float64             // Percentage change over PAUSE_INITIAL_RISE_WINDOW_MINUTES as of the last fresh price
+	initialRisePaused   bool                // Set while initial buys wait for a sharp rise to stall
+	lastPrice           float64             // Last reference price fetched successfully (0 until the first fetch)
+	lastPriceAt         time.Time           // When lastPrice was fetched
+	baseAsset           string              // BASE_ASSET, or SYMBOL's base asset from exchange info (empty until resolved)
+	quoteAsset          string              // QUOTE_ASSET, or SYMBOL's quote asset from exchange info (empty until resolved)
+	missingOrders       map[int64]bool      // Binance ID -> placed order Binance did not know right after placement, pending reconciliation
+	symbolHalted        bool                // Set while exchange info reports SYMBOL as not TRADING (e.g. BREAK during maintenance)
 }
 
+// initialLadderOrders is how many buy orders the initial (ladder) phase places.
+const initialLadderOrders = 10
+
+// maxSellPlacementAttempts is how many consecutive cycles may fail to place a trade's sell order
+// before the trade is marked ERROR.
+const maxSellPlacementAttempts = 3
+
 // NewTradingStrategy creates and returns a new TradingStrategy.
 func NewTradingStrategy(
 	binanceService *BinanceService,
 	stateManager *StateManager,
 	cfg *config.Config,
+	metricsRegistry *metrics.Registry,
 	logger *utils.Logger,
 ) *TradingStrategy {
 	return &TradingStrategy{
-		binanceService: binanceService,
-		stateManager:   stateManager,
-		config:         cfg,
-		logger:         logger,
+		binanceService:      binanceService,
+		stateManager:        stateManager,
+		config:              cfg,
+		metrics:             metricsRegistry,
+		logger:              logger,
+		stopLossTriggeredAt: make(map[int64]time.Time),
+		sellFailures:        make(map[int64]int),
+		missingOrders:       make(map[int64]bool),
 	}
 }
 
+// CycleResult summarizes one trading cycle. Errors lists the steps that failed without aborting the cycle.
+type CycleResult struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Errors     []string  `json:"errors"`
+}
+
+func (r *CycleResult) addError(step string, err error) {
+	r.Errors = append(r.Errors, fmt.Sprintf("%s: %v", step, err))
+}
+
 // ExecuteTradingCycle is the main loop function called periodically by main.go.
 // It orchestrates all the trading logic.
 func (ts *TradingStrategy) ExecuteTradingCycle(ctx context.Context) error {
+	if !ts.cycleRunning.CompareAndSwap(false, true) {
+		ts.logger.Warn("Previous trading cycle is still running. Skipping this one.")
+		return ErrCycleInProgress
+	}
+	defer ts.cycleRunning.Store(false)
+
+	// Wait out a short reconciliation or stream update rather than skip the whole cycle
+	ts.cycleMu.Lock()
+	defer ts.cycleMu.Unlock()
+
+	_, err := ts.runCycle(ctx)
+	return err
+}
+
+// RunCycleNow runs one trading cycle on demand, waiting for any cycle already in progress
+// to finish first instead of skipping.
+func (ts *TradingStrategy) RunCycleNow(ctx context.Context) (*CycleResult, error) {
+	ts.cycleMu.Lock()
+	defer ts.cycleMu.Unlock()
+
+	return ts.runCycle(ctx)
+}
+
+// ReconcileOrders checks local open orders against Binance outside of the full trading cycle.
+// It shares the cycle mutex and skips silently while a cycle is running, since the cycle
+// reconciles orders itself.
+func (ts *TradingStrategy) ReconcileOrders(ctx context.Context) error {
+	if !ts.cycleMu.TryLock() {
+		ts.logger.Debug("Trading cycle in progress. Skipping order reconciliation.")
+		return nil
+	}
+	defer ts.cycleMu.Unlock()
+
+	ts.configMu.RLock()
+	defer ts.configMu.RUnlock()
+
+	if ts.stateManager.GetBotState() == nil {
+		return fmt.Errorf("bot state is nil")
+	}
+	if ts.binanceService.CooldownRemaining() > 0 {
+		ts.logger.Debug("Binance API cooldown in effect. Skipping order reconciliation.")
+		return nil
+	}
+	if err := ts.manageOpenOrders(ctx); err != nil {
+		return fmt.Errorf("failed to manage open orders: %w", err)
+	}
+	// Settled fills change balances and profit, so persist them now rather than at the next cycle
+	if err := ts.stateManager.SaveBotStateWithRetry(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// runCycle executes the trading logic. The caller must hold cycleMu.
+func (ts *TradingStrategy) runCycle(ctx context.Context) (*CycleResult, error) {
+	result := &CycleResult{StartedAt: time.Now(), Errors: []string{}}
+	defer func() { result.FinishedAt = time.Now() }()
+
+	ts.configMu.RLock()
+	defer ts.configMu.RUnlock()
+
+	if remaining := ts.binanceService.CooldownRemaining(); remaining > 0 {
+		ts.logger.Warnf("Binance API cooldown in effect for another %s. Skipping trading cycle.", remaining.Round(time.Second))
+		return result, nil
+	}
+
 	ts.logger.Info("Starting new trading cycle...")
 
 	botState := ts.stateManager.GetBotState()
 	if botState == nil {
 		ts.logger.Error("Bot state is nil, cannot proceed with trading cycle. This should not happen after LoadBotState.")
-		return fmt.Errorf("bot state is nil")
+		return result, fmt.Errorf("bot state is nil")
 	}
 
 	// 1. Initialize Bot State if it's new (only first run)
-	if botState.ID == 0 { // A new state, ID is 0 before first save
+	if !botState.Initialized { // Either no row yet or the row seeded by the migration
 		ts.logger.Info("Initializing bot state for the first time...")
 		initialState := models.NewBotState(ts.config.InitialUSDT)
+		initialState.ID = botState.ID
+		initialState.Paused = botState.Paused // A pause requested before the first cycle still applies
+		initialState.MarkInitialized()
 		ts.stateManager.SetBotState(initialState)
 		botState = initialState // Update the local reference
 	}
@@ -57,9 +183,15 @@ func (ts *TradingStrategy) ExecuteTradingCycle(ctx context.Context) error {
 	var btcBal float64
 	var err error // Variable para errores
 
-	usdtBal, err = ts.binanceService.GetAccountBalance(ctx, "USDT")
+	if err := ts.resolveAssets(ctx); err != nil {
+		ts.logger.Errorf("Failed to resolve the assets of %s: %v", ts.config.Symbol, err)
+		return result, fmt.Errorf("failed to resolve symbol assets, skipping cycle: %w", err)
+	}
+
+	usdtBal, err = ts.binanceService.GetAccountBalance(ctx, ts.quoteAsset)
 	if err != nil {
-		ts.logger.Errorf("Failed to refresh USDT balance: %v", err)
+		ts.logger.Errorf("Failed to refresh %s balance: %v", ts.quoteAsset, err)
+		result.addError("refresh quote balance", err)
 		// Decide si quieres retornar, continuar, o manejar este error de otra forma
 		// Por ahora, para que compile y funcione, lo dejaré solo logueado.
 		// Podrías considerar un 'return' o un 'continue' en un ciclo.
@@ -68,117 +200,925 @@ func (ts *TradingStrategy) ExecuteTradingCycle(ctx context.Context) error {
 	}
 
 	// Obtener el balance de BTC
-	btcBal, err = ts.binanceService.GetAccountBalance(ctx, "BTC") // Asumiendo que "BTC" es el asset string
+	btcBal, err = ts.binanceService.GetAccountBalance(ctx, ts.baseAsset)
 	if err != nil {
-		ts.logger.Errorf("Failed to refresh BTC balance: %v", err)
+		ts.logger.Errorf("Failed to refresh %s balance: %v", ts.baseAsset, err)
+		result.addError("refresh base balance", err)
 		// Decide si quieres retornar, continuar, o manejar este error de otra forma
 		// Para depuración, podríamos inicializar btcBal a 0.
 		btcBal = 0 // O manejar el error de otra forma
 	}
 
 	botState.UpdateBalances(usdtBal, btcBal)
-	ts.logger.Infof("Balances refreshed: USDT=%f, BTC=%f", usdtBal, btcBal)
+	ts.logger.Infof("Balances refreshed: %s=%f, %s=%f", ts.quoteAsset, usdtBal, ts.baseAsset, btcBal)
 
 	// 3. Get Current Market Price
-	currentPrice, err := ts.binanceService.GetCurrentPrice(ctx, ts.config.Symbol)
+	currentPrice, err := ts.getReferencePrice(ctx)
+	usingCachedPrice := false
 	if err != nil {
 		ts.logger.Errorf("Failed to get current market price: %v", err)
-		return fmt.Errorf("failed to get current price, skipping cycle: %w", err)
+		cachedPrice, ok := ts.cachedPrice()
+		if !ok {
+			return result, fmt.Errorf("failed to get current price, skipping cycle: %w", err)
+		}
+		result.addError("get current price", err)
+		currentPrice, usingCachedPrice = cachedPrice, true
+		ts.logger.Warnf("Using last known price %s from %s ago. Managing existing orders only; no new buys this cycle.",
+			ts.fmtPrice(currentPrice), time.Since(ts.lastPriceAt).Round(time.Second))
+	} else {
+		ts.lastPrice, ts.lastPriceAt = currentPrice, time.Now()
+		ts.logger.Infof("Current market price for %s: %s", ts.config.Symbol, ts.fmtPrice(currentPrice))
+	}
+
+	if ts.config.DailyPriceSnapshot && !usingCachedPrice {
+		ts.snapshotDailyPrice(ctx, currentPrice)
+	}
+
+	if ts.config.PriceAlertPercentage > 0 && !usingCachedPrice {
+		window := time.Duration(ts.config.PriceAlertWindowMinutes) * time.Minute
+		if move, alert := ts.priceMoves.observe(time.Now(), currentPrice, window, ts.config.PriceAlertPercentage); alert {
+			ts.logger.Warnf("ALERT: %s price moved %+.2f%% within %s (PRICE_ALERT_PERCENTAGE %.2f%%), now %s.",
+				ts.config.Symbol, move, window, ts.config.PriceAlertPercentage, ts.fmtPrice(currentPrice))
+		}
+	}
+
+	if ts.config.PauseInitialOnRise > 0 && !usingCachedPrice {
+		window := time.Duration(ts.config.PauseInitialRiseWindowMins) * time.Minute
+		ts.recentRise = ts.priceSlope.observe(time.Now(), currentPrice, window)
 	}
-	ts.logger.Infof("Current market price for %s: %f", ts.config.Symbol, currentPrice)
+
+	if ts.config.IgnoreDust && botState.CurrentBTCBalance > 0 {
+		ts.handleDust(ctx, currentPrice)
+	}
+
+	marketOpen := !ts.symbolNotTrading(ctx) && !ts.spreadTooWide(ctx)
+	placementAllowed := !botState.Paused && marketOpen
+	if botState.Paused {
+		ts.logger.Warn("Bot is paused via the HTTP API. Managing existing orders and stop-losses only; no new orders will be placed.")
+	}
+	buyingAllowed := ts.updateFundsMode(botState) && !usingCachedPrice && !ts.orderCapReached(botState)
 
 	// 4. Execute Initial Buy Orders
-	if !botState.IsInitialBuyingComplete {
-		ts.logger.Info("Checking for initial buy orders...")
-		if err := ts.placeInitialBuyOrders(ctx, currentPrice); err != nil {
-			ts.logger.Errorf("Error placing initial buy orders: %v", err)
+	if placementAllowed && buyingAllowed && !botState.IsInitialBuyingComplete {
+		if ts.config.Strategy == config.StrategyTWAP {
+			ts.logger.Info("Checking for next TWAP slice...")
+			if err := ts.placeTWAPSlice(ctx); err != nil {
+				ts.logger.Errorf("Error placing TWAP slice: %v", err)
+				result.addError("place TWAP slice", err)
+			}
+		} else {
+			ts.logger.Info("Checking for initial buy orders...")
+			if err := ts.placeInitialBuyOrders(ctx, currentPrice); err != nil {
+				ts.logger.Errorf("Error placing initial buy orders: %v", err)
+				result.addError("place initial buy orders", err)
+			}
 		}
 	}
 
-	// 5. Check and Place Sell Orders for Filled Buy Orders
-	ts.logger.Info("Checking for filled buy orders to place sell orders...")
-	if err := ts.checkAndPlaceSellOrders(ctx, currentPrice); err != nil {
-		ts.logger.Errorf("Error checking and placing sell orders: %v", err)
+	// 5. Check and Place Sell Orders for Filled Buy Orders (stop-losses still run while paused)
+	if marketOpen {
+		ts.logger.Info("Checking for filled buy orders to place sell orders...")
+		if err := ts.checkAndPlaceSellOrders(ctx, currentPrice, placementAllowed); err != nil {
+			ts.logger.Errorf("Error checking and placing sell orders: %v", err)
+			result.addError("place sell orders", err)
+		}
 	}
 
 	// 6. Manage Open Orders (check status and update)
-	ts.logger.Info("Managing open orders...")
-	if err := ts.manageOpenOrders(ctx); err != nil {
-		ts.logger.Errorf("Error managing open orders: %v", err)
+	if ts.config.OrderStatusSource == config.OrderStatusSourceWebSocket {
+		ts.logger.Debug("Order statuses come from the user data stream. Skipping REST order check.")
+	} else {
+		ts.logger.Info("Managing open orders...")
+		if err := ts.manageOpenOrders(ctx); err != nil {
+			ts.logger.Errorf("Error managing open orders: %v", err)
+			result.addError("manage open orders", err)
+		}
 	}
 
 	// 7. Place Additional Buy Orders (if initial phase complete and USDT available)
-	if botState.IsInitialBuyingComplete && botState.CurrentUSDTBalance >= ts.config.OrderAmount {
+	if placementAllowed && buyingAllowed && botState.IsInitialBuyingComplete && ts.hasFundsForOrder(botState) {
 		ts.logger.Info("Checking for additional buy opportunities...")
 		if err := ts.placeAdditionalBuyOrders(ctx, currentPrice); err != nil {
 			ts.logger.Errorf("Error placing additional buy orders: %v", err)
+			result.addError("place additional buy orders", err)
 		}
 	}
 
+	// 7b. Move realized profit to the funding wallet once it exceeds the threshold
+	if ts.config.AutoWithdrawProfitAbove > 0 {
+		ts.withdrawProfit(ctx)
+	}
+
+	ts.updateMetrics(ctx, botState, currentPrice)
+
 	// 8. Save Bot State
-	if err := ts.stateManager.SaveBotState(ctx); err != nil {
-		ts.logger.Fatalf("Failed to save bot state: %v", err) // This is critical
+	if err := ts.stateManager.SaveBotStateWithRetry(ctx); err != nil {
+		ts.logger.Errorf("Failed to save bot state: %v", err) // Critical: main decides whether to keep running
+		result.addError("save bot state", err)
+		return result, err
 	}
 
 	ts.logger.Info("Trading cycle completed.")
+	return result, nil
+}
+
+// WarnUnprofitableTargets logs a warning at startup for each SELL_PROFIT_PERCENTAGE target that does
+// not cover the round-trip fees, since trades closed at it lose money.
+func (ts *TradingStrategy) WarnUnprofitableTargets(ctx context.Context) {
+	fees := ts.roundTripFeePercentage(ctx)
+	for _, p := range ts.config.SellProfitPercentages {
+		if p <= fees {
+			ts.logger.Warnf("SELL_PROFIT_PERCENTAGE (%.4f%%) does not cover round-trip fees (%.4f%%). Trades may close at a loss.", p, fees)
+		}
+	}
+}
+
+// ValidateOrderSizes checks at startup that the configured order sizes meet the symbol's
+// NOTIONAL minimum and that INITIAL_USDT covers at least one order, so misconfiguration
+// fails fast instead of surfacing as rejected orders.
+func (ts *TradingStrategy) ValidateOrderSizes(ctx context.Context) error {
+	limits, err := ts.binanceService.GetSymbolLimits(ctx, ts.config.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to fetch symbol limits: %w", err)
+	}
+
+	orderAmount := ts.buyOrderAmount()
+	amountName := "ORDER_AMOUNT"
+	if ts.config.Strategy == config.StrategyTWAP {
+		amountName = "INITIAL_USDT / TWAP_SLICES"
+	} else if ts.config.OrderAmountPercent > 0 {
+		amountName = "ORDER_AMOUNT_PERCENT of available USDT"
+	}
+
+	if orderAmount < limits.MinNotional {
+		return fmt.Errorf("%s (%.8f) is below the minimum notional %.8f for %s",
+			amountName, orderAmount, limits.MinNotional, ts.config.Symbol)
+	}
+	if ts.config.InitialUSDT < orderAmount {
+		return fmt.Errorf("INITIAL_USDT (%.8f) does not cover a single order of %.8f", ts.config.InitialUSDT, orderAmount)
+	}
+
+	ts.logger.Infof("Order size %.8f meets minimum notional %.8f for %s (%d orders fit in INITIAL_USDT).",
+		orderAmount, limits.MinNotional, ts.config.Symbol, int(ts.config.InitialUSDT/orderAmount))
+	return nil
+}
+
+// buyOrderAmount returns the USDT spent by one buy: a TWAP slice or the ladder order amount.
+func (ts *TradingStrategy) buyOrderAmount() float64 {
+	if ts.config.Strategy == config.StrategyTWAP {
+		return ts.config.InitialUSDT / float64(ts.config.TWAPSlices)
+	}
+	return ts.orderAmount()
+}
+
+// orderAmount returns the USDT spent by one ladder buy: ORDER_AMOUNT, or ORDER_AMOUNT_PERCENT of
+// the USDT currently available to the bot. It is recomputed every time an order is sized, so the
+// orders scale with the account; before the state is loaded INITIAL_USDT stands in for the balance.
+func (ts *TradingStrategy) orderAmount() float64 {
+	if ts.config.OrderAmountPercent <= 0 {
+		return ts.config.OrderAmount
+	}
+	available := ts.config.InitialUSDT
+	if botState := ts.stateManager.GetBotState(); botState != nil && botState.Initialized {
+		available = botState.AvailableUSDT()
+	}
+	return math.Max(available, 0) * ts.config.OrderAmountPercent / 100
+}
+
+// hasFundsForOrder reports whether the USDT available to the bot covers one ladder buy.
+func (ts *TradingStrategy) hasFundsForOrder(botState *models.BotState) bool {
+	amount := ts.orderAmount()
+	return amount > 0 && botState.AvailableUSDT() >= amount
+}
+
+// LadderRung is one buy of the full strategy, priced against a reference market price.
+type LadderRung struct {
+	Phase      string  `json:"phase"`      // "initial", "twap" or "additional"
+	Percentage float64 `json:"percentage"` // Percentage below market the buy is placed at (0 for market buys)
+	Price      float64 `json:"price"`
+	Quantity   float64 `json:"quantity"`
+	USDT       float64 `json:"usdt"`
+}
+
+// LadderEstimate is the USDT needed to fill every rung of the configured strategy.
+type LadderEstimate struct {
+	Rungs     []LadderRung `json:"rungs"`
+	TotalUSDT float64      `json:"total_usdt"`
+}
+
+// EstimateLadderCapital returns the USDT needed to fill every rung at currentPrice: the initial
+// ladder (or every TWAP slice) plus one additional buy per BUY_PERCENTAGES entry. Each buy spends a
+// fixed quote amount, so the total does not depend on the price; the price only sets each rung's
+// limit price and quantity. With ORDER_AMOUNT_PERCENT each rung spends that percentage of what the
+// previous rungs left of INITIAL_USDT.
+func (ts *TradingStrategy) EstimateLadderCapital(currentPrice float64) *LadderEstimate {
+	cfg := ts.Config()
+	estimate := &LadderEstimate{}
+	addRung := func(phase string, percentage, usdt float64) {
+		price := utils.CalculateBuyPrice(currentPrice, percentage)
+		rung := LadderRung{Phase: phase, Percentage: percentage, Price: price, USDT: usdt}
+		if price > 0 {
+			rung.Quantity = usdt / price
+		}
+		estimate.Rungs = append(estimate.Rungs, rung)
+		estimate.TotalUSDT += usdt
+	}
+	ladderAmount := func() float64 {
+		if cfg.OrderAmountPercent <= 0 {
+			return cfg.OrderAmount
+		}
+		return math.Max(cfg.InitialUSDT-estimate.TotalUSDT, 0) * cfg.OrderAmountPercent / 100
+	}
+
+	if cfg.Strategy == config.StrategyTWAP {
+		for i := 0; i < cfg.TWAPSlices; i++ {
+			addRung("twap", 0, cfg.InitialUSDT/float64(cfg.TWAPSlices))
+		}
+	} else {
+		for i := 0; i < initialLadderOrders; i++ {
+			addRung("initial", cfg.InitialBuyPercentage, ladderAmount())
+		}
+	}
+	for _, percentage := range cfg.BuyPercentages {
+		addRung("additional", percentage, ladderAmount())
+	}
+	return estimate
+}
+
+// ComputeUnrealizedPnL returns the unrealized profit in USDT of all OPEN trades at currentPrice,
+// along with the number of open trades it covers.
+func (ts *TradingStrategy) ComputeUnrealizedPnL(ctx context.Context, currentPrice float64) (float64, int, error) {
+	openTrades, err := ts.stateManager.GetOpenTrades(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get open trades: %w", err)
+	}
+
+	return unrealizedPnL(openTrades, currentPrice), len(openTrades), nil
+}
+
+// unrealizedPnL sums (currentPrice - buy price) * quantity over the trades.
+func unrealizedPnL(trades []*models.Trade, currentPrice float64) float64 {
+	pnl := 0.0
+	for _, trade := range trades {
+		pnl += (currentPrice - trade.BuyPrice) * trade.BuyQuantity
+	}
+	return pnl
+}
+
+// SetPaused pauses or resumes the placement of new orders and persists the flag so it survives
+// restarts. While paused, cycles keep managing existing orders and stop-losses still close losing
+// trades. It waits for a running cycle to finish.
+func (ts *TradingStrategy) SetPaused(ctx context.Context, paused bool) error {
+	ts.cycleMu.Lock()
+	defer ts.cycleMu.Unlock()
+
+	botState := ts.stateManager.GetBotState()
+	if botState == nil {
+		return fmt.Errorf("bot state is nil")
+	}
+	if botState.Paused == paused {
+		return nil
+	}
+	botState.SetPaused(paused)
+	if err := ts.stateManager.SaveBotState(ctx); err != nil {
+		botState.SetPaused(!paused)
+		return fmt.Errorf("failed to persist paused=%t: %w", paused, err)
+	}
+	return nil
+}
+
+// Config returns the configuration currently used by the strategy.
+func (ts *TradingStrategy) Config() *config.Config {
+	ts.configMu.RLock()
+	defer ts.configMu.RUnlock()
+	return ts.config
+}
+
+// Snapshot returns a copy of the bot state taken under the cycle mutex, safe to read while cycles,
+// reconciliation and stream updates keep changing the live state. It waits for a running cycle and
+// returns nil before the state is loaded.
+func (ts *TradingStrategy) Snapshot() *models.BotState {
+	ts.cycleMu.Lock()
+	defer ts.cycleMu.Unlock()
+
+	botState := ts.stateManager.GetBotState()
+	if botState == nil {
+		return nil
+	}
+	snapshot := *botState
+	return &snapshot
+}
+
+// UpdateConfig swaps in a reloaded configuration. Changes to structural fields are rejected.
+// The swap waits for any running cycle to finish.
+func (ts *TradingStrategy) UpdateConfig(next *config.Config) error {
+	ts.configMu.Lock()
+	defer ts.configMu.Unlock()
+
+	if err := ts.config.CheckReloadable(next); err != nil {
+		return err
+	}
+	ts.config = next
 	return nil
 }
 
+// fmtPrice formats a price for logging with LOG_PRICE_DECIMALS, or the symbol's tick precision when
+// that is -1. Values kept in memory and stored in the database are never rounded by it.
+func (ts *TradingStrategy) fmtPrice(price float64) string {
+	decimals := ts.config.LogPriceDecimals
+	if decimals < 0 {
+		decimals = ts.binanceService.PriceDecimals(ts.config.Symbol)
+	}
+	return utils.FormatPrice(price, decimals)
+}
+
+// getReferencePrice returns the price the strategy bases its orders on, according to PRICE_SOURCE.
+func (ts *TradingStrategy) getReferencePrice(ctx context.Context) (float64, error) {
+	if ts.config.PriceSource == config.PriceSourceAvg {
+		return ts.binanceService.GetAveragePrice(ctx, ts.config.Symbol)
+	}
+	if ts.config.MaxPriceAgeSeconds <= 0 {
+		return ts.binanceService.GetCurrentPrice(ctx, ts.config.Symbol)
+	}
+
+	// The last price only moves when a trade prints, so in a quiet market it can be old
+	price, tradedAt, err := ts.binanceService.GetLastTradePrice(ctx, ts.config.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	maxAge := time.Duration(ts.config.MaxPriceAgeSeconds) * time.Second
+	if age := time.Since(tradedAt); age > maxAge {
+		ts.logger.Warnf("Last trade price %f for %s is %s old (max %s). Falling back to the book mid price.",
+			price, ts.config.Symbol, age.Round(time.Second), maxAge)
+		bid, ask, err := ts.binanceService.GetBookTicker(ctx, ts.config.Symbol)
+		if err != nil {
+			return 0, fmt.Errorf("last price is stale and book ticker is unavailable: %w", err)
+		}
+		if bid <= 0 || ask <= 0 {
+			return 0, fmt.Errorf("last price is stale and book ticker for %s is empty", ts.config.Symbol)
+		}
+		return (bid + ask) / 2, nil
+	}
+	return price, nil
+}
+
+// updateMetrics refreshes the per-symbol gauges at the end of a cycle.
+func (ts *TradingStrategy) updateMetrics(ctx context.Context, botState *models.BotState, currentPrice float64) {
+	openTrades, err := ts.stateManager.GetOpenTrades(ctx)
+	if err != nil {
+		ts.logger.Warnf("Failed to count open trades for metrics: %v", err)
+	} else {
+		ts.metrics.SetOpenTrades(ts.config.Symbol, len(openTrades))
+		ts.metrics.SetUnrealizedPnLUSDT(ts.config.Symbol, unrealizedPnL(openTrades, currentPrice))
+	}
+	ts.metrics.SetProfitUSDT(ts.config.Symbol, botState.TotalUSDTProfit)
+}
+
+// updateFundsMode moves the bot in and out of "funds depleted" mode and reports whether buying is
+// allowed. Each transition is logged once instead of warning every cycle; the bot resumes buying
+// once available USDT reaches MIN_QUOTE_TO_RESUME (or one order's size).
+func (ts *TradingStrategy) updateFundsMode(botState *models.BotState) bool {
+	available := botState.AvailableUSDT()
+	orderAmount := ts.buyOrderAmount()
+
+	if !botState.FundsDepleted {
+		if available >= orderAmount {
+			return true
+		}
+		botState.SetFundsDepleted(true)
+		ts.logger.Errorf("FUNDS DEPLETED: available USDT %.8f is below the order size %.8f. Buying paused until it reaches %.8f.",
+			available, orderAmount, math.Max(ts.config.MinQuoteToResume, orderAmount))
+		return false
+	}
+
+	resumeAt := math.Max(ts.config.MinQuoteToResume, orderAmount)
+	if available < resumeAt {
+		ts.logger.Debugf("Funds depleted: available USDT %.8f, buying resumes at %.8f.", available, resumeAt)
+		return false
+	}
+	botState.SetFundsDepleted(false)
+	ts.logger.Infof("Funds replenished: available USDT %.8f reached %.8f. Resuming buys.", available, resumeAt)
+	return true
+}
+
+// snapshotDailyPrice records the first price of each UTC day as that day's reference. Once the
+// day's snapshot is stored (by this run or an earlier one) it is not retried until the next day.
+func (ts *TradingStrategy) snapshotDailyPrice(ctx context.Context, currentPrice float64) {
+	snapshot := models.NewPriceSnapshot(ts.config.Symbol, currentPrice, time.Now())
+	day := snapshot.SnapshotDate.Format(time.DateOnly)
+	if day == ts.lastSnapshotDate {
+		return
+	}
+	inserted, err := ts.stateManager.SavePriceSnapshot(ctx, snapshot)
+	if err != nil {
+		ts.logger.Errorf("Failed to save daily price snapshot: %v", err)
+		return
+	}
+	ts.lastSnapshotDate = day
+	if inserted {
+		ts.logger.Infof("Recorded %s reference price %s for %s.", ts.config.Symbol, ts.fmtPrice(currentPrice), day)
+	}
+}
+
+// symbolNotTrading reports whether exchange info lists SYMBOL with a status other than TRADING, such as
+// BREAK or HALT during maintenance, when orders would only be rejected. Placement resumes automatically
+// once the status is TRADING again, with an alert when the halt starts and a notice when it ends. If the
+// status cannot be fetched, the last known one is kept.
+func (ts *TradingStrategy) symbolNotTrading(ctx context.Context) bool {
+	status, err := ts.binanceService.GetSymbolStatus(ctx, ts.config.Symbol)
+	if err != nil {
+		ts.logger.Warnf("Could not check the trading status of %s: %v", ts.config.Symbol, err)
+		return ts.symbolHalted
+	}
+	halted := !IsSymbolTrading(status)
+	if halted && !ts.symbolHalted {
+		ts.logger.Errorf("ALERT: %s is %s on Binance (maintenance?). Managing existing orders only until it is TRADING again.",
+			ts.config.Symbol, status)
+	} else if !halted && ts.symbolHalted {
+		ts.logger.Infof("%s is TRADING again on Binance. Resuming order placement.", ts.config.Symbol)
+	} else if halted {
+		ts.logger.Warnf("%s is still %s on Binance. No new orders will be placed.", ts.config.Symbol, status)
+	}
+	ts.symbolHalted = halted
+	return halted
+}
+
+// spreadTooWide reports whether the current bid-ask spread exceeds MAX_SPREAD_PERCENTAGE.
+// If the book ticker cannot be fetched the guard fails closed and placement is skipped.
+func (ts *TradingStrategy) spreadTooWide(ctx context.Context) bool {
+	if ts.config.MaxSpreadPercentage <= 0 {
+		return false
+	}
+	bid, ask, err := ts.binanceService.GetBookTicker(ctx, ts.config.Symbol)
+	if err != nil {
+		ts.logger.Warnf("Could not check spread, skipping order placement this cycle: %v", err)
+		return true
+	}
+	if bid <= 0 {
+		ts.logger.Warnf("Invalid best bid %f for %s, skipping order placement this cycle.", bid, ts.config.Symbol)
+		return true
+	}
+	spread := (ask - bid) / bid * 100
+	if spread > ts.config.MaxSpreadPercentage {
+		ts.logger.Warnf("Spread for %s is %.4f%% (bid %f, ask %f), above MAX_SPREAD_PERCENTAGE %.4f%%. Skipping order placement this cycle.",
+			ts.config.Symbol, spread, bid, ask, ts.config.MaxSpreadPercentage)
+		return true
+	}
+	return false
+}
+
+// cachedPrice returns the last reference price fetched successfully if it is within
+// CACHED_PRICE_MAX_AGE_SECONDS, so a cycle whose price fetch fails can still manage existing orders.
+func (ts *TradingStrategy) cachedPrice() (float64, bool) {
+	if ts.config.CachedPriceMaxAgeSeconds <= 0 || ts.lastPrice <= 0 {
+		return 0, false
+	}
+	maxAge := time.Duration(ts.config.CachedPriceMaxAgeSeconds) * time.Second
+	if age := time.Since(ts.lastPriceAt); age > maxAge {
+		ts.logger.Warnf("Last known price is %s old (CACHED_PRICE_MAX_AGE_SECONDS %s). Not using it.", age.Round(time.Second), maxAge)
+		return 0, false
+	}
+	return ts.lastPrice, true
+}
+
+// recordOrderPlaced counts an order the bot has just placed, in the metrics and in the bot state's
+// lifetime total checked against MAX_TOTAL_ORDERS, and verifies it with VERIFY_PLACED_ORDERS.
+func (ts *TradingStrategy) recordOrderPlaced(ctx context.Context, order *models.Order) {
+	ts.metrics.IncOrdersPlaced(order.Symbol)
+	ts.stateManager.GetBotState().IncrementOrdersPlaced()
+	ts.verifyOrderPlaced(ctx, order)
+}
+
+// verifyOrderPlaced looks a newly placed limit order up on Binance to catch placements the API
+// acknowledged but that never reached the book (seen on testnet). An order Binance does not know is
+// logged as an alert and marked so that the next reconciliation settles it as REJECTED, releasing
+// what it reserved. Market orders are already filled and are not checked.
+func (ts *TradingStrategy) verifyOrderPlaced(ctx context.Context, order *models.Order) {
+	if !ts.config.VerifyPlacedOrders || order.Status != models.OrderStatusNew {
+		return
+	}
+	_, err := ts.binanceService.GetOrderStatus(ctx, order.Symbol, order.BinanceID)
+	if err == nil {
+		return
+	}
+	if !IsOrderNotFound(err) {
+		ts.logger.Warnf("Could not verify placed order %d: %v", order.BinanceID, err)
+		return
+	}
+	ts.logger.Errorf("ALERT: %s order %d was acknowledged by Binance but does not exist there. Marking it for reconciliation.",
+		order.Type, order.BinanceID)
+	ts.missingOrders[order.BinanceID] = true
+	ts.stateManager.RecordEvent(ctx, models.EventOrderMissing,
+		fmt.Sprintf("%s order %d on %s not found after placement", order.Type, order.BinanceID, order.Symbol))
+}
+
+// orderCapReached reports whether the bot has placed MAX_TOTAL_ORDERS orders, logging an alert if so.
+// The cap guards against a bug placing orders in a loop and only stops new buys: take-profit sells,
+// their repricing and stop-loss exits still close the positions already held.
+func (ts *TradingStrategy) orderCapReached(botState *models.BotState) bool {
+	if ts.config.MaxTotalOrders <= 0 || botState.TotalOrdersPlaced < ts.config.MaxTotalOrders {
+		return false
+	}
+	ts.logger.Errorf("ALERT: %d orders placed, MAX_TOTAL_ORDERS (%d) reached. No new buys will be placed; investigate before raising the cap.",
+		botState.TotalOrdersPlaced, ts.config.MaxTotalOrders)
+	return true
+}
+
+// resolveAssets sets the base and quote assets whose balances the bot tracks: BASE_ASSET and QUOTE_ASSET
+// when set, otherwise the assets exchange info lists for SYMBOL. They are resolved once, on the first cycle.
+func (ts *TradingStrategy) resolveAssets(ctx context.Context) error {
+	if ts.baseAsset != "" && ts.quoteAsset != "" {
+		return nil
+	}
+	base, quote := ts.config.BaseAsset, ts.config.QuoteAsset
+	if base == "" || quote == "" {
+		derivedBase, derivedQuote, err := ts.binanceService.GetSymbolAssets(ctx, ts.config.Symbol)
+		if err != nil {
+			return err
+		}
+		if base == "" {
+			base = derivedBase
+		}
+		if quote == "" {
+			quote = derivedQuote
+		}
+	}
+	ts.baseAsset, ts.quoteAsset = base, quote
+	ts.logger.Infof("Tracking balances of %s (base) and %s (quote) for %s.", base, quote, ts.config.Symbol)
+	return nil
+}
+
+// handleDust treats a base asset balance that is below the symbol's minimum quantity or notional
+// as zero, since it cannot be sold, and optionally converts it to BNB.
+func (ts *TradingStrategy) handleDust(ctx context.Context, currentPrice float64) {
+	botState := ts.stateManager.GetBotState()
+
+	limits, err := ts.binanceService.GetSymbolLimits(ctx, ts.config.Symbol)
+	if err != nil {
+		ts.logger.Warnf("Could not fetch symbol limits for dust check: %v", err)
+		return
+	}
+	if !limits.IsDust(botState.CurrentBTCBalance, currentPrice) {
+		return
+	}
+
+	ts.logger.Infof("%s balance %f is dust (min qty %f, min notional %f). Ignoring it.",
+		ts.baseAsset, botState.CurrentBTCBalance, limits.MinQuantity, limits.MinNotional)
+	if ts.config.ConvertDust {
+		if err := ts.binanceService.ConvertDust(ctx, ts.baseAsset); err != nil {
+			ts.logger.Warnf("Dust conversion failed: %v", err)
+		}
+	}
+	botState.UpdateBalances(botState.CurrentUSDTBalance, 0)
+}
+
+// withdrawProfit transfers all realized, not yet withdrawn profit to the funding wallet
+// when it exceeds AUTO_WITHDRAW_PROFIT_ABOVE.
+func (ts *TradingStrategy) withdrawProfit(ctx context.Context) {
+	botState := ts.stateManager.GetBotState()
+	withdrawable := botState.WithdrawableProfit()
+	if withdrawable <= ts.config.AutoWithdrawProfitAbove {
+		return
+	}
+
+	ts.logger.Infof("Withdrawable profit %f %s exceeds %f. Transferring to funding wallet...",
+		withdrawable, ts.quoteAsset, ts.config.AutoWithdrawProfitAbove)
+	if err := ts.binanceService.TransferToFunding(ctx, ts.quoteAsset, withdrawable); err != nil {
+		ts.logger.Errorf("Profit withdrawal failed: %v", err)
+		return
+	}
+	botState.RecordProfitWithdrawal(withdrawable)
+	botState.UpdateBalances(botState.CurrentUSDTBalance-withdrawable, botState.CurrentBTCBalance)
+}
+
+// initialPhaseOrderDue reports whether the next initial-phase order (ladder buy or TWAP slice) is due,
+// and when it is or was due. The deadline is anchored to the persisted time of the previous order, so
+// after a restart with a downtime longer than the interval the order fires at once instead of waiting
+// another full interval.
+func (ts *TradingStrategy) initialPhaseOrderDue(lastPlacedAt *time.Time, interval time.Duration) (time.Time, bool) {
+	now := time.Now()
+	if lastPlacedAt == nil {
+		return now, true
+	}
+	last := *lastPlacedAt
+	if last.After(now) {
+		// Clock moved backwards since the timestamp was persisted; measure from now rather than wait longer.
+		ts.logger.Warnf("Last initial-phase order timestamp %s is in the future. Measuring the interval from now.", last.Format(time.RFC3339))
+		last = now
+	}
+	next := last.Add(interval)
+	if now.Before(next) {
+		return next, false
+	}
+	if elapsed := now.Sub(last); elapsed > 2*interval {
+		ts.logger.Infof("Last initial-phase order was placed %s ago (interval %s). Placing the next one immediately.",
+			elapsed.Round(time.Second), interval)
+	}
+	return next, true
+}
+
+// initialLadderArmed reports whether the initial ladder may start. With INITIAL_TRIGGER_DROP_PERCENTAGE
+// set, the first price seen after startup becomes the reference and the ladder waits until the price
+// drops that far below it. A ladder that already has orders placed is always armed, so a restart
+// never pauses it halfway.
+func (ts *TradingStrategy) initialLadderArmed(botState *models.BotState, currentPrice float64) bool {
+	if ts.ladderArmed || ts.config.InitialTriggerDrop <= 0 || botState.InitialBuyOrdersPlacedCount > 0 {
+		return true
+	}
+	if ts.triggerReference == 0 {
+		ts.triggerReference = currentPrice
+		ts.logger.Infof("Initial ladder waits for a %.2f%% drop from the startup price %s (trigger at %s).",
+			ts.config.InitialTriggerDrop, ts.fmtPrice(currentPrice), ts.fmtPrice(currentPrice*(1-ts.config.InitialTriggerDrop/100)))
+	}
+
+	triggerPrice := ts.triggerReference * (1 - ts.config.InitialTriggerDrop/100)
+	if currentPrice > triggerPrice {
+		ts.logger.Debugf("Initial ladder not armed: price %f is above the trigger %f.", currentPrice, triggerPrice)
+		return false
+	}
+	ts.ladderArmed = true
+	ts.logger.Infof("Price %s dropped %.2f%% below the reference %s. Initial ladder armed.",
+		ts.fmtPrice(currentPrice), (1-currentPrice/ts.triggerReference)*100, ts.fmtPrice(ts.triggerReference))
+	return true
+}
+
 // placeInitialBuyOrders handles the logic for the first 10 staggered buy orders.
 func (ts *TradingStrategy) placeInitialBuyOrders(ctx context.Context, currentPrice float64) error {
 	botState := ts.stateManager.GetBotState()
 
-	if botState.InitialBuyOrdersPlacedCount >= 10 {
+	if botState.InitialBuyOrdersPlacedCount >= initialLadderOrders {
 		botState.SetInitialBuyingComplete()
 		ts.logger.Info("Initial buying phase complete.")
 		return nil
 	}
 
+	if !ts.initialLadderArmed(botState, currentPrice) {
+		return nil
+	}
+
+	if ts.initialBuysPausedOnRise(currentPrice) {
+		return nil
+	}
+
 	// Check interval since last initial order
-	if botState.LastInitialBuyOrderPlacedAt != nil {
-		nextOrderTime := botState.LastInitialBuyOrderPlacedAt.Add(time.Duration(ts.config.OrderIntervalMinutes) * time.Minute)
-		if time.Now().Before(nextOrderTime) {
+	if nextOrderTime, due := ts.initialPhaseOrderDue(botState.LastInitialBuyOrderPlacedAt, time.Duration(ts.config.OrderIntervalMinutes)*time.Minute); !due {
+		if !ts.config.InitialBuyOnFill || !ts.isLastInitialBuyFilled(ctx) {
 			ts.logger.Debugf("Waiting for next initial buy order interval. Next order at: %s", nextOrderTime.Format(time.RFC3339))
 			return nil
 		}
+		ts.logger.Info("Previous initial buy order filled before the interval elapsed. Placing next initial buy now.")
 	}
 
 	// Ensure enough USDT balance for the order
-	if botState.CurrentUSDTBalance < ts.config.OrderAmount {
-		ts.logger.Warnf("Not enough USDT (%f) to place initial buy order (needs %f). Waiting for funds.",
-			botState.CurrentUSDTBalance, ts.config.OrderAmount)
+	if !ts.hasFundsForOrder(botState) {
+		ts.logger.Warnf("Not enough available USDT (%f) to place initial buy order (needs %f). Waiting for funds.",
+			botState.AvailableUSDT(), ts.orderAmount())
 		return nil
 	}
 
 	buyPrice := utils.CalculateBuyPrice(currentPrice, ts.config.InitialBuyPercentage)
-	// Calculate quantity based on ORDER_AMOUNT and calculated buyPrice
-	quantity := ts.config.OrderAmount / buyPrice
 
-	ts.logger.Infof("Placing initial buy order #%d: %f %s at %.8f USDT (%.2f%% below market %f)",
-		botState.InitialBuyOrdersPlacedCount+1, quantity, ts.config.Symbol, buyPrice, ts.config.InitialBuyPercentage, currentPrice)
+	ts.logger.Infof("Placing initial %s buy order #%d: %.2f USDT of %s (limit %s, %.2f%% below market %s)",
+		ts.config.InitialOrderType, botState.InitialBuyOrdersPlacedCount+1, ts.orderAmount(), ts.config.Symbol,
+		ts.fmtPrice(buyPrice), ts.config.InitialBuyPercentage, ts.fmtPrice(currentPrice))
 
-	order, err := ts.binanceService.PlaceLimitOrder(ctx, ts.config.Symbol, models.OrderTypeBuy, buyPrice, quantity)
+	order, err := ts.placeBuyOrder(ctx, ts.config.InitialOrderType, ts.config.InitialBuyTIF, buyPrice, ts.config.SellProfitForRung(-1))
+	if IsInsufficientBalance(err) {
+		// The rung is retried next cycle; the rest of the cycle still manages existing orders
+		ts.logger.Warnf("Initial buy order #%d skipped: insufficient balance on Binance (%v).", botState.InitialBuyOrdersPlacedCount+1, err)
+		return nil
+	}
 	if err != nil {
 		ts.logger.Errorf("Failed to place initial buy order: %v", err)
 		return err
 	}
 
 	// Save the newly placed order to DB
+	ts.recordOrderPlaced(ctx, order)
 	if err := ts.stateManager.AddOrder(ctx, order); err != nil {
 		ts.logger.Errorf("Failed to save new buy order to DB: %v", err)
 		// This is a serious problem, consider what to do (retry, alert)
 	}
 
 	botState.IncrementInitialBuyOrdersCount()
-	botState.UpdateBalances(botState.CurrentUSDTBalance-ts.config.OrderAmount, botState.CurrentBTCBalance) // Optimistic update
+	botState.SetLastInitialBuyOrderID(order.BinanceID)
 	ts.logger.Infof("Initial buy order #%d placed. Remaining initial orders: %d",
-		botState.InitialBuyOrdersPlacedCount, 10-botState.InitialBuyOrdersPlacedCount)
+		botState.InitialBuyOrdersPlacedCount, initialLadderOrders-botState.InitialBuyOrdersPlacedCount)
+
+	return nil
+}
+
+// initialBuysPausedOnRise reports whether the price rose more than PAUSE_INITIAL_ON_RISE within
+// PAUSE_INITIAL_RISE_WINDOW_MINUTES, so the initial ladder waits for a pullback instead of chasing the
+// move. Buying resumes once the rise over the window falls back below the threshold, i.e. the price
+// stabilizes or drops; the pause and its end are each logged once.
+func (ts *TradingStrategy) initialBuysPausedOnRise(currentPrice float64) bool {
+	rising := ts.config.PauseInitialOnRise > 0 && ts.recentRise > ts.config.PauseInitialOnRise
+	if rising && !ts.initialRisePaused {
+		ts.logger.Infof("%s rose %.2f%% within %d minutes (PAUSE_INITIAL_ON_RISE %.2f%%), now %s. Pausing initial buys until it stabilizes.",
+			ts.config.Symbol, ts.recentRise, ts.config.PauseInitialRiseWindowMins, ts.config.PauseInitialOnRise, ts.fmtPrice(currentPrice))
+	} else if !rising && ts.initialRisePaused {
+		ts.logger.Infof("%s rise eased to %.2f%% within %d minutes. Resuming initial buys.",
+			ts.config.Symbol, ts.recentRise, ts.config.PauseInitialRiseWindowMins)
+	}
+	ts.initialRisePaused = rising
+	return rising
+}
+
+// placeBuyOrder buys one order amount (ORDER_AMOUNT or ORDER_AMOUNT_PERCENT) worth of the symbol, either as a limit order at limitPrice
+// or as a market order, and updates the USDT bookkeeping: limit orders reserve their amount until
+// they close, market orders (and limit orders timeInForce closed on placement) are spent immediately. The order records profitTarget, the sell target
+// of the rung it was placed for.
+//
+// If Binance rejects the order for insufficient balance and SHRINK_BUY_TO_FUNDS is set, it is retried
+// once for the USDT actually free on the account, as long as that still meets the symbol's minimum notional.
+func (ts *TradingStrategy) placeBuyOrder(ctx context.Context, orderType, timeInForce string, limitPrice, profitTarget float64) (*models.Order, error) {
+	amount := ts.orderAmount()
+	order, err := ts.placeBuyOrderFor(ctx, orderType, timeInForce, limitPrice, profitTarget, amount)
+	if err == nil || !IsInsufficientBalance(err) || !ts.config.ShrinkBuyToFunds {
+		return order, err
+	}
+
+	freeUSDT, balanceErr := ts.binanceService.GetAccountBalance(ctx, ts.quoteAsset)
+	if balanceErr != nil {
+		ts.logger.Warnf("Could not fetch free USDT to shrink the buy order: %v", balanceErr)
+		return nil, err
+	}
+	limits, limitsErr := ts.binanceService.GetSymbolLimits(ctx, ts.config.Symbol)
+	if limitsErr != nil || freeUSDT >= amount || freeUSDT < limits.MinNotional || freeUSDT <= 0 {
+		return nil, err
+	}
+	ts.logger.Warnf("Insufficient balance for a %.2f USDT buy. Retrying with the %.2f USDT free on the account (SHRINK_BUY_TO_FUNDS).",
+		amount, freeUSDT)
+	return ts.placeBuyOrderFor(ctx, orderType, timeInForce, limitPrice, profitTarget, freeUSDT)
+}
+
+// placeBuyOrderFor places a buy of amount USDT; see placeBuyOrder.
+func (ts *TradingStrategy) placeBuyOrderFor(ctx context.Context, orderType, timeInForce string, limitPrice, profitTarget, amount float64) (*models.Order, error) {
+	botState := ts.stateManager.GetBotState()
+
+	if orderType == config.OrderTypeMarket {
+		order, err := ts.binanceService.PlaceMarketBuyOrder(ctx, ts.config.Symbol, amount)
+		if err != nil {
+			return nil, err
+		}
+		botState.UpdateBalances(botState.CurrentUSDTBalance-order.QuoteQty, botState.CurrentBTCBalance+order.Quantity) // Optimistic update
+		botState.AddToPosition(order.Quantity, order.QuoteQty)
+		order.SellProfitPercentage = profitTarget
+		return order, nil
+	}
+
+	if ts.openOrderLimitReached(ctx) {
+		return nil, ErrOpenOrderLimit
+	}
+
+	// Calculate quantity based on the amount and the limit price
+	quantity := amount / limitPrice
+	order, err := ts.binanceService.PlaceLimitOrder(ctx, ts.config.Symbol, models.OrderTypeBuy, limitPrice, quantity, timeInForce)
+	if err != nil {
+		return nil, err
+	}
+	switch order.Status {
+	case models.OrderStatusNew, models.OrderStatusPartiallyFilled:
+		botState.ReserveUSDT(order.QuoteQty) // Held until the order fills or is cancelled
+	default:
+		// IOC and FOK buys are already closed: book what filled at once, like a market buy
+		if order.QuoteQty > 0 && order.Price > 0 {
+			executedQty := order.QuoteQty / order.Price
+			botState.UpdateBalances(botState.CurrentUSDTBalance-order.QuoteQty, botState.CurrentBTCBalance+executedQty) // Optimistic update
+			botState.AddToPosition(executedQty, order.QuoteQty)
+		}
+		ts.logger.Infof("%s buy order %d closed on placement as %s (%.8f USDT filled).", timeInForce, order.BinanceID, order.Status, order.QuoteQty)
+	}
+	order.SellProfitPercentage = profitTarget
+	return order, nil
+}
+
+// atrProfitTarget returns the profit target, as a percentage of entryPrice, that lies SELL_TARGET_ATR_MULTIPLE
+// Average True Ranges above it, with the ATR taken over the last ATR_PERIOD ATR_INTERVAL klines. It
+// reports false, leaving the rung's fixed target in place, when the option is off, the klines cannot be
+// fetched, or the target would not cover round-trip fees.
+func (ts *TradingStrategy) atrProfitTarget(ctx context.Context, entryPrice float64) (float64, bool) {
+	if ts.config.SellTargetATRMultiple <= 0 || entryPrice <= 0 {
+		return 0, false
+	}
+	// Extra history lets Wilder's smoothing settle instead of returning a plain mean
+	limit := min(3*ts.config.ATRPeriod+1, 1000)
+	candles, err := ts.binanceService.GetCandles(ctx, ts.config.Symbol, ts.config.ATRInterval, limit)
+	if err != nil {
+		ts.logger.Warnf("Could not fetch klines for the ATR target, using the fixed profit target: %v", err)
+		return 0, false
+	}
+	atr, err := indicators.ATR(candles, ts.config.ATRPeriod)
+	if err != nil {
+		ts.logger.Warnf("Could not compute the ATR target, using the fixed profit target: %v", err)
+		return 0, false
+	}
+
+	target := ts.config.SellTargetATRMultiple * atr / entryPrice * 100
+	if fees := ts.roundTripFeePercentage(ctx); target <= fees {
+		ts.logger.Warnf("ATR target %.4f%% (ATR %s) does not cover round-trip fees (~%.4f%%). Using the fixed profit target.",
+			target, ts.fmtPrice(atr), fees)
+		return 0, false
+	}
+	ts.logger.Infof("ATR(%d, %s) is %s: profit target %.4f%% (%.2f ATR above %s).",
+		ts.config.ATRPeriod, ts.config.ATRInterval, ts.fmtPrice(atr), target, ts.config.SellTargetATRMultiple, ts.fmtPrice(entryPrice))
+	return target, true
+}
+
+// openOrderLimitReached reports whether the symbol already has as many open orders as its
+// MAX_NUM_ORDERS filter allows. Symbols without the filter are never capped; if the count cannot be
+// fetched, placement goes ahead and Binance remains the final check.
+func (ts *TradingStrategy) openOrderLimitReached(ctx context.Context) bool {
+	limits, err := ts.binanceService.GetSymbolLimits(ctx, ts.config.Symbol)
+	if err != nil || limits.MaxOrders <= 0 {
+		return false
+	}
+	openOrders, err := ts.binanceService.CountOpenOrders(ctx, ts.config.Symbol)
+	if err != nil {
+		ts.logger.Warnf("Could not count open orders for %s: %v", ts.config.Symbol, err)
+		return false
+	}
+	if openOrders >= limits.MaxOrders {
+		ts.logger.Warnf("%s has %d open orders, at its MAX_NUM_ORDERS limit of %d. Skipping placement.",
+			ts.config.Symbol, openOrders, limits.MaxOrders)
+		return true
+	}
+	return false
+}
+
+// placeTWAPSlice places the next market buy of a TWAP entry, splitting INITIAL_USDT
+// into TWAP_SLICES equal slices spaced TWAP_INTERVAL_MINUTES apart, regardless of price.
+func (ts *TradingStrategy) placeTWAPSlice(ctx context.Context) error {
+	botState := ts.stateManager.GetBotState()
+
+	if botState.TWAPSlicesPlacedCount >= ts.config.TWAPSlices {
+		botState.SetInitialBuyingComplete()
+		ts.logger.Info("TWAP entry complete.")
+		return nil
+	}
+
+	// Same interval gating as the initial ladder buys
+	if nextSliceTime, due := ts.initialPhaseOrderDue(botState.LastInitialBuyOrderPlacedAt, time.Duration(ts.config.TWAPIntervalMinutes)*time.Minute); !due {
+		ts.logger.Debugf("Waiting for next TWAP slice interval. Next slice at: %s", nextSliceTime.Format(time.RFC3339))
+		return nil
+	}
+
+	sliceAmount := ts.config.InitialUSDT / float64(ts.config.TWAPSlices)
+	if botState.AvailableUSDT() < sliceAmount {
+		ts.logger.Warnf("Not enough available USDT (%f) to place TWAP slice (needs %f). Waiting for funds.",
+			botState.AvailableUSDT(), sliceAmount)
+		return nil
+	}
+
+	ts.logger.Infof("Placing TWAP slice %d/%d: market buy of %.2f USDT on %s",
+		botState.TWAPSlicesPlacedCount+1, ts.config.TWAPSlices, sliceAmount, ts.config.Symbol)
+
+	order, err := ts.binanceService.PlaceMarketBuyOrder(ctx, ts.config.Symbol, sliceAmount)
+	if IsInsufficientBalance(err) {
+		ts.logger.Warnf("TWAP slice skipped: insufficient balance on Binance (%v).", err)
+		return nil
+	}
+	if err != nil {
+		ts.logger.Errorf("Failed to place TWAP slice: %v", err)
+		return err
+	}
+
+	ts.recordOrderPlaced(ctx, order)
+	if err := ts.stateManager.AddOrder(ctx, order); err != nil {
+		ts.logger.Errorf("Failed to save TWAP slice order to DB: %v", err)
+	}
+
+	botState.IncrementTWAPSlicesCount(ts.config.TWAPSlices)
+	botState.SetLastInitialBuyOrderID(order.BinanceID)
+	botState.UpdateBalances(botState.CurrentUSDTBalance-order.QuoteQty, botState.CurrentBTCBalance+order.Quantity) // Optimistic update
+	botState.AddToPosition(order.Quantity, order.QuoteQty)
+	ts.logger.Infof("TWAP slice %d/%d placed at average price %s.",
+		botState.TWAPSlicesPlacedCount, ts.config.TWAPSlices, ts.fmtPrice(order.Price))
 
 	return nil
 }
 
-// checkAndPlaceSellOrders checks for filled buy orders and places corresponding sell orders.
-func (ts *TradingStrategy) checkAndPlaceSellOrders(ctx context.Context, currentPrice float64) error {
+// isLastInitialBuyFilled reports whether the most recent initial buy order is FILLED on Binance.
+// Any lookup failure is treated as "not filled" so the regular interval still applies.
+func (ts *TradingStrategy) isLastInitialBuyFilled(ctx context.Context) bool {
+	botState := ts.stateManager.GetBotState()
+	if botState.LastInitialBuyOrderID == nil {
+		return false
+	}
+
+	order, err := ts.binanceService.GetOrderStatus(ctx, ts.config.Symbol, *botState.LastInitialBuyOrderID)
+	if err != nil {
+		ts.logger.Warnf("Could not check status of last initial buy order %d: %v", *botState.LastInitialBuyOrderID, err)
+		return false
+	}
+	return order.Status == models.OrderStatusFilled
+}
+
+// checkAndPlaceSellOrders checks for filled buy orders and places corresponding sell orders. With
+// placeNew false (the bot is paused) it only runs stop-losses and settles filled sells: take-profit
+// sells are neither placed nor repriced.
+func (ts *TradingStrategy) checkAndPlaceSellOrders(ctx context.Context, currentPrice float64, placeNew bool) error {
 	openTrades, err := ts.stateManager.GetOpenTrades(ctx) // Get trades where buy order is filled but sell is not
 	if err != nil {
 		return fmt.Errorf("failed to get open trades: %w", err)
@@ -204,28 +1144,61 @@ func (ts *TradingStrategy) checkAndPlaceSellOrders(ctx context.Context, currentP
 			continue
 		}
 
+		if ts.config.StopLossPercentage > 0 {
+			closed, err := ts.checkStopLoss(ctx, trade, buyOrder, currentPrice)
+			if err != nil {
+				ts.logger.Errorf("Stop-loss check failed for trade %d: %v", trade.ID, err)
+				continue
+			}
+			if closed {
+				continue
+			}
+		}
+
 		// If a sell order for this trade hasn't been placed yet
 		if trade.SellOrderID == nil {
+			if !placeNew {
+				ts.logger.Debugf("Bot is paused. Deferring sell order for trade %d.", trade.ID)
+				continue
+			}
+			if holdUntil, held := ts.minHoldUntil(trade); !held {
+				ts.logger.Debugf("Trade %d is within MIN_HOLD_MINUTES. Deferring sell order until %s.", trade.ID, holdUntil.Format(time.RFC3339))
+				continue
+			}
+			if ts.openOrderLimitReached(ctx) {
+				ts.logger.Warnf("Open order limit reached for %s. Deferring sell order for trade %d.", ts.config.Symbol, trade.ID)
+				continue
+			}
 			ts.logger.Infof("Buy order %d for trade %d is FILLED. Placing sell order...", buyOrder.BinanceID, trade.ID)
-			sellPrice := utils.CalculateSellPrice(buyOrder.Price, ts.config.SellProfitPercentage)
+			profitTarget := buyOrder.SellProfitPercentage // Target of the rung the buy was placed for
+			if profitTarget <= 0 {
+				profitTarget = ts.config.SellProfitPercentage
+			}
+			if atrTarget, ok := ts.atrProfitTarget(ctx, buyOrder.Price); ok {
+				profitTarget = atrTarget
+			}
+			sellPrice := utils.CalculateSellPrice(buyOrder.Price, profitTarget)
 			// Quantity to sell is the quantity that was bought
 			quantityToSell := buyOrder.Quantity
 
-			ts.logger.Infof("Placing sell order for trade %d: %f %s at %.8f USDT (%.2f%% profit target)",
-				trade.ID, quantityToSell, ts.config.Symbol, sellPrice, ts.config.SellProfitPercentage)
+			ts.logger.Infof("Placing sell order for trade %d: %f %s at %s USDT (%.2f%% profit target)",
+				trade.ID, quantityToSell, ts.config.Symbol, ts.fmtPrice(sellPrice), profitTarget)
 
-			sellOrder, err := ts.binanceService.PlaceLimitOrder(ctx, ts.config.Symbol, models.OrderTypeSell, sellPrice, quantityToSell)
+			sellOrder, err := ts.binanceService.PlaceLimitOrder(ctx, ts.config.Symbol, models.OrderTypeSell, sellPrice, quantityToSell, config.TimeInForceGTC)
 			if err != nil {
 				ts.logger.Errorf("Failed to place sell order for trade %d (BuyOrderID %d): %v", trade.ID, trade.BuyOrderID, err)
-				// Consider marking trade as ERROR or retrying
+				ts.handleSellPlacementFailure(ctx, trade, err)
 				continue
 			}
+			delete(ts.sellFailures, trade.ID)
 
 			// Update Trade with sell order ID and save sell order to DB
 			trade.SetSellOrder(sellOrder.BinanceID)
+			trade.SellProfitPct = profitTarget
 			if err := ts.stateManager.UpdateTrade(ctx, trade); err != nil {
 				ts.logger.Errorf("Failed to update trade %d with sell order ID: %v", trade.ID, err)
 			}
+			ts.recordOrderPlaced(ctx, sellOrder)
 			if err := ts.stateManager.AddOrder(ctx, sellOrder); err != nil {
 				ts.logger.Errorf("Failed to save new sell order %d to DB: %v", sellOrder.BinanceID, err)
 			}
@@ -240,7 +1213,7 @@ func (ts *TradingStrategy) checkAndPlaceSellOrders(ctx context.Context, currentP
 
 			if sellOrder.Status == models.OrderStatusFilled {
 				ts.logger.Infof("Sell order %d for trade %d is FILLED! Marking trade as SOLD.", sellOrder.BinanceID, trade.ID)
-				trade.MarkAsSold(sellOrder.Price) // Use the actual executed price from the sell order
+				ts.settleSoldTrade(ctx, trade, sellOrder)
 				if err := ts.stateManager.UpdateTrade(ctx, trade); err != nil {
 					ts.logger.Errorf("Failed to mark trade %d as SOLD: %v", trade.ID, err)
 				}
@@ -252,14 +1225,298 @@ func (ts *TradingStrategy) checkAndPlaceSellOrders(ctx context.Context, currentP
 				// Also update balances based on the full trade execution
 				// For simplicity, we update based on current balances from Binance, which should reflect this.
 				// A more precise calculation would adjust balances by order amounts, but less robust if Binance API is preferred source.
-			} else {
+			} else if placeNew {
 				ts.logger.Debugf("Sell order %d for trade %d is still %s.", sellOrder.BinanceID, trade.ID, sellOrder.Status)
+				ttl := time.Duration(ts.config.SellOrderTTLMinutes) * time.Minute
+				if ttl > 0 && sellOrder.Status == models.OrderStatusNew && time.Since(sellOrder.PlacedAt) >= ttl {
+					if err := ts.expireSellOrder(ctx, trade, buyOrder, sellOrder); err != nil {
+						ts.logger.Errorf("Failed to handle expired sell order %d for trade %d: %v", sellOrder.BinanceID, trade.ID, err)
+					}
+				} else if ts.config.SellRepriceAfterMinutes > 0 && sellOrder.Status == models.OrderStatusNew {
+					repriceAfter := time.Duration(ts.config.SellRepriceAfterMinutes) * time.Minute
+					if err := ts.repriceSellOrder(ctx, trade, buyOrder, sellOrder, repriceAfter); err != nil {
+						ts.logger.Errorf("Failed to reprice sell order %d for trade %d: %v", sellOrder.BinanceID, trade.ID, err)
+					}
+				}
 			}
 		}
 	}
 	return nil
 }
 
+// minHoldUntil returns when the trade's MIN_HOLD_MINUTES elapse and whether they already have.
+// The stop-loss is not subject to the hold; only placing the take-profit sell is deferred.
+func (ts *TradingStrategy) minHoldUntil(trade *models.Trade) (time.Time, bool) {
+	holdUntil := trade.OpenedAt.Add(time.Duration(ts.config.MinHoldMinutes) * time.Minute)
+	return holdUntil, !time.Now().Before(holdUntil)
+}
+
+// checkStopLoss market-sells a trade whose price has fallen STOP_LOSS_PERCENTAGE below its entry.
+// The stop only executes once the price has stayed below it for STOP_LOSS_CONFIRM_SECONDS and a
+// fresh price confirms it is still below both the stop and the entry, so brief wicks are ignored.
+// It returns true if the trade was closed.
+func (ts *TradingStrategy) checkStopLoss(ctx context.Context, trade *models.Trade, buyOrder *models.Order, currentPrice float64) (bool, error) {
+	stopPrice := utils.CalculateBuyPrice(buyOrder.Price, ts.config.StopLossPercentage)
+
+	if currentPrice > stopPrice {
+		if _, pending := ts.stopLossTriggeredAt[trade.ID]; pending {
+			ts.logger.Infof("Price recovered above stop (%s) for trade %d. Stop-loss cancelled.", ts.fmtPrice(stopPrice), trade.ID)
+			delete(ts.stopLossTriggeredAt, trade.ID)
+		}
+		return false, nil
+	}
+
+	triggeredAt, pending := ts.stopLossTriggeredAt[trade.ID]
+	if !pending {
+		triggeredAt = time.Now()
+		ts.stopLossTriggeredAt[trade.ID] = triggeredAt
+		ts.logger.Warnf("Price %.8f crossed stop %.8f for trade %d. Waiting %ds for confirmation.",
+			currentPrice, stopPrice, trade.ID, ts.config.StopLossConfirmSeconds)
+	}
+
+	confirmDelay := time.Duration(ts.config.StopLossConfirmSeconds) * time.Second
+	if time.Since(triggeredAt) < confirmDelay {
+		ts.logger.Debugf("Stop-loss for trade %d pending confirmation until %s", trade.ID, triggeredAt.Add(confirmDelay).Format(time.RFC3339))
+		return false, nil
+	}
+
+	// Re-check with a fresh price so a wick that recovered in the meantime does not liquidate the position.
+	freshPrice, err := ts.stopLossPrice(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to re-check price for stop-loss: %w", err)
+	}
+	if freshPrice > stopPrice || freshPrice >= buyOrder.Price {
+		ts.logger.Infof("Fresh price %s no longer below stop %s for trade %d. Stop-loss cancelled.", ts.fmtPrice(freshPrice), ts.fmtPrice(stopPrice), trade.ID)
+		delete(ts.stopLossTriggeredAt, trade.ID)
+		return false, nil
+	}
+	delete(ts.stopLossTriggeredAt, trade.ID)
+
+	ts.logger.Warnf("Stop-loss confirmed for trade %d at %.8f (entry %.8f). Selling at market.", trade.ID, freshPrice, buyOrder.Price)
+
+	if err := ts.closeTradeAtMarket(ctx, trade, buyOrder, "stop-loss"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// stopLossPrice returns the price a confirmed stop-loss is re-checked against. With
+// STOP_LOSS_MAX_PRICE_AGE_SECONDS set it is the last trade price, and an error if that trade is older
+// than the limit, so a position is never liquidated on stale data even where regular operations accept
+// it; the stop stays pending and is re-checked next cycle. Otherwise it is the regular reference price.
+func (ts *TradingStrategy) stopLossPrice(ctx context.Context) (float64, error) {
+	if ts.config.StopLossMaxPriceAgeSeconds <= 0 {
+		return ts.getReferencePrice(ctx)
+	}
+	price, tradedAt, err := ts.binanceService.GetLastTradePrice(ctx, ts.config.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	maxAge := time.Duration(ts.config.StopLossMaxPriceAgeSeconds) * time.Second
+	if age := time.Since(tradedAt); age > maxAge {
+		ts.logger.Warnf("Last trade price %s for %s is %s old (STOP_LOSS_MAX_PRICE_AGE_SECONDS %s). Refusing to liquidate on it.",
+			ts.fmtPrice(price), ts.config.Symbol, age.Round(time.Second), maxAge)
+		return 0, fmt.Errorf("last trade price is %s old, older than STOP_LOSS_MAX_PRICE_AGE_SECONDS", age.Round(time.Second))
+	}
+	return price, nil
+}
+
+// closeTradeAtMarket cancels the trade's resting take-profit order, if any, sells the bought
+// quantity at market (or with the liquidation floor) and marks the trade SOLD. reason names the
+// caller in errors and logs.
+func (ts *TradingStrategy) closeTradeAtMarket(ctx context.Context, trade *models.Trade, buyOrder *models.Order, reason string) error {
+	// Release the quantity locked by the take-profit order before selling at market
+	if trade.SellOrderID != nil {
+		if err := ts.binanceService.CancelOrder(ctx, ts.config.Symbol, *trade.SellOrderID); err != nil {
+			return fmt.Errorf("failed to cancel take-profit order %d: %w", *trade.SellOrderID, err)
+		}
+		if takeProfitOrder, err := ts.stateManager.GetOrder(ctx, *trade.SellOrderID); err == nil {
+			takeProfitOrder.UpdateStatus(models.OrderStatusCanceled)
+			if err := ts.stateManager.UpdateOrder(ctx, takeProfitOrder); err != nil {
+				ts.logger.Errorf("Failed to update cancelled take-profit order %d in DB: %v", takeProfitOrder.BinanceID, err)
+			}
+		}
+	}
+
+	sellOrder, err := ts.liquidate(ctx, buyOrder.Quantity)
+	if err != nil {
+		return fmt.Errorf("failed to place %s sell order: %w", reason, err)
+	}
+	ts.recordOrderPlaced(ctx, sellOrder)
+	if err := ts.stateManager.AddOrder(ctx, sellOrder); err != nil {
+		ts.logger.Errorf("Failed to save %s sell order %d to DB: %v", reason, sellOrder.BinanceID, err)
+	}
+
+	ts.stateManager.RecordEvent(ctx, models.EventLiquidation, fmt.Sprintf("trade %d closed by %s: %f %s at %f (order %d)",
+		trade.ID, reason, sellOrder.Quantity, ts.config.Symbol, sellOrder.Price, sellOrder.BinanceID))
+
+	trade.SetSellOrder(sellOrder.BinanceID)
+	trade.MarkAsSold(sellOrder.Price, ts.binanceService.GetQuotePrecision(ctx, ts.config.Symbol))
+	ts.stateManager.GetBotState().ReduceFromPosition(sellOrder.Quantity)
+	if err := ts.stateManager.UpdateTrade(ctx, trade); err != nil {
+		ts.logger.Errorf("Failed to mark trade %d as SOLD after %s: %v", trade.ID, reason, err)
+	}
+	if trade.ProfitUSDT != nil {
+		ts.stateManager.GetBotState().UpdateInvestedAndProfit(0, *trade.ProfitUSDT)
+	}
+	return nil
+}
+
+// roundTripFeePercentage returns the fees of a buy plus its sell as a percentage of the trade, from the
+// account's commission rates. Taker rates are used for both legs so break-even is never underestimated.
+// If the rates cannot be fetched it falls back to the standard Binance fee.
+func (ts *TradingStrategy) roundTripFeePercentage(ctx context.Context) float64 {
+	rates, err := ts.binanceService.GetCommissionRates(ctx)
+	if err != nil {
+		ts.logger.Warnf("Could not fetch commission rates, assuming standard fees: %v", err)
+		return ts.config.RoundTripFeePercentage()
+	}
+	return 2 * rates.Taker
+}
+
+// expireSellOrder handles a sell that has rested unfilled for SELL_ORDER_TTL_MINUTES according to
+// SELL_ORDER_TTL_ACTION: repriced toward the break-even floor, or cancelled and sold at market.
+func (ts *TradingStrategy) expireSellOrder(ctx context.Context, trade *models.Trade, buyOrder, sellOrder *models.Order) error {
+	ttl := time.Duration(ts.config.SellOrderTTLMinutes) * time.Minute
+	if ts.config.SellOrderTTLAction == config.SellOrderTTLActionMarket {
+		ts.logger.Warnf("Sell order %d for trade %d unfilled for over %s (SELL_ORDER_TTL_MINUTES). Selling at market.",
+			sellOrder.BinanceID, trade.ID, ttl)
+		return ts.closeTradeAtMarket(ctx, trade, buyOrder, "expired sell")
+	}
+	return ts.repriceSellOrder(ctx, trade, buyOrder, sellOrder, ttl)
+}
+
+// repriceSellOrder lowers an unfilled sell halfway toward the minimum acceptable price once it has
+// rested for repriceAfter. The floor keeps SELL_REPRICE_MIN_PROFIT_PERCENTAGE of net profit after
+// round-trip fees, so repricing never turns the trade into a loss.
+func (ts *TradingStrategy) repriceSellOrder(ctx context.Context, trade *models.Trade, buyOrder, sellOrder *models.Order, repriceAfter time.Duration) error {
+	if time.Since(sellOrder.PlacedAt) < repriceAfter {
+		return nil
+	}
+
+	floorPrice := utils.CalculateSellPrice(buyOrder.Price, ts.config.SellRepriceMinProfit+ts.roundTripFeePercentage(ctx))
+	// Stop once the next step would move the price by less than 0.01%; the order is effectively at the floor
+	if sellOrder.Price-floorPrice <= sellOrder.Price*0.0001 {
+		ts.logger.Debugf("Sell order %d for trade %d is already at its reprice floor %.8f.", sellOrder.BinanceID, trade.ID, floorPrice)
+		return nil
+	}
+	newPrice := (sellOrder.Price + floorPrice) / 2
+
+	ts.logger.Infof("Sell order %d for trade %d unfilled for over %s. Repricing %s -> %s (floor %s).",
+		sellOrder.BinanceID, trade.ID, repriceAfter, ts.fmtPrice(sellOrder.Price), ts.fmtPrice(newPrice), ts.fmtPrice(floorPrice))
+
+	if err := ts.binanceService.CancelOrder(ctx, ts.config.Symbol, sellOrder.BinanceID); err != nil {
+		return fmt.Errorf("failed to cancel sell order: %w", err)
+	}
+	sellOrder.UpdateStatus(models.OrderStatusCanceled)
+	if err := ts.stateManager.UpdateOrder(ctx, sellOrder); err != nil {
+		ts.logger.Errorf("Failed to update cancelled sell order %d in DB: %v", sellOrder.BinanceID, err)
+	}
+
+	newSellOrder, err := ts.binanceService.PlaceLimitOrder(ctx, ts.config.Symbol, models.OrderTypeSell, newPrice, sellOrder.Quantity, config.TimeInForceGTC)
+	if err != nil {
+		// Clear the cancelled order so the next cycle places a fresh sell
+		trade.SellOrderID = nil
+		if updateErr := ts.stateManager.UpdateTrade(ctx, trade); updateErr != nil {
+			ts.logger.Errorf("Failed to clear sell order of trade %d: %v", trade.ID, updateErr)
+		}
+		return fmt.Errorf("failed to place repriced sell order: %w", err)
+	}
+	ts.recordOrderPlaced(ctx, newSellOrder)
+	if err := ts.stateManager.AddOrder(ctx, newSellOrder); err != nil {
+		ts.logger.Errorf("Failed to save repriced sell order %d to DB: %v", newSellOrder.BinanceID, err)
+	}
+
+	trade.Reprice(newSellOrder.BinanceID, newSellOrder.Price)
+	if err := ts.stateManager.UpdateTrade(ctx, trade); err != nil {
+		ts.logger.Errorf("Failed to update trade %d after reprice: %v", trade.ID, err)
+	}
+	ts.logger.Infof("Trade %d repriced (%d so far): new sell order %d at %s.", trade.ID, trade.RepriceCount, newSellOrder.BinanceID, ts.fmtPrice(newSellOrder.Price))
+	return nil
+}
+
+// handleSellPlacementFailure marks a trade ERROR once its sell order cannot be placed: at once if the
+// balance is insufficient, otherwise after maxSellPlacementAttempts consecutive failures. A sell outside
+// the PERCENT_PRICE band is only deferred.
+func (ts *TradingStrategy) handleSellPlacementFailure(ctx context.Context, trade *models.Trade, placeErr error) {
+	var bandErr *PriceOutsideBandError
+	if errors.As(placeErr, &bandErr) {
+		// Not the trade's fault: the target is valid again once the average price moves closer
+		ts.logger.Warnf("Sell order for trade %d deferred: %v", trade.ID, placeErr)
+		return
+	}
+
+	var reason string
+	if IsInsufficientBalance(placeErr) {
+		reason = fmt.Sprintf("insufficient balance to place sell order: %v", placeErr)
+	} else {
+		ts.sellFailures[trade.ID]++
+		attempts := ts.sellFailures[trade.ID]
+		if attempts < maxSellPlacementAttempts {
+			ts.logger.Warnf("Sell order for trade %d failed (attempt %d/%d). Retrying next cycle.", trade.ID, attempts, maxSellPlacementAttempts)
+			return
+		}
+		reason = fmt.Sprintf("sell order could not be placed after %d attempts: %v", attempts, placeErr)
+	}
+
+	delete(ts.sellFailures, trade.ID)
+	ts.logger.Errorf("Marking trade %d as ERROR: %s", trade.ID, reason)
+	trade.MarkAsError(reason)
+	if err := ts.stateManager.UpdateTrade(ctx, trade); err != nil {
+		ts.logger.Errorf("Failed to mark trade %d as ERROR: %v", trade.ID, err)
+	}
+}
+
+// settleSoldTrade marks a trade as SOLD using the executed fills from Binance, so the realized profit
+// reflects the weighted average sell price and the commissions paid on both legs. If the fills
+// cannot be fetched it falls back to the sell order's price without fees.
+func (ts *TradingStrategy) settleSoldTrade(ctx context.Context, trade *models.Trade, sellOrder *models.Order) {
+	ts.stateManager.GetBotState().ReduceFromPosition(sellOrder.Quantity)
+	if err := ts.resolveAssets(ctx); err != nil { // Commissions are valued by asset; may run before the first cycle
+		ts.logger.Warnf("Could not resolve the assets of %s, commissions will not be valued: %v", ts.config.Symbol, err)
+	}
+
+	quotePrecision := ts.binanceService.GetQuotePrecision(ctx, ts.config.Symbol)
+	sellFills, err := ts.binanceService.GetOrderFills(ctx, ts.config.Symbol, sellOrder.BinanceID)
+	if err != nil || len(sellFills) == 0 {
+		ts.logger.Warnf("Could not fetch fills for sell order %d, using order price and estimated fees for trade %d: %v", sellOrder.BinanceID, trade.ID, err)
+		trade.MarkAsSold(sellOrder.Price, quotePrecision)
+		trade.DeductFees((trade.BuyPrice+sellOrder.Price)*trade.BuyQuantity*ts.roundTripFeePercentage(ctx)/200, quotePrecision)
+		return
+	}
+	sellSummary := SummarizeFills(sellFills, ts.baseAsset, ts.quoteAsset)
+	trade.MarkAsSold(sellSummary.AveragePrice, quotePrecision)
+
+	fees := sellSummary.CommissionUSDT
+	if buyFills, err := ts.binanceService.GetOrderFills(ctx, ts.config.Symbol, trade.BuyOrderID); err == nil {
+		fees += SummarizeFills(buyFills, ts.baseAsset, ts.quoteAsset).CommissionUSDT
+	} else {
+		ts.logger.Warnf("Could not fetch fills for buy order %d, buy-side fees not deducted for trade %d: %v", trade.BuyOrderID, trade.ID, err)
+	}
+	trade.DeductFees(fees, quotePrecision)
+	ts.logger.Infof("Trade %d settled at average price %s with %.8f USDT fees.", trade.ID, ts.fmtPrice(sellSummary.AveragePrice), fees)
+}
+
+// liquidate sells quantity immediately. With LIQUIDATION_MAX_SLIPPAGE_PERCENTAGE set it uses a
+// marketable limit order so fills cannot happen below the floor; otherwise a plain market order.
+func (ts *TradingStrategy) liquidate(ctx context.Context, quantity float64) (*models.Order, error) {
+	if ts.config.LiquidationMaxSlippage <= 0 {
+		return ts.binanceService.PlaceMarketSellOrder(ctx, ts.config.Symbol, quantity)
+	}
+
+	order, unfilled, err := ts.binanceService.LiquidateWithFloor(ctx, ts.config.Symbol, quantity, ts.config.LiquidationMaxSlippage)
+	if err != nil {
+		return nil, err
+	}
+	if order.Quantity == 0 {
+		return nil, fmt.Errorf("no liquidity above the %.2f%% price floor, nothing sold", ts.config.LiquidationMaxSlippage)
+	}
+	if unfilled > 0 {
+		ts.logger.Warnf("Liquidation left %f %s unsold below the price floor. Manual action may be required.", unfilled, ts.config.Symbol)
+	}
+	return order, nil
+}
+
 // manageOpenOrders periodically checks the status of all open orders (buy and sell)
 // and updates their status in the database.
 func (ts *TradingStrategy) manageOpenOrders(ctx context.Context) error {
@@ -284,28 +1541,242 @@ func (ts *TradingStrategy) manageOpenOrders(ctx context.Context) error {
 		}
 
 		// Check if the status has changed
-		newStatus := models.OrderStatus(openOrder.Status)
-		if localOrder.Status != newStatus {
-			ts.logger.Infof("Updating status for order %d from %s to %s",
-				localOrder.BinanceID, localOrder.Status, newStatus)
-			localOrder.UpdateStatus(newStatus)
-			if err := ts.stateManager.UpdateOrder(ctx, localOrder); err != nil {
-				ts.logger.Errorf("Failed to update status of order %d in DB: %v", localOrder.BinanceID, err)
-			}
+		ts.applyOrderUpdate(ctx, localOrder, OrderUpdate{
+			BinanceID: binanceID,
+			Symbol:    openOrder.Symbol,
+			Status:    models.OrderStatus(openOrder.Status),
+		})
+	}
+
+	// Orders that left Binance's open list were filled, cancelled or expired since the last cycle
+	openIDs := make(map[int64]bool, len(openOrders))
+	for _, openOrder := range openOrders {
+		openIDs[openOrder.OrderID] = true
+	}
+	activeOrders, err := ts.stateManager.GetActiveOrders(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get active orders from DB: %w", err)
+	}
+	for _, localOrder := range activeOrders {
+		if openIDs[localOrder.BinanceID] {
+			continue
 		}
+		ts.settleClosedOrder(ctx, localOrder)
 	}
 	return nil
 }
 
+// settleClosedOrder records the final status of an order that is no longer open on Binance,
+// fetching it over REST.
+func (ts *TradingStrategy) settleClosedOrder(ctx context.Context, localOrder *models.Order) {
+	remoteOrder, err := ts.binanceService.GetOrderStatus(ctx, localOrder.Symbol, localOrder.BinanceID)
+	if err != nil && IsOrderNotFound(err) && ts.missingOrders[localOrder.BinanceID] {
+		ts.logger.Warnf("Order %d never reached Binance. Settling it as %s.", localOrder.BinanceID, models.OrderStatusRejected)
+		delete(ts.missingOrders, localOrder.BinanceID)
+		ts.applyOrderUpdate(ctx, localOrder, OrderUpdate{
+			BinanceID: localOrder.BinanceID,
+			Symbol:    localOrder.Symbol,
+			Status:    models.OrderStatusRejected,
+		})
+		return
+	}
+	if err != nil {
+		ts.logger.Warnf("Could not fetch final status of order %d: %v", localOrder.BinanceID, err)
+		return
+	}
+	delete(ts.missingOrders, localOrder.BinanceID)
+
+	// The REST order reports the original quantity; derive what was executed from the quote spent
+	executedQty := remoteOrder.Quantity
+	if remoteOrder.Status != models.OrderStatusFilled {
+		executedQty = 0
+		if localOrder.Price > 0 {
+			executedQty = remoteOrder.QuoteQty / localOrder.Price
+		}
+	}
+	ts.applyOrderUpdate(ctx, localOrder, OrderUpdate{
+		BinanceID:      remoteOrder.BinanceID,
+		Symbol:         remoteOrder.Symbol,
+		Status:         remoteOrder.Status,
+		ExecutedQty:    executedQty,
+		ExecutedQuote:  remoteOrder.QuoteQty,
+		FromUserStream: false,
+	})
+}
+
+// applyOrderUpdate is the single place where a status change reported by Binance, over REST or the
+// user data stream, is applied to a local order. When a buy closes it releases the USDT reserved for
+// it and adds what was bought to the open position; for a buy cancelled after a partial fill, only the
+// unspent part is returned to available capital.
+func (ts *TradingStrategy) applyOrderUpdate(ctx context.Context, localOrder *models.Order, update OrderUpdate) {
+	if update.Status == localOrder.Status {
+		return
+	}
+
+	source := "REST"
+	if update.FromUserStream {
+		source = "user data stream"
+	}
+	ts.logger.Infof("Order %d status %s -> %s (via %s)", localOrder.BinanceID, localOrder.Status, update.Status, source)
+	reserved := localOrder.QuoteQty
+	localOrder.UpdateStatus(update.Status)
+	if err := ts.stateManager.UpdateOrder(ctx, localOrder); err != nil {
+		ts.logger.Errorf("Failed to update status of order %d in DB: %v", localOrder.BinanceID, err)
+	}
+
+	if localOrder.Type != models.OrderTypeBuy {
+		return
+	}
+	botState := ts.stateManager.GetBotState()
+	switch update.Status {
+	case models.OrderStatusFilled:
+		botState.ReleaseUSDT(reserved) // Spent: the balance refresh now reflects it
+		botState.AddToPosition(update.ExecutedQty, update.ExecutedQuote)
+	case models.OrderStatusCanceled, models.OrderStatusExpired, models.OrderStatusRejected:
+		botState.ReleaseUSDT(reserved)
+		if update.ExecutedQuote > 0 {
+			botState.AddToPosition(update.ExecutedQty, update.ExecutedQuote) // Partially filled before it closed
+		}
+		ts.logger.Infof("Released %.8f USDT reserved by buy order %d (%.8f was spent before it closed).",
+			reserved-update.ExecutedQuote, localOrder.BinanceID, update.ExecutedQuote)
+	}
+}
+
+// CancelOpenOrders cancels every open order on the symbol and settles the local orders from their final
+// status on Binance, releasing the USDT reserved by cancelled buys. It waits for any running cycle and
+// returns how many orders Binance cancelled.
+func (ts *TradingStrategy) CancelOpenOrders(ctx context.Context) (int, error) {
+	ts.cycleMu.Lock()
+	defer ts.cycleMu.Unlock()
+
+	ts.configMu.RLock()
+	defer ts.configMu.RUnlock()
+
+	cancelled, err := ts.binanceService.CancelAllOpenOrders(ctx, ts.config.Symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	activeOrders, err := ts.stateManager.GetActiveOrders(ctx)
+	if err != nil {
+		return cancelled, fmt.Errorf("failed to get active orders from DB: %w", err)
+	}
+	for _, localOrder := range activeOrders {
+		ts.settleClosedOrder(ctx, localOrder) // Also catches orders that filled just before the cancel
+	}
+	if ts.stateManager.GetBotState() != nil {
+		if err := ts.stateManager.SaveBotState(ctx); err != nil {
+			return cancelled, fmt.Errorf("failed to save bot state after cancelling orders: %w", err)
+		}
+	}
+	return cancelled, nil
+}
+
+// CancelOrder cancels a single order on Binance and settles it like any other closed order: a buy
+// releases its reserved USDT and books what filled before the cancel, and a cancelled sell is detached
+// from its trade so the next cycle places a fresh one. It waits for any running cycle.
+func (ts *TradingStrategy) CancelOrder(ctx context.Context, binanceID int64) (*models.Order, error) {
+	ts.cycleMu.Lock()
+	defer ts.cycleMu.Unlock()
+
+	ts.configMu.RLock()
+	defer ts.configMu.RUnlock()
+
+	localOrder, err := ts.stateManager.GetOrder(ctx, binanceID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOrderNotFound, err)
+	}
+	if err := ts.binanceService.CancelOrder(ctx, localOrder.Symbol, binanceID); err != nil {
+		return nil, err
+	}
+
+	ts.settleClosedOrder(ctx, localOrder) // Picks up a partial fill, or a fill that beat the cancel
+	if localOrder.Status == models.OrderStatusNew || localOrder.Status == models.OrderStatusPartiallyFilled {
+		// Binance accepted the cancel but its final status could not be fetched
+		ts.applyOrderUpdate(ctx, localOrder, OrderUpdate{
+			BinanceID: binanceID,
+			Symbol:    localOrder.Symbol,
+			Status:    models.OrderStatusCanceled,
+		})
+	}
+	if localOrder.Type == models.OrderTypeSell && localOrder.Status != models.OrderStatusFilled {
+		ts.detachSellOrder(ctx, binanceID)
+	}
+
+	if ts.stateManager.GetBotState() != nil {
+		if err := ts.stateManager.SaveBotState(ctx); err != nil {
+			ts.logger.Errorf("Failed to save bot state after cancelling order %d: %v", binanceID, err)
+		}
+	}
+	return localOrder, nil
+}
+
+// detachSellOrder clears the sell order of the open trade waiting on it, so the trade is not left
+// pointing at an order that can no longer fill.
+func (ts *TradingStrategy) detachSellOrder(ctx context.Context, sellOrderID int64) {
+	openTrades, err := ts.stateManager.GetOpenTrades(ctx)
+	if err != nil {
+		ts.logger.Errorf("Failed to retrieve open trades to detach sell order %d: %v", sellOrderID, err)
+		return
+	}
+	for _, trade := range openTrades {
+		if trade.SellOrderID == nil || *trade.SellOrderID != sellOrderID {
+			continue
+		}
+		trade.SellOrderID = nil
+		if err := ts.stateManager.UpdateTrade(ctx, trade); err != nil {
+			ts.logger.Errorf("Failed to clear sell order of trade %d: %v", trade.ID, err)
+			return
+		}
+		ts.logger.Infof("Sell order %d of trade %d cancelled. A new sell will be placed on the next cycle.", sellOrderID, trade.ID)
+		return
+	}
+}
+
+// HandleOrderUpdate applies an order update pushed by the user data stream. It waits for any running
+// cycle so stream events and cycles never change the state at the same time.
+func (ts *TradingStrategy) HandleOrderUpdate(ctx context.Context, update OrderUpdate) {
+	ts.cycleMu.Lock()
+	defer ts.cycleMu.Unlock()
+
+	ts.configMu.RLock()
+	defer ts.configMu.RUnlock()
+
+	if update.Symbol != ts.config.Symbol || ts.stateManager.GetBotState() == nil {
+		return
+	}
+	localOrder, err := ts.stateManager.GetOrder(ctx, update.BinanceID)
+	if err != nil {
+		ts.logger.Debugf("Order %d from user data stream not found in local DB. Ignoring.", update.BinanceID)
+		return
+	}
+	ts.applyOrderUpdate(ctx, localOrder, update)
+	if err := ts.stateManager.SaveBotState(ctx); err != nil {
+		ts.logger.Errorf("Failed to save bot state after order update: %v", err)
+	}
+}
+
+// additionalBuysToday counts the additional buy orders placed since the start of now's UTC day.
+// Initial buys and TWAP slices (up to LastInitialBuyOrderID) are not counted.
+func (ts *TradingStrategy) additionalBuysToday(ctx context.Context, botState *models.BotState, now time.Time) (int, error) {
+	now = now.UTC()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var lastInitialBuyID int64
+	if botState.LastInitialBuyOrderID != nil {
+		lastInitialBuyID = *botState.LastInitialBuyOrderID
+	}
+	return ts.stateManager.CountBuyOrdersPlacedSince(ctx, ts.config.Symbol, startOfDay, lastInitialBuyID)
+}
+
 // placeAdditionalBuyOrders checks if there are opportunities for additional buys
 // based on BUY_PERCENTAGES and available USDT.
 func (ts *TradingStrategy) placeAdditionalBuyOrders(ctx context.Context, currentPrice float64) error {
 	botState := ts.stateManager.GetBotState()
 
 	// Ensure there's enough USDT for another order
-	if botState.CurrentUSDTBalance < ts.config.OrderAmount {
-		ts.logger.Debugf("Not enough USDT (%f) for an additional buy order (needs %f).",
-			botState.CurrentUSDTBalance, ts.config.OrderAmount)
+	if !ts.hasFundsForOrder(botState) {
+		ts.logger.Debugf("Not enough available USDT (%f) for an additional buy order (needs %f).",
+			botState.AvailableUSDT(), ts.orderAmount())
 		return nil
 	}
 
@@ -323,28 +1794,45 @@ func (ts *TradingStrategy) placeAdditionalBuyOrders(ctx context.Context, current
 		return nil
 	}
 
+	if ts.config.MaxAdditionalBuysPerDay > 0 {
+		placedToday, err := ts.additionalBuysToday(ctx, botState, time.Now())
+		if err != nil {
+			ts.logger.Errorf("Failed to count today's additional buy orders: %v", err)
+			return err
+		}
+		if placedToday >= ts.config.MaxAdditionalBuysPerDay {
+			ts.logger.Infof("MAX_ADDITIONAL_BUYS_PER_DAY (%d) reached for today (UTC). Skipping additional buy order.", ts.config.MaxAdditionalBuysPerDay)
+			return nil
+		}
+	}
+
 	// ... el resto de la lógica de placeAdditionalBuyOrders ...
 
 	// Si inicial buying is complete, and we have enough USDT, and no pending buy orders (simplified)
-	if botState.IsInitialBuyingComplete && botState.CurrentUSDTBalance >= ts.config.OrderAmount {
+	if botState.IsInitialBuyingComplete && ts.hasFundsForOrder(botState) {
 		if len(ts.config.BuyPercentages) > 0 {
-			chosenPercentage := ts.config.BuyPercentages[0]
+			// Each open position pushes the next buy one rung deeper, staying on the last rung once past it
+			rung := min(len(allTrades), len(ts.config.BuyPercentages)-1)
+			chosenPercentage := ts.config.BuyPercentages[rung]
 			potentialBuyPrice := utils.CalculateBuyPrice(currentPrice, chosenPercentage)
 
-			ts.logger.Infof("Placing additional buy order: %f %s at %.8f USDT (%.2f%% below market %f)",
-				ts.config.OrderAmount/potentialBuyPrice, ts.config.Symbol, potentialBuyPrice, chosenPercentage, currentPrice)
+			ts.logger.Infof("Placing additional %s buy order on rung %d: %.2f USDT of %s (limit %s, %.2f%% below market %s)",
+				ts.config.AdditionalOrderType, rung, ts.orderAmount(), ts.config.Symbol, ts.fmtPrice(potentialBuyPrice), chosenPercentage, ts.fmtPrice(currentPrice))
 
-			quantity := ts.config.OrderAmount / potentialBuyPrice
-			order, err := ts.binanceService.PlaceLimitOrder(ctx, ts.config.Symbol, models.OrderTypeBuy, potentialBuyPrice, quantity)
+			order, err := ts.placeBuyOrder(ctx, ts.config.AdditionalOrderType, ts.config.AdditionalBuyTIF, potentialBuyPrice, ts.config.SellProfitForRung(rung))
+			if IsInsufficientBalance(err) {
+				ts.logger.Warnf("Additional buy order skipped: insufficient balance on Binance (%v).", err)
+				return nil
+			}
 			if err != nil {
 				ts.logger.Errorf("Failed to place additional buy order: %v", err)
 				return err
 			}
 
+			ts.recordOrderPlaced(ctx, order)
 			if err := ts.stateManager.AddOrder(ctx, order); err != nil {
 				ts.logger.Errorf("Failed to save additional buy order to DB: %v", err)
 			}
-			botState.UpdateBalances(botState.CurrentUSDTBalance-ts.config.OrderAmount, botState.CurrentBTCBalance)
 			ts.logger.Infof("Additional buy order %d placed.", order.BinanceID)
 		} else {
 			ts.logger.Debug("No BUY_PERCENTAGES defined for additional buys.")
@@ -0,0 +1,2236 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"binance-trader-bot/config"
+	"binance-trader-bot/metrics"
+	"binance-trader-bot/models"
+	"binance-trader-bot/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/adshao/go-binance/v2/common"
+)
+
+// newTestStrategy returns a TradingStrategy for BTCUSDT trading against a fake Binance server and a
+// sqlmock database, with a fresh bot state of 1000 USDT already loaded.
+func newTestStrategy(t *testing.T, cfg *config.Config) (*TradingStrategy, *fakeBinance, sqlmock.Sqlmock) {
+	t.Helper()
+	if cfg.Symbol == "" {
+		cfg.Symbol = "BTCUSDT"
+	}
+	fake := newFakeBinance(t)
+	sm, mock := newMockStateManager(t)
+	sm.SetBotState(models.NewBotState(1000))
+	ts := NewTradingStrategy(fake.service(), sm, cfg, metrics.NewRegistry(), utils.NewLogger())
+	return ts, fake, mock
+}
+
+// newCycleConfig returns a ladder configuration placing 20 USDT limit buys 1% below market out of 1000 USDT.
+func newCycleConfig() *config.Config {
+	return &config.Config{
+		Symbol:               "BTCUSDT",
+		InitialUSDT:          1000,
+		OrderAmount:          20,
+		OrderIntervalMinutes: 10,
+		InitialBuyPercentage: 1,
+		SellProfitPercentage: 2,
+		InitialOrderType:     config.OrderTypeLimit,
+		AdditionalOrderType:  config.OrderTypeLimit,
+		InitialBuyTIF:        config.TimeInForceGTC,
+		AdditionalBuyTIF:     config.TimeInForceGTC,
+		Strategy:             config.StrategyLadder,
+		LogPriceDecimals:     2,
+	}
+}
+
+// expectQuietCycle sets up the database calls of a cycle with no open trades and no active orders:
+// reading open trades for sells and metrics, reading active orders, and saving the bot state. A buy
+// placed during the cycle is stored too.
+func expectQuietCycle(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows())
+	mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows())
+	mock.ExpectQuery("FROM orders").WillReturnRows(orderRows())
+	mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO bot_states").WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// newBuyOrder returns a resting buy order as stored locally, with its quote amount reserved.
+func newBuyOrder(binanceID int64, price, quantity float64) *models.Order {
+	now := time.Now()
+	return &models.Order{
+		BinanceID:     binanceID,
+		Symbol:        "BTCUSDT",
+		Type:          models.OrderTypeBuy,
+		Price:         price,
+		Quantity:      quantity,
+		QuoteQty:      price * quantity,
+		Status:        models.OrderStatusNew,
+		PlacedAt:      now,
+		LastUpdatedAt: now,
+	}
+}
+
+func TestCancelOpenOrders(t *testing.T) {
+	ts, fake, mock := newTestStrategy(t, &config.Config{CancelOrdersOnShutdown: true})
+	fake.fixture("DELETE /api/v3/openOrders", "open_orders_canceled.json", http.StatusOK)
+	fake.fixture("GET /api/v3/order", "order_canceled.json", http.StatusOK)
+
+	order := newBuyOrder(28, 29000.01, 0.00034)
+	botState := ts.stateManager.GetBotState()
+	botState.ReserveUSDT(order.QuoteQty)
+
+	mock.ExpectQuery("FROM orders").WillReturnRows(orderRows(order))
+	mock.ExpectExec("UPDATE orders").
+		WithArgs(models.OrderStatusCanceled, sqlmock.AnyArg(), sqlmock.AnyArg(), int64(28)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO bot_states").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	cancelled, err := ts.CancelOpenOrders(context.Background())
+	if err != nil {
+		t.Fatalf("CancelOpenOrders returned error: %v", err)
+	}
+	if cancelled != 1 {
+		t.Errorf("cancelled = %d, want 1", cancelled)
+	}
+	if calls := fake.calls("DELETE /api/v3/openOrders"); len(calls) != 1 || calls[0].Get("symbol") != "BTCUSDT" {
+		t.Errorf("cancel-all requests = %v, want one for BTCUSDT", calls)
+	}
+	if botState.ReservedUSDT != 0 {
+		t.Errorf("ReservedUSDT = %v after cancelling, want 0", botState.ReservedUSDT)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCancelOpenOrdersBinanceError(t *testing.T) {
+	ts, fake, mock := newTestStrategy(t, &config.Config{CancelOrdersOnShutdown: true})
+	fake.fixture("DELETE /api/v3/openOrders", "error_unknown_order.json", http.StatusBadRequest)
+
+	if _, err := ts.CancelOpenOrders(context.Background()); err == nil {
+		t.Fatal("CancelOpenOrders returned no error when Binance rejected the cancel")
+	}
+	// Local orders are left for the next start's reconciliation
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCancelOrderSettlesPartialBuy(t *testing.T) {
+	ts, fake, mock := newTestStrategy(t, &config.Config{Symbol: "BTCUSDT"})
+	fake.fixture("DELETE /api/v3/order", "order_canceled.json", http.StatusOK)
+	fake.respond("GET /api/v3/order", http.StatusOK, `{"symbol":"BTCUSDT","orderId":28,"price":"29000.01","origQty":"0.00034",`+
+		`"executedQty":"0.0001","cummulativeQuoteQty":"2.900001","status":"CANCELED","type":"LIMIT","side":"BUY"}`)
+
+	order := newBuyOrder(28, 29000.01, 0.00034)
+	botState := ts.stateManager.GetBotState()
+	botState.ReserveUSDT(order.QuoteQty)
+
+	mock.ExpectQuery("FROM orders").WithArgs(int64(28)).WillReturnRows(orderRows(order))
+	mock.ExpectExec("UPDATE orders").
+		WithArgs(models.OrderStatusCanceled, sqlmock.AnyArg(), sqlmock.AnyArg(), int64(28)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO bot_states").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	cancelled, err := ts.CancelOrder(context.Background(), 28)
+	if err != nil {
+		t.Fatalf("CancelOrder returned error: %v", err)
+	}
+	if cancelled.Status != models.OrderStatusCanceled {
+		t.Errorf("order status = %s, want CANCELED", cancelled.Status)
+	}
+	if botState.ReservedUSDT != 0 {
+		t.Errorf("ReservedUSDT = %v after cancelling, want 0", botState.ReservedUSDT)
+	}
+	if math.Abs(botState.OpenPositionQuantity-0.0001) > 1e-12 || math.Abs(botState.OpenPositionCostBasis-2.900001) > 1e-9 {
+		t.Errorf("position = %v BTC for %v USDT, want the 0.0001 BTC filled before the cancel for 2.900001",
+			botState.OpenPositionQuantity, botState.OpenPositionCostBasis)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCancelOrderUnknown(t *testing.T) {
+	ts, _, mock := newTestStrategy(t, &config.Config{Symbol: "BTCUSDT"})
+	mock.ExpectQuery("FROM orders").WithArgs(int64(28)).WillReturnError(sql.ErrNoRows)
+
+	if _, err := ts.CancelOrder(context.Background(), 28); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("CancelOrder error = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestCancelledSellIsPlacedAgain(t *testing.T) {
+	ts, fake, mock := newTestStrategy(t, newCycleConfig())
+	fake.respond("DELETE /api/v3/order", http.StatusOK, `{"symbol":"BTCUSDT","orderId":29,"status":"CANCELED"}`)
+	fake.respond("GET /api/v3/order", http.StatusOK, `{"symbol":"BTCUSDT","orderId":29,"price":"29580.01","origQty":"0.00034",`+
+		`"executedQty":"0","cummulativeQuoteQty":"0","status":"CANCELED","type":"LIMIT","side":"SELL"}`)
+	echoSellOrders(fake, 40)
+
+	trade, buyOrder := newFilledTrade(29000.01)
+	trade.SetSellOrder(29)
+	sellOrder := newBuyOrder(29, 29580.01, 0.00034)
+	sellOrder.Type = models.OrderTypeSell
+	anyArg := sqlmock.AnyArg()
+	mock.ExpectQuery("FROM orders").WithArgs(int64(29)).WillReturnRows(orderRows(sellOrder))
+	mock.ExpectExec("UPDATE orders").WithArgs(models.OrderStatusCanceled, anyArg, anyArg, int64(29)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows(trade))
+	mock.ExpectExec("UPDATE trades").
+		WithArgs(nil, anyArg, models.TradeStatusOpen, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO bot_states").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := ts.CancelOrder(context.Background(), 29); err != nil {
+		t.Fatalf("CancelOrder returned error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sell order not detached from trade 7: %v", err)
+	}
+
+	// The next cycle finds the trade without a sell order and places a new one
+	trade.SellOrderID = nil
+	mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows(trade))
+	mock.ExpectQuery("FROM orders").WithArgs(int64(28)).WillReturnRows(orderRows(buyOrder))
+	mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectExec("UPDATE trades").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := ts.checkAndPlaceSellOrders(context.Background(), 29100, true); err != nil {
+		t.Fatalf("checkAndPlaceSellOrders returned error: %v", err)
+	}
+	if calls := fake.calls("POST /api/v3/order"); len(calls) != 1 || calls[0].Get("side") != "SELL" {
+		t.Errorf("order requests = %v, want one new sell", calls)
+	}
+}
+
+func TestRunCyclePlacesInitialBuy(t *testing.T) {
+	ts, fake, mock := newTestStrategy(t, newCycleConfig())
+	expectQuietCycle(mock)
+
+	if _, err := ts.runCycle(context.Background()); err != nil {
+		t.Fatalf("runCycle returned error: %v", err)
+	}
+	if calls := fake.calls("POST /api/v3/order"); len(calls) != 1 {
+		t.Fatalf("got %d orders placed, want the first initial buy", len(calls))
+	}
+	if got := ts.stateManager.GetBotState().TotalOrdersPlaced; got != 1 {
+		t.Errorf("TotalOrdersPlaced = %d, want 1", got)
+	}
+}
+
+func TestOrderCapHaltsBuys(t *testing.T) {
+	cfg := newCycleConfig()
+	cfg.MaxTotalOrders = 5
+	ts, fake, mock := newTestStrategy(t, cfg)
+	expectQuietCycle(mock)
+	botState := ts.stateManager.GetBotState()
+	botState.MarkInitialized()
+	botState.TotalOrdersPlaced = 5
+
+	if _, err := ts.runCycle(context.Background()); err != nil {
+		t.Fatalf("runCycle returned error: %v", err)
+	}
+	if calls := fake.calls("POST /api/v3/order"); len(calls) != 0 {
+		t.Errorf("placed %d orders with MAX_TOTAL_ORDERS reached, want none", len(calls))
+	}
+	if botState.TotalOrdersPlaced != 5 {
+		t.Errorf("TotalOrdersPlaced = %d, want it to stay at the cap", botState.TotalOrdersPlaced)
+	}
+}
+
+func TestOrderCapStillPlacesSells(t *testing.T) {
+	cfg := newCycleConfig()
+	cfg.MaxTotalOrders = 1
+	ts, fake, mock := newTestStrategy(t, cfg)
+	fake.fixture("POST /api/v3/order", "order_sell_new.json", http.StatusOK)
+	botState := ts.stateManager.GetBotState()
+	botState.MarkInitialized()
+	botState.TotalOrdersPlaced = 1
+
+	buyOrder := newBuyOrder(28, 29000.01, 0.00034)
+	buyOrder.Status = models.OrderStatusFilled
+	trade := models.NewTrade(buyOrder.BinanceID, "BTCUSDT", buyOrder.Price, buyOrder.Quantity, 0)
+	trade.ID = 7
+	mock.ExpectQuery("FROM orders").WithArgs(int64(28)).WillReturnRows(orderRows(buyOrder))
+	mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows(trade))
+	mock.ExpectExec("UPDATE trades").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectQuietCycle(mock)
+
+	if _, err := ts.runCycle(context.Background()); err != nil {
+		t.Fatalf("runCycle returned error: %v", err)
+	}
+	calls := fake.calls("POST /api/v3/order")
+	if len(calls) != 1 || calls[0].Get("side") != "SELL" {
+		t.Fatalf("order requests = %v, want only the take-profit sell despite the cap", calls)
+	}
+}
+
+// newFilledTrade returns an open trade whose buy order 28 filled at price, with no sell order yet.
+func newFilledTrade(price float64) (*models.Trade, *models.Order) {
+	buyOrder := newBuyOrder(28, price, 0.00034)
+	buyOrder.Status = models.OrderStatusFilled
+	trade := models.NewTrade(buyOrder.BinanceID, "BTCUSDT", buyOrder.Price, buyOrder.Quantity, 0)
+	trade.ID = 7
+	return trade, buyOrder
+}
+
+func TestPausedCyclePlacesNoOrders(t *testing.T) {
+	ts, fake, mock := newTestStrategy(t, newCycleConfig())
+	botState := ts.stateManager.GetBotState()
+	botState.MarkInitialized()
+	botState.SetPaused(true)
+
+	trade, buyOrder := newFilledTrade(29000.01)
+	mock.ExpectQuery("FROM orders").WithArgs(int64(28)).WillReturnRows(orderRows(buyOrder))
+	mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows(trade))
+	expectQuietCycle(mock)
+
+	if _, err := ts.runCycle(context.Background()); err != nil {
+		t.Fatalf("runCycle returned error: %v", err)
+	}
+	if calls := fake.calls("POST /api/v3/order"); len(calls) != 0 {
+		t.Errorf("paused bot placed orders %v, want none", calls)
+	}
+}
+
+func TestPausedCycleRunsStopLoss(t *testing.T) {
+	cfg := newCycleConfig()
+	cfg.StopLossPercentage = 5
+	ts, fake, mock := newTestStrategy(t, cfg)
+	fake.respond("GET /api/v3/ticker/price", http.StatusOK, `{"symbol":"BTCUSDT","price":"27000.00000000"}`)
+	fake.fixture("POST /api/v3/order", "order_market_sell_filled.json", http.StatusOK)
+	botState := ts.stateManager.GetBotState()
+	botState.MarkInitialized()
+	botState.SetPaused(true)
+
+	trade, buyOrder := newFilledTrade(29000.01)
+	mock.ExpectQuery("FROM orders").WithArgs(int64(28)).WillReturnRows(orderRows(buyOrder))
+	mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows(trade))
+	mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("INSERT INTO events").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("UPDATE trades").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows())
+	mock.ExpectQuery("FROM orders").WillReturnRows(orderRows())
+	mock.ExpectExec("INSERT INTO bot_states").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := ts.runCycle(context.Background()); err != nil {
+		t.Fatalf("runCycle returned error: %v", err)
+	}
+	calls := fake.calls("POST /api/v3/order")
+	if len(calls) != 1 || calls[0].Get("side") != "SELL" || calls[0].Get("type") != "MARKET" {
+		t.Fatalf("order requests = %v, want the stop-loss market sell while paused", calls)
+	}
+	// The sell order is stored and the trade marked SOLD
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRoundTripFeePercentage(t *testing.T) {
+	ts, fake, _ := newTestStrategy(t, newCycleConfig())
+	fake.respond("GET /api/v3/account", http.StatusOK, `{"makerCommission":2,"takerCommission":4,"balances":[]}`)
+	if got := ts.roundTripFeePercentage(context.Background()); got != 0.08 {
+		t.Errorf("roundTripFeePercentage = %v, want twice the 0.04%% taker rate", got)
+	}
+
+	ts, fake, _ = newTestStrategy(t, newCycleConfig())
+	fake.respond("GET /api/v3/account", http.StatusInternalServerError, `{"code":-1000,"msg":"An unknown error occurred."}`)
+	if got, want := ts.roundTripFeePercentage(context.Background()), ts.config.RoundTripFeePercentage(); got != want {
+		t.Errorf("roundTripFeePercentage without rates = %v, want the standard %v", got, want)
+	}
+}
+
+func TestFmtPriceKeepsStoredPrecision(t *testing.T) {
+	cfg := newCycleConfig()
+	cfg.LogPriceDecimals = -1 // The symbol's tick size
+	ts, _, mock := newTestStrategy(t, cfg)
+	ts.binanceService.SetDefaultPrecision(4, 5)
+
+	const price = 99.00000003
+	if got := ts.fmtPrice(price); got != "99.0000" {
+		t.Errorf("fmtPrice before exchange info is cached = %q, want the default precision 99.0000", got)
+	}
+	// Exchange info is cached by any call needing it; the tick size is "0.01000000"
+	if _, err := ts.binanceService.GetSymbolLimits(context.Background(), "BTCUSDT"); err != nil {
+		t.Fatalf("GetSymbolLimits returned error: %v", err)
+	}
+	if got := ts.fmtPrice(price); got != "99.00" {
+		t.Errorf("fmtPrice = %q, want the 0.01 tick precision 99.00", got)
+	}
+
+	// The database gets the full-precision price, not the logged one
+	anyArg := sqlmock.AnyArg()
+	mock.ExpectQuery("INSERT INTO orders").WithArgs(int64(28), "BTCUSDT", models.OrderTypeBuy, price, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	if err := ts.stateManager.AddOrder(context.Background(), newBuyOrder(28, price, 0.00034)); err != nil {
+		t.Fatalf("AddOrder returned error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInitialBuyFillOrTimeTrigger(t *testing.T) {
+	tests := []struct {
+		name       string
+		onFill     bool
+		lastStatus string // Fixture answering the status check of the previous initial buy
+		lastAgo    time.Duration
+		wantPlaced bool
+	}{
+		{"filled before interval", true, "order_filled.json", time.Minute, true},
+		{"unfilled before interval", true, "order_new.json", time.Minute, false},
+		{"unfilled after interval", true, "order_new.json", 11 * time.Minute, true},
+		{"filled but fill trigger off", false, "order_filled.json", time.Minute, false},
+		{"interval elapsed, fill trigger off", false, "order_new.json", 11 * time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.InitialBuyOnFill = tt.onFill
+			ts, fake, mock := newTestStrategy(t, cfg)
+			fake.fixture("GET /api/v3/order", tt.lastStatus, http.StatusOK)
+			mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+			botState := ts.stateManager.GetBotState()
+			botState.IncrementInitialBuyOrdersCount()
+			lastPlacedAt := time.Now().Add(-tt.lastAgo)
+			botState.LastInitialBuyOrderPlacedAt = &lastPlacedAt
+			botState.SetLastInitialBuyOrderID(27)
+
+			if err := ts.placeInitialBuyOrders(context.Background(), 30000); err != nil {
+				t.Fatalf("placeInitialBuyOrders returned error: %v", err)
+			}
+			placed := len(fake.calls("POST /api/v3/order")) == 1
+			if placed != tt.wantPlaced {
+				t.Errorf("placed next initial buy = %t, want %t", placed, tt.wantPlaced)
+			}
+			if tt.wantPlaced && botState.InitialBuyOrdersPlacedCount != 2 {
+				t.Errorf("InitialBuyOrdersPlacedCount = %d, want 2", botState.InitialBuyOrdersPlacedCount)
+			}
+		})
+	}
+}
+
+func TestTWAPSlices(t *testing.T) {
+	cfg := newCycleConfig()
+	cfg.Strategy = config.StrategyTWAP
+	cfg.TWAPSlices = 4
+	cfg.TWAPIntervalMinutes = 30
+	ts, fake, mock := newTestStrategy(t, cfg)
+	fake.fixture("POST /api/v3/order", "order_market_buy_filled.json", http.StatusOK)
+	botState := ts.stateManager.GetBotState()
+	ctx := context.Background()
+
+	// rewind moves the last slice back in time, as if the clock had advanced by d
+	rewind := func(d time.Duration) {
+		if last := botState.LastInitialBuyOrderPlacedAt; last != nil {
+			earlier := last.Add(-d)
+			botState.LastInitialBuyOrderPlacedAt = &earlier
+		}
+	}
+
+	for slice := 1; slice <= cfg.TWAPSlices; slice++ {
+		mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(slice))
+		if err := ts.placeTWAPSlice(ctx); err != nil {
+			t.Fatalf("slice %d: placeTWAPSlice returned error: %v", slice, err)
+		}
+		if got := len(fake.calls("POST /api/v3/order")); got != slice {
+			t.Fatalf("after slice %d: %d orders placed, want %d", slice, got, slice)
+		}
+
+		// Before the interval has elapsed, no further slice is placed
+		rewind(29 * time.Minute)
+		if err := ts.placeTWAPSlice(ctx); err != nil {
+			t.Fatalf("placeTWAPSlice returned error: %v", err)
+		}
+		if got := len(fake.calls("POST /api/v3/order")); got != slice {
+			t.Fatalf("%d orders placed 29 minutes after slice %d, want %d", got, slice, slice)
+		}
+		rewind(time.Minute)
+	}
+
+	calls := fake.calls("POST /api/v3/order")
+	for _, call := range calls {
+		if call.Get("type") != "MARKET" || call.Get("side") != "BUY" || call.Get("quoteOrderQty") != "250" {
+			t.Errorf("slice order = %v, want a 250 USDT market buy", call)
+		}
+	}
+	if botState.TWAPSlicesPlacedCount != 4 || !botState.IsInitialBuyingComplete {
+		t.Errorf("slices placed = %d, complete = %t, want 4 and complete", botState.TWAPSlicesPlacedCount, botState.IsInitialBuyingComplete)
+	}
+
+	// Once all slices are placed, no more are
+	rewind(time.Hour)
+	if err := ts.placeTWAPSlice(ctx); err != nil {
+		t.Fatalf("placeTWAPSlice returned error: %v", err)
+	}
+	if got := len(fake.calls("POST /api/v3/order")); got != 4 {
+		t.Errorf("%d orders placed after the TWAP completed, want 4", got)
+	}
+}
+
+func TestReferencePriceSource(t *testing.T) {
+	tests := []struct {
+		source string
+		want   float64
+	}{
+		{config.PriceSourceLast, 30000},
+		{config.PriceSourceAvg, 29950.12345678},
+	}
+	for _, tt := range tests {
+		cfg := newCycleConfig()
+		cfg.PriceSource = tt.source
+		ts, fake, _ := newTestStrategy(t, cfg)
+		fake.fixture("GET /api/v3/avgPrice", "avg_price.json", http.StatusOK)
+
+		price, err := ts.getReferencePrice(context.Background())
+		if err != nil {
+			t.Fatalf("PRICE_SOURCE=%s: getReferencePrice returned error: %v", tt.source, err)
+		}
+		if price != tt.want {
+			t.Errorf("PRICE_SOURCE=%s: reference price = %v, want %v", tt.source, price, tt.want)
+		}
+	}
+}
+
+func TestStopLossDipRecovers(t *testing.T) {
+	cfg := newCycleConfig()
+	cfg.StopLossPercentage = 5 // Stop at 27550.01 for a buy at 29000.01
+	cfg.StopLossConfirmSeconds = 60
+	ctx := context.Background()
+
+	t.Run("recovers before the confirmation delay", func(t *testing.T) {
+		ts, fake, _ := newTestStrategy(t, cfg)
+		trade, buyOrder := newFilledTrade(29000.01)
+
+		for _, price := range []float64{27000, 28500} { // Dip below the stop, then back above it
+			closed, err := ts.checkStopLoss(ctx, trade, buyOrder, price)
+			if err != nil || closed {
+				t.Fatalf("checkStopLoss(%v) = %t, %v, want the stop left open", price, closed, err)
+			}
+		}
+		if _, pending := ts.stopLossTriggeredAt[trade.ID]; pending {
+			t.Error("stop-loss still pending after the price recovered")
+		}
+		if calls := fake.calls("POST /api/v3/order"); len(calls) != 0 {
+			t.Errorf("liquidated on a recovered dip: %v", calls)
+		}
+	})
+
+	t.Run("fresh price recovered when confirming", func(t *testing.T) {
+		ts, fake, _ := newTestStrategy(t, cfg) // The ticker answers 30000, above the entry
+		trade, buyOrder := newFilledTrade(29000.01)
+		ts.stopLossTriggeredAt[trade.ID] = time.Now().Add(-2 * time.Minute)
+
+		closed, err := ts.checkStopLoss(ctx, trade, buyOrder, 27000)
+		if err != nil || closed {
+			t.Fatalf("checkStopLoss = %t, %v, want the stop cancelled on the fresh price", closed, err)
+		}
+		if calls := fake.calls("POST /api/v3/order"); len(calls) != 0 {
+			t.Errorf("liquidated although the fresh price recovered: %v", calls)
+		}
+	})
+
+	t.Run("still below the stop after the delay", func(t *testing.T) {
+		ts, fake, mock := newTestStrategy(t, cfg)
+		fake.respond("GET /api/v3/ticker/price", http.StatusOK, `{"symbol":"BTCUSDT","price":"27000.00000000"}`)
+		fake.fixture("POST /api/v3/order", "order_market_sell_filled.json", http.StatusOK)
+		mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery("INSERT INTO events").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec("UPDATE trades").WillReturnResult(sqlmock.NewResult(0, 1))
+		trade, buyOrder := newFilledTrade(29000.01)
+
+		if closed, err := ts.checkStopLoss(ctx, trade, buyOrder, 27000); err != nil || closed {
+			t.Fatalf("checkStopLoss on the first dip = %t, %v, want it pending", closed, err)
+		}
+		ts.stopLossTriggeredAt[trade.ID] = time.Now().Add(-2 * time.Minute) // The delay has elapsed
+		closed, err := ts.checkStopLoss(ctx, trade, buyOrder, 27000)
+		if err != nil || !closed {
+			t.Fatalf("checkStopLoss after the delay = %t, %v, want the trade closed", closed, err)
+		}
+		if trade.Status != models.TradeStatusSold {
+			t.Errorf("trade status = %s, want SOLD", trade.Status)
+		}
+	})
+}
+
+func TestHandleDust(t *testing.T) {
+	// The fixture symbol needs 0.00001 BTC and 5 USDT per order; at 30000 that is 0.00016667 BTC
+	tests := []struct {
+		name    string
+		balance float64
+		convert bool
+		want    float64
+	}{
+		{"below min notional", 0.0001, false, 0},
+		{"below min qty", 0.000005, false, 0},
+		{"sellable", 0.001, false, 0.001},
+		{"converted", 0.0001, true, 0},
+		{"sellable not converted", 0.001, true, 0.001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.IgnoreDust, cfg.ConvertDust = true, tt.convert
+			ts, fake, _ := newTestStrategy(t, cfg)
+			ts.baseAsset = "BTC"
+			fake.respond("POST /sapi/v1/asset/dust", http.StatusOK, `{"totalServiceCharge":"0.00000002","totalTransfered":"0.0000009","transferResult":[]}`)
+			botState := ts.stateManager.GetBotState()
+			botState.UpdateBalances(1000, tt.balance)
+
+			ts.handleDust(context.Background(), 30000)
+
+			if botState.CurrentBTCBalance != tt.want {
+				t.Errorf("BTC balance = %v, want %v", botState.CurrentBTCBalance, tt.want)
+			}
+			if botState.CurrentUSDTBalance != 1000 {
+				t.Errorf("USDT balance = %v, want it untouched", botState.CurrentUSDTBalance)
+			}
+			wantConversions := 0
+			if tt.convert && tt.want == 0 {
+				wantConversions = 1
+			}
+			if got := len(fake.calls("POST /sapi/v1/asset/dust")); got != wantConversions {
+				t.Errorf("dust conversions = %d, want %d", got, wantConversions)
+			}
+		})
+	}
+}
+
+func TestUpdateConfigAppliesToNextCycle(t *testing.T) {
+	ts, fake, mock := newTestStrategy(t, newCycleConfig())
+	expectQuietCycle(mock)
+
+	reloaded := newCycleConfig()
+	reloaded.InitialBuyPercentage = 2
+	if err := ts.UpdateConfig(reloaded); err != nil {
+		t.Fatalf("UpdateConfig rejected a non-structural change: %v", err)
+	}
+	if ts.Config() != reloaded {
+		t.Fatal("Config() does not return the reloaded configuration")
+	}
+
+	if err := ts.ExecuteTradingCycle(context.Background()); err != nil {
+		t.Fatalf("ExecuteTradingCycle returned error: %v", err)
+	}
+	calls := fake.calls("POST /api/v3/order")
+	if len(calls) != 1 {
+		t.Fatalf("got %d orders placed, want the first initial buy", len(calls))
+	}
+	// 2% below the 30000 ticker instead of the original 1%
+	if got := calls[0].Get("price"); got != "29400" {
+		t.Errorf("buy price = %s, want 29400 from the reloaded INITIAL_BUY_PERCENTAGE", got)
+	}
+}
+
+func TestUpdateConfigRejectsStructuralChange(t *testing.T) {
+	cfg := newCycleConfig()
+	ts, _, _ := newTestStrategy(t, cfg)
+
+	reloaded := newCycleConfig()
+	reloaded.Symbol = "ETHUSDT"
+	reloaded.InitialBuyPercentage = 2
+	if err := ts.UpdateConfig(reloaded); err == nil {
+		t.Fatal("UpdateConfig accepted a SYMBOL change")
+	}
+	if ts.Config() != cfg {
+		t.Error("a rejected reload replaced the configuration")
+	}
+}
+
+func TestFirstCycleInitializesState(t *testing.T) {
+	// Both states are paused so the cycle places no buy that would change the counters
+	// The row the migration seeds has no investment yet and is not flagged initialized
+	seeded := models.NewBotState(0)
+	seeded.ID = 1
+	seeded.Paused = true
+
+	// A configured state whose investment differs from INITIAL_USDT, as after a manual top-up
+	configured := models.NewBotState(500)
+	configured.ID = 1
+	configured.Paused = true
+	configured.MarkInitialized()
+	configured.InitialBuyOrdersPlacedCount = 3
+	configured.TotalOrdersPlaced = 3
+
+	tests := []struct {
+		name           string
+		stored         *models.BotState
+		wantInvestment float64
+		wantPlaced     int
+	}{
+		{"fresh", seeded, 1000, 0},
+		{"already initialized", configured, 500, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, _, mock := newTestStrategy(t, newCycleConfig())
+			mock.ExpectQuery("FROM bot_states").WillReturnRows(botStateRows(tt.stored))
+			expectQuietCycle(mock)
+			if err := ts.stateManager.LoadBotState(context.Background()); err != nil {
+				t.Fatalf("LoadBotState returned error: %v", err)
+			}
+
+			if _, err := ts.runCycle(context.Background()); err != nil {
+				t.Fatalf("runCycle returned error: %v", err)
+			}
+			botState := ts.stateManager.GetBotState()
+			if !botState.Initialized || botState.ID != 1 {
+				t.Errorf("Initialized = %t, ID = %d, want an initialized state keeping row 1", botState.Initialized, botState.ID)
+			}
+			if botState.InitialUSDTInvestment != tt.wantInvestment {
+				t.Errorf("InitialUSDTInvestment = %v, want %v", botState.InitialUSDTInvestment, tt.wantInvestment)
+			}
+			if botState.InitialBuyOrdersPlacedCount != tt.wantPlaced {
+				t.Errorf("InitialBuyOrdersPlacedCount = %d, want %d", botState.InitialBuyOrdersPlacedCount, tt.wantPlaced)
+			}
+			if !botState.Paused {
+				t.Error("the stored pause was dropped")
+			}
+		})
+	}
+}
+
+func TestOverlappingCycleSkipped(t *testing.T) {
+	ts, fake, mock := newTestStrategy(t, newCycleConfig())
+	expectQuietCycle(mock)
+
+	// Hold the first cycle inside its balance refresh until the second one has been attempted
+	entered, release := make(chan struct{}), make(chan struct{})
+	account, err := os.ReadFile(filepath.Join("testdata", "account.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	var once sync.Once
+	fake.handle("GET /api/v3/account", func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			close(entered)
+			<-release
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(account)
+	})
+
+	firstDone := make(chan error, 1)
+	go func() { firstDone <- ts.ExecuteTradingCycle(context.Background()) }()
+	<-entered
+
+	if err := ts.ExecuteTradingCycle(context.Background()); !errors.Is(err, ErrCycleInProgress) {
+		t.Errorf("overlapping cycle returned %v, want ErrCycleInProgress", err)
+	}
+	close(release)
+	if err := <-firstDone; err != nil {
+		t.Fatalf("first cycle returned error: %v", err)
+	}
+	if calls := fake.calls("POST /api/v3/order"); len(calls) != 1 {
+		t.Errorf("got %d orders placed, want only the first cycle's buy", len(calls))
+	}
+}
+
+func TestValidateOrderSizes(t *testing.T) {
+	// The fixture symbol's minimum notional is 5 USDT
+	tests := []struct {
+		name    string
+		modify  func(cfg *config.Config)
+		wantErr string
+	}{
+		{"valid", func(cfg *config.Config) {}, ""},
+		{"order below min notional", func(cfg *config.Config) { cfg.OrderAmount = 4 }, "ORDER_AMOUNT"},
+		{"percentage below min notional", func(cfg *config.Config) { cfg.OrderAmountPercent = 0.4 }, "ORDER_AMOUNT_PERCENT"},
+		{"TWAP slice below min notional", func(cfg *config.Config) {
+			cfg.Strategy = config.StrategyTWAP
+			cfg.TWAPSlices = 250
+		}, "TWAP_SLICES"},
+		{"capital below one order", func(cfg *config.Config) { cfg.InitialUSDT = 10 }, "INITIAL_USDT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			tt.modify(cfg)
+			ts, _, _ := newTestStrategy(t, cfg)
+
+			err := ts.ValidateOrderSizes(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateOrderSizes returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateOrderSizes error = %v, want one naming %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithdrawProfit(t *testing.T) {
+	tests := []struct {
+		name          string
+		profit        float64
+		withdrawn     float64
+		status        int
+		wantTransfer  string
+		wantWithdrawn float64
+	}{
+		{"below threshold", 9, 0, http.StatusOK, "", 0},
+		{"at threshold", 10, 0, http.StatusOK, "", 0},
+		{"above threshold", 12.5, 0, http.StatusOK, "12.5", 12.5},
+		{"only the unwithdrawn part counts", 15, 12.5, http.StatusOK, "", 12.5},
+		{"transfer rejected", 12.5, 0, http.StatusBadRequest, "12.5", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.AutoWithdrawProfitAbove = 10
+			ts, fake, _ := newTestStrategy(t, cfg)
+			ts.quoteAsset = "USDT"
+			body := `{"tranId":13526853623}`
+			if tt.status != http.StatusOK {
+				body = `{"code":-5002,"msg":"You have insufficient balance."}`
+			}
+			fake.respond("POST /sapi/v1/asset/transfer", tt.status, body)
+			botState := ts.stateManager.GetBotState()
+			botState.UpdateInvestedAndProfit(0, tt.profit)
+			botState.TotalUSDTWithdrawn = tt.withdrawn
+
+			ts.withdrawProfit(context.Background())
+
+			calls := fake.calls("POST /sapi/v1/asset/transfer")
+			if tt.wantTransfer == "" {
+				if len(calls) != 0 {
+					t.Errorf("transferred %v, want no transfer", calls)
+				}
+			} else if len(calls) != 1 || calls[0].Get("amount") != tt.wantTransfer || calls[0].Get("type") != "MAIN_FUNDING" {
+				t.Errorf("transfer requests = %v, want one MAIN_FUNDING transfer of %s", calls, tt.wantTransfer)
+			}
+			if botState.TotalUSDTWithdrawn != tt.wantWithdrawn {
+				t.Errorf("TotalUSDTWithdrawn = %v, want %v", botState.TotalUSDTWithdrawn, tt.wantWithdrawn)
+			}
+			if want := 1000 - (tt.wantWithdrawn - tt.withdrawn); botState.CurrentUSDTBalance != want {
+				t.Errorf("USDT balance = %v, want %v", botState.CurrentUSDTBalance, want)
+			}
+		})
+	}
+}
+
+func TestCancelledBuyFreesCapital(t *testing.T) {
+	ts, fake, mock := newTestStrategy(t, newCycleConfig())
+	fake.fixture("GET /api/v3/order", "order_canceled.json", http.StatusOK)
+	botState := ts.stateManager.GetBotState()
+	botState.MarkInitialized()
+
+	// A resting buy holds all but 10 of the 1050 USDT the account reports, too little for a 20 USDT order
+	resting := newBuyOrder(28, 29000.01, 0.03586)
+	botState.ReserveUSDT(resting.QuoteQty)
+
+	// First cycle: no buy fits, then order 28 is found cancelled on Binance
+	mock.ExpectQuery("FROM orders").WillReturnRows(orderRows(resting))
+	mock.ExpectExec("UPDATE orders").WithArgs(models.OrderStatusCanceled, sqlmock.AnyArg(), sqlmock.AnyArg(), int64(28)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows())
+	mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows())
+	mock.ExpectExec("INSERT INTO bot_states").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := ts.runCycle(context.Background()); err != nil {
+		t.Fatalf("first cycle returned error: %v", err)
+	}
+	if calls := fake.calls("POST /api/v3/order"); len(calls) != 0 {
+		t.Fatalf("placed %d orders while the capital was reserved, want none", len(calls))
+	}
+	if botState.ReservedUSDT != 0 {
+		t.Errorf("ReservedUSDT = %v after the cancellation, want 0", botState.ReservedUSDT)
+	}
+
+	// Second cycle: the freed capital funds a new buy
+	expectQuietCycle(mock)
+	if _, err := ts.runCycle(context.Background()); err != nil {
+		t.Fatalf("second cycle returned error: %v", err)
+	}
+	if calls := fake.calls("POST /api/v3/order"); len(calls) != 1 || calls[0].Get("side") != "BUY" {
+		t.Errorf("order requests = %v, want one buy funded by the released capital", calls)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOrderTypePerPhase(t *testing.T) {
+	tests := []struct {
+		name                string
+		initial, additional string
+		phase               string
+		wantType            string
+	}{
+		{"initial limit", config.OrderTypeLimit, config.OrderTypeMarket, "initial", "LIMIT"},
+		{"initial market", config.OrderTypeMarket, config.OrderTypeLimit, "initial", "MARKET"},
+		{"additional limit", config.OrderTypeMarket, config.OrderTypeLimit, "additional", "LIMIT"},
+		{"additional market", config.OrderTypeLimit, config.OrderTypeMarket, "additional", "MARKET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.InitialOrderType, cfg.AdditionalOrderType = tt.initial, tt.additional
+			cfg.BuyPercentages = []float64{1}
+			cfg.MaxOpenTrades = 5
+			ts, fake, mock := newTestStrategy(t, cfg)
+			if tt.wantType == "MARKET" {
+				fake.fixture("POST /api/v3/order", "order_market_buy_filled.json", http.StatusOK)
+			}
+			botState := ts.stateManager.GetBotState()
+			botState.MarkInitialized()
+
+			var err error
+			if tt.phase == "initial" {
+				err = ts.placeInitialBuyOrders(context.Background(), 30000)
+			} else {
+				botState.SetInitialBuyingComplete()
+				mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows())
+				err = ts.placeAdditionalBuyOrders(context.Background(), 30000)
+			}
+			if err != nil {
+				t.Fatalf("placing the %s buy returned error: %v", tt.phase, err)
+			}
+
+			calls := fake.calls("POST /api/v3/order")
+			if len(calls) != 1 {
+				t.Fatalf("got %d orders placed, want 1", len(calls))
+			}
+			if got := calls[0].Get("type"); got != tt.wantType {
+				t.Errorf("%s buy type = %s, want %s", tt.phase, got, tt.wantType)
+			}
+			if tt.wantType == "MARKET" && calls[0].Get("quoteOrderQty") != "20" {
+				t.Errorf("market buy quoteOrderQty = %s, want the 20 USDT order amount", calls[0].Get("quoteOrderQty"))
+			}
+		})
+	}
+}
+
+func TestComputeUnrealizedPnL(t *testing.T) {
+	ts, _, mock := newTestStrategy(t, newCycleConfig())
+	gain := models.NewTrade(101, "BTCUSDT", 29000, 0.002, 0)  // +2
+	loss := models.NewTrade(102, "BTCUSDT", 31000, 0.001, 0)  // -1
+	flat := models.NewTrade(103, "BTCUSDT", 30000, 0.0005, 0) // 0
+	mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows(gain, loss, flat))
+
+	pnl, count, err := ts.ComputeUnrealizedPnL(context.Background(), 30000)
+	if err != nil {
+		t.Fatalf("ComputeUnrealizedPnL returned error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("count = %d, want 3", count)
+	}
+	if math.Abs(pnl-1) > 1e-9 {
+		t.Errorf("unrealized P&L = %v, want 1", pnl)
+	}
+}
+
+func TestCycleExportsUnrealizedPnL(t *testing.T) {
+	ts, _, mock := newTestStrategy(t, newCycleConfig())
+	ts.stateManager.GetBotState().Paused = true
+	trade := models.NewTrade(101, "BTCUSDT", 29000, 0.002, 0)
+	trade.SellOrderID = new(int64)
+	*trade.SellOrderID = 29
+	mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows(trade))
+	mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows(trade))
+	mock.ExpectQuery("FROM orders").WillReturnRows(orderRows())
+	mock.ExpectExec("INSERT INTO bot_states").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := ts.runCycle(context.Background()); err != nil {
+		t.Fatalf("runCycle returned error: %v", err)
+	}
+	var out strings.Builder
+	if err := ts.metrics.WriteText(&out); err != nil {
+		t.Fatalf("WriteText returned error: %v", err)
+	}
+	// 0.002 BTC bought 1000 USDT below the 30000 ticker
+	if !strings.Contains(out.String(), `unrealized_pnl_usdt{symbol="BTCUSDT"} 2`+"\n") {
+		t.Errorf("metrics do not report an unrealized P&L of 2:\n%s", out.String())
+	}
+}
+
+func TestMaxSpreadGuard(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		book       string
+		wantOrders int
+	}{
+		{"narrow spread", http.StatusOK, `{"symbol":"BTCUSDT","bidPrice":"29999.99","bidQty":"1","askPrice":"30000.01","askQty":"1"}`, 1},
+		{"wide spread", http.StatusOK, `{"symbol":"BTCUSDT","bidPrice":"29000.00","bidQty":"1","askPrice":"31000.00","askQty":"1"}`, 0},
+		{"book ticker unavailable", http.StatusInternalServerError, `{"code":-1000,"msg":"An unknown error occurred."}`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.MaxSpreadPercentage = 0.5
+			ts, fake, mock := newTestStrategy(t, cfg)
+			fake.respond("GET /api/v3/ticker/bookTicker", tt.status, tt.book)
+			expectQuietCycle(mock)
+
+			if _, err := ts.runCycle(context.Background()); err != nil {
+				t.Fatalf("runCycle returned error: %v", err)
+			}
+			if calls := fake.calls("POST /api/v3/order"); len(calls) != tt.wantOrders {
+				t.Errorf("placed %d orders, want %d", len(calls), tt.wantOrders)
+			}
+		})
+	}
+}
+
+func TestInitialBuyPacingAfterRestart(t *testing.T) {
+	tests := []struct {
+		name       string
+		lastPlaced time.Duration // Age of the persisted LastInitialBuyOrderPlacedAt
+		wantOrders int
+	}{
+		{"down longer than the interval", 3 * time.Hour, 1},
+		{"just past the interval", 11 * time.Minute, 1},
+		{"within the interval", 2 * time.Minute, 0},
+		{"timestamp in the future", -time.Hour, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, fake, mock := newTestStrategy(t, newCycleConfig())
+			expectQuietCycle(mock)
+			botState := ts.stateManager.GetBotState()
+			botState.MarkInitialized()
+			botState.InitialBuyOrdersPlacedCount = 2
+			lastPlaced := time.Now().Add(-tt.lastPlaced)
+			botState.LastInitialBuyOrderPlacedAt = &lastPlaced
+
+			if _, err := ts.runCycle(context.Background()); err != nil {
+				t.Fatalf("runCycle returned error: %v", err)
+			}
+			if calls := fake.calls("POST /api/v3/order"); len(calls) != tt.wantOrders {
+				t.Errorf("placed %d orders, want %d", len(calls), tt.wantOrders)
+			}
+		})
+	}
+}
+
+func TestStalePriceFallsBackToBookMid(t *testing.T) {
+	tests := []struct {
+		name       string
+		age        time.Duration
+		bookStatus int
+		want       float64
+		wantErr    bool
+	}{
+		{"fresh last trade", 5 * time.Second, http.StatusOK, 30100, false},
+		{"stale last trade", 10 * time.Minute, http.StatusOK, 30000, false},
+		{"stale and no book ticker", 10 * time.Minute, http.StatusInternalServerError, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.MaxPriceAgeSeconds = 60
+			ts, fake, _ := newTestStrategy(t, cfg)
+			fake.respond("GET /api/v1/trades", http.StatusOK, fmt.Sprintf(
+				`[{"id":1,"price":"30100.00","qty":"0.01","quoteQty":"301","time":%d,"isBuyerMaker":false,"isBestMatch":true}]`,
+				time.Now().Add(-tt.age).UnixMilli()))
+			fake.respond("GET /api/v3/ticker/bookTicker", tt.bookStatus,
+				`{"symbol":"BTCUSDT","bidPrice":"29999.00","bidQty":"1","askPrice":"30001.00","askQty":"1"}`)
+
+			price, err := ts.getReferencePrice(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getReferencePrice error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if price != tt.want {
+				t.Errorf("price = %v, want %v", price, tt.want)
+			}
+			wantBookCalls := 0
+			if tt.age > time.Minute {
+				wantBookCalls = 1
+			}
+			if got := len(fake.calls("GET /api/v3/ticker/bookTicker")); got != wantBookCalls {
+				t.Errorf("book ticker fetched %d times, want %d", got, wantBookCalls)
+			}
+		})
+	}
+}
+
+func TestHandleSellPlacementFailure(t *testing.T) {
+	insufficient := fmt.Errorf("failed to place order: %w",
+		&common.APIError{Code: -2010, Message: "Account has insufficient balance for requested action."})
+	tests := []struct {
+		name       string
+		err        error
+		failures   int // Calls to handleSellPlacementFailure
+		wantStatus models.TradeStatus
+		wantReason string
+	}{
+		{"insufficient balance", insufficient, 1, models.TradeStatusError, "insufficient balance"},
+		{"transient failure retried", errors.New("connection reset"), maxSellPlacementAttempts - 1, models.TradeStatusOpen, ""},
+		{"persistent failure", errors.New("connection reset"), maxSellPlacementAttempts, models.TradeStatusError, "after 3 attempts"},
+		{"outside the price band", &PriceOutsideBandError{Side: models.OrderTypeSell}, maxSellPlacementAttempts + 1, models.TradeStatusOpen, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, _, mock := newTestStrategy(t, newCycleConfig())
+			trade, _ := newFilledTrade(29000.01)
+			if tt.wantStatus == models.TradeStatusError {
+				mock.ExpectExec("UPDATE trades").WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			for i := 0; i < tt.failures; i++ {
+				ts.handleSellPlacementFailure(context.Background(), trade, tt.err)
+			}
+
+			if trade.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", trade.Status, tt.wantStatus)
+			}
+			if tt.wantStatus != models.TradeStatusError {
+				if trade.ErrorReason != nil || trade.ClosedAt != nil {
+					t.Errorf("open trade has error reason %v / closed at %v", deref(trade.ErrorReason), deref(trade.ClosedAt))
+				}
+				return
+			}
+			if trade.ErrorReason == nil || !strings.Contains(*trade.ErrorReason, tt.wantReason) {
+				t.Errorf("error reason = %v, want one mentioning %q", deref(trade.ErrorReason), tt.wantReason)
+			}
+			if trade.ClosedAt == nil {
+				t.Error("trade marked ERROR without a closing time")
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// echoSellOrders makes the fake Binance accept every order as a NEW sell at the requested price and
+// quantity, numbering the orders from firstID.
+func echoSellOrders(fake *fakeBinance, firstID int64) {
+	var mu sync.Mutex
+	nextID := firstID
+	fake.handle("POST /api/v3/order", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		id := nextID
+		nextID++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"symbol":"BTCUSDT","orderId":%d,"transactTime":%d,"price":"%s","origQty":"%s","executedQty":"0",`+
+			`"cummulativeQuoteQty":"0","status":"NEW","timeInForce":"GTC","type":"LIMIT","side":"SELL"}`,
+			id, time.Now().UnixMilli(), r.Form.Get("price"), r.Form.Get("quantity"))
+	})
+}
+
+func TestRepriceSellOrder(t *testing.T) {
+	cfg := newCycleConfig()
+	cfg.SellRepriceMinProfit = 0.5
+	repriceAfter := time.Hour
+	// Buy at 29000.01 with the fixture's 0.1% taker fee: floor = 29000.01 * (1 + (0.5 + 0.2) / 100)
+	floor := 29000.01 * 1.007
+
+	t.Run("not due yet", func(t *testing.T) {
+		ts, fake, _ := newTestStrategy(t, cfg)
+		trade, buyOrder := newFilledTrade(29000.01)
+		sellOrder := newBuyOrder(29, 29580.01, 0.00034)
+		sellOrder.Type = models.OrderTypeSell
+		sellOrder.PlacedAt = time.Now().Add(-30 * time.Minute)
+
+		if err := ts.repriceSellOrder(context.Background(), trade, buyOrder, sellOrder, repriceAfter); err != nil {
+			t.Fatalf("repriceSellOrder returned error: %v", err)
+		}
+		if calls := fake.calls("DELETE /api/v3/order"); len(calls) != 0 {
+			t.Errorf("cancelled a sell that rested only 30 minutes")
+		}
+	})
+
+	t.Run("reprices halfway to the floor", func(t *testing.T) {
+		ts, fake, mock := newTestStrategy(t, cfg)
+		echoSellOrders(fake, 40)
+		trade, buyOrder := newFilledTrade(29000.01)
+		sellOrder := newBuyOrder(29, 29580.01, 0.00034)
+		sellOrder.Type = models.OrderTypeSell
+		sellOrder.PlacedAt = time.Now().Add(-2 * time.Hour)
+		mock.ExpectExec("UPDATE orders").WithArgs(models.OrderStatusCanceled, sqlmock.AnyArg(), sqlmock.AnyArg(), int64(29)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectExec("UPDATE trades").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := ts.repriceSellOrder(context.Background(), trade, buyOrder, sellOrder, repriceAfter); err != nil {
+			t.Fatalf("repriceSellOrder returned error: %v", err)
+		}
+		if calls := fake.calls("DELETE /api/v3/order"); len(calls) != 1 {
+			t.Errorf("got %d cancel requests, want the resting sell cancelled", len(calls))
+		}
+		want := math.Round((29580.01+floor)/2*100) / 100
+		if trade.SellPriceTarget != want || trade.RepriceCount != 1 || deref(trade.SellOrderID) != int64(40) {
+			t.Errorf("trade target/reprices/sell order = %v/%d/%v, want %v/1/40",
+				trade.SellPriceTarget, trade.RepriceCount, deref(trade.SellOrderID), want)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("never below the floor", func(t *testing.T) {
+		ts, fake, _ := newTestStrategy(t, cfg) // Database writes fail and are only logged
+		echoSellOrders(fake, 40)
+		trade, buyOrder := newFilledTrade(29000.01)
+		price := 29580.01
+		for i := 0; i < 30; i++ {
+			sellOrder := newBuyOrder(int64(39+i), price, 0.00034)
+			sellOrder.Type = models.OrderTypeSell
+			sellOrder.PlacedAt = time.Now().Add(-2 * time.Hour)
+			if err := ts.repriceSellOrder(context.Background(), trade, buyOrder, sellOrder, repriceAfter); err != nil {
+				t.Fatalf("reprice %d returned error: %v", i+1, err)
+			}
+			if trade.SellPriceTarget == price {
+				break // At the floor: left alone
+			}
+			if trade.SellPriceTarget < floor || trade.SellPriceTarget > price {
+				t.Fatalf("reprice %d moved %v -> %v, want a lower price not below the floor %v", i+1, price, trade.SellPriceTarget, floor)
+			}
+			price = trade.SellPriceTarget
+		}
+		if price-floor > price*0.0001 {
+			t.Errorf("repricing stopped at %v, want it to converge on the floor %v", price, floor)
+		}
+		if got := len(fake.calls("POST /api/v3/order")); got != trade.RepriceCount || got >= 30 {
+			t.Errorf("placed %d sells for %d reprices, want one each and a finite number", got, trade.RepriceCount)
+		}
+	})
+}
+
+func TestFilledBuyAddsToCostBasis(t *testing.T) {
+	ts, _, mock := newTestStrategy(t, newCycleConfig())
+	mock.ExpectExec("UPDATE orders").WillReturnResult(sqlmock.NewResult(0, 1))
+	botState := ts.stateManager.GetBotState()
+	buyOrder := newBuyOrder(28, 29000, 0.001)
+	botState.ReserveUSDT(buyOrder.QuoteQty)
+
+	ts.applyOrderUpdate(context.Background(), buyOrder, OrderUpdate{
+		BinanceID:     28,
+		Symbol:        "BTCUSDT",
+		Status:        models.OrderStatusFilled,
+		ExecutedQty:   0.001,
+		ExecutedQuote: 28.95, // Filled a little below the limit
+	})
+
+	if botState.OpenPositionQuantity != 0.001 || botState.OpenPositionCostBasis != 28.95 {
+		t.Errorf("position = %v for %v, want 0.001 for the 28.95 actually paid",
+			botState.OpenPositionQuantity, botState.OpenPositionCostBasis)
+	}
+	if botState.ReservedUSDT != 0 {
+		t.Errorf("ReservedUSDT = %v, want the reservation released", botState.ReservedUSDT)
+	}
+}
+
+func TestOpenOrderCapSkipsPlacement(t *testing.T) {
+	exchangeInfo, err := os.ReadFile(filepath.Join("testdata", "exchange_info.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	openOrders := func(count int) string {
+		orders := make([]string, count)
+		for i := range orders {
+			orders[i] = fmt.Sprintf(`{"symbol":"BTCUSDT","orderId":%d,"status":"NEW","side":"BUY"}`, 100+i)
+		}
+		return "[" + strings.Join(orders, ",") + "]"
+	}
+	tests := []struct {
+		name       string
+		open       int
+		wantOrders int
+	}{
+		{"below the cap", 2, 1},
+		{"at the cap", 3, 0},
+		{"above the cap", 4, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, fake, _ := newTestStrategy(t, newCycleConfig())
+			fake.respond("GET /api/v3/exchangeInfo", http.StatusOK,
+				strings.Replace(string(exchangeInfo), `"maxNumOrders": 200`, `"maxNumOrders": 3`, 1))
+			fake.respond("GET /api/v3/openOrders", http.StatusOK, openOrders(tt.open))
+
+			limits, err := ts.binanceService.GetSymbolLimits(context.Background(), "BTCUSDT")
+			if err != nil || limits.MaxOrders != 3 {
+				t.Fatalf("MaxOrders = %v (%v), want 3 from MAX_NUM_ORDERS", limits, err)
+			}
+			_, err = ts.placeBuyOrder(context.Background(), config.OrderTypeLimit, config.TimeInForceGTC, 29700, 2)
+			if tt.wantOrders == 0 && !errors.Is(err, ErrOpenOrderLimit) {
+				t.Errorf("placeBuyOrder error = %v, want ErrOpenOrderLimit", err)
+			}
+			if calls := fake.calls("POST /api/v3/order"); len(calls) != tt.wantOrders {
+				t.Errorf("placed %d orders with %d open, want %d", len(calls), tt.open, tt.wantOrders)
+			}
+		})
+	}
+}
+
+func TestFundsDepletedTransitions(t *testing.T) {
+	cfg := newCycleConfig()
+	cfg.MinQuoteToResume = 50
+	ts, _, _ := newTestStrategy(t, cfg)
+	botState := &models.BotState{CurrentUSDTBalance: 100}
+
+	steps := []struct {
+		name         string
+		balance      float64
+		wantBuying   bool
+		wantDepleted bool
+	}{
+		{"enough funds", 100, true, false},
+		{"below one order", 15, false, true},
+		{"still depleted", 10, false, true},
+		{"above one order but below MIN_QUOTE_TO_RESUME", 40, false, true},
+		{"replenished", 50, true, false},
+		{"stays active above one order", 25, true, false},
+	}
+	for _, step := range steps {
+		botState.CurrentUSDTBalance = step.balance
+		if got := ts.updateFundsMode(botState); got != step.wantBuying {
+			t.Errorf("%s: buying allowed = %v, want %v", step.name, got, step.wantBuying)
+		}
+		if botState.FundsDepleted != step.wantDepleted {
+			t.Errorf("%s: FundsDepleted = %v, want %v", step.name, botState.FundsDepleted, step.wantDepleted)
+		}
+	}
+}
+
+func TestFundsDepletedResumesAtOrderSize(t *testing.T) {
+	ts, _, _ := newTestStrategy(t, newCycleConfig())
+	botState := &models.BotState{CurrentUSDTBalance: 19, FundsDepleted: true}
+
+	if ts.updateFundsMode(botState) || !botState.FundsDepleted {
+		t.Fatal("expected buying to stay paused below one order's size")
+	}
+	botState.CurrentUSDTBalance = 20
+	if !ts.updateFundsMode(botState) || botState.FundsDepleted {
+		t.Error("expected buying to resume at one order's size when MIN_QUOTE_TO_RESUME is unset")
+	}
+}
+
+func TestOrderStatusSourceModes(t *testing.T) {
+	tests := []struct {
+		source       string
+		wantRESTPoll bool
+	}{
+		{config.OrderStatusSourceREST, true},
+		{config.OrderStatusSourceWebSocket, false},
+		{config.OrderStatusSourceBoth, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.OrderStatusSource = tt.source
+			ts, fake, mock := newTestStrategy(t, cfg)
+			botState := ts.stateManager.GetBotState()
+			botState.MarkInitialized()
+			botState.SetPaused(true)
+
+			// Buy 28 rests locally but has left Binance's open list: REST polling settles it as filled
+			resting := newBuyOrder(28, 29000.01, 0.00034)
+			botState.ReserveUSDT(resting.QuoteQty)
+			mock.ExpectQuery("FROM orders").WillReturnRows(orderRows(resting))
+			mock.ExpectExec("UPDATE orders").WillReturnResult(sqlmock.NewResult(0, 1))
+			expectQuietCycle(mock)
+
+			if _, err := ts.runCycle(context.Background()); err != nil {
+				t.Fatalf("runCycle returned error: %v", err)
+			}
+			polled := len(fake.calls("GET /api/v3/openOrders")) > 0
+			if polled != tt.wantRESTPoll {
+				t.Errorf("REST open orders polled = %v, want %v", polled, tt.wantRESTPoll)
+			}
+			if settled := botState.ReservedUSDT == 0; settled != tt.wantRESTPoll {
+				t.Errorf("ReservedUSDT = %v, want the reservation released only by REST polling", botState.ReservedUSDT)
+			}
+		})
+	}
+}
+
+func TestHandleOrderUpdate(t *testing.T) {
+	ts, _, mock := newTestStrategy(t, newCycleConfig())
+	botState := ts.stateManager.GetBotState()
+	resting := newBuyOrder(28, 29000, 0.0005)
+	botState.ReserveUSDT(resting.QuoteQty)
+
+	// An update for another symbol is ignored without touching the database
+	ts.HandleOrderUpdate(context.Background(), OrderUpdate{BinanceID: 28, Symbol: "ETHUSDT", Status: models.OrderStatusFilled, FromUserStream: true})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("update for another symbol reached the database: %v", err)
+	}
+
+	// A buy cancelled after a partial fill releases its reservation and keeps what was bought
+	mock.ExpectQuery("FROM orders").WithArgs(int64(28)).WillReturnRows(orderRows(resting))
+	mock.ExpectExec("UPDATE orders").WithArgs(models.OrderStatusCanceled, sqlmock.AnyArg(), sqlmock.AnyArg(), int64(28)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO bot_states").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ts.HandleOrderUpdate(context.Background(), OrderUpdate{
+		BinanceID:      28,
+		Symbol:         "BTCUSDT",
+		Status:         models.OrderStatusCanceled,
+		ExecutedQty:    0.0002,
+		ExecutedQuote:  5.8,
+		FromUserStream: true,
+	})
+	if botState.ReservedUSDT != 0 {
+		t.Errorf("ReservedUSDT = %v, want 0", botState.ReservedUSDT)
+	}
+	if botState.OpenPositionQuantity != 0.0002 || botState.OpenPositionCostBasis != 5.8 {
+		t.Errorf("position = %v for %v, want 0.0002 for 5.8", botState.OpenPositionQuantity, botState.OpenPositionCostBasis)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSellOutsidePriceBandDeferred(t *testing.T) {
+	ts, _, mock := newTestStrategy(t, newCycleConfig())
+	trade, _ := newFilledTrade(29000.01)
+	bandErr := &PriceOutsideBandError{Side: models.OrderTypeSell, Price: 36000, AveragePrice: 29950, Low: 23960, High: 35940}
+
+	for i := 0; i < maxSellPlacementAttempts+1; i++ {
+		ts.handleSellPlacementFailure(context.Background(), trade, fmt.Errorf("failed to place sell order: %w", bandErr))
+	}
+	if trade.Status != models.TradeStatusOpen || ts.sellFailures[trade.ID] != 0 {
+		t.Errorf("trade status = %s with %d failures, want OPEN and no failures counted", trade.Status, ts.sellFailures[trade.ID])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInitialTriggerDrop(t *testing.T) {
+	tests := []struct {
+		name      string
+		prices    []float64
+		wantArmed []bool
+	}{
+		{"drop reaches the trigger", []float64{30000, 29800, 29500, 29900}, []bool{false, false, true, true}},
+		{"price never drops far enough", []float64{30000, 29900, 29560, 30500}, []bool{false, false, false, false}},
+		{"rise does not move the reference", []float64{30000, 32000, 29400}, []bool{false, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.InitialTriggerDrop = 1.5
+			ts, _, _ := newTestStrategy(t, cfg)
+			botState := ts.stateManager.GetBotState()
+
+			for i, price := range tt.prices {
+				if got := ts.initialLadderArmed(botState, price); got != tt.wantArmed[i] {
+					t.Errorf("price %v: armed = %v, want %v", price, got, tt.wantArmed[i])
+				}
+			}
+		})
+	}
+}
+
+func TestInitialTriggerDropSkippedWhenLadderStarted(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		drop    float64
+		started int
+	}{
+		{"trigger disabled", 0, 0},
+		{"ladder already has orders", 1.5, 3},
+	} {
+		cfg := newCycleConfig()
+		cfg.InitialTriggerDrop = tt.drop
+		ts, _, _ := newTestStrategy(t, cfg)
+		botState := ts.stateManager.GetBotState()
+		botState.InitialBuyOrdersPlacedCount = tt.started
+
+		if !ts.initialLadderArmed(botState, 30000) {
+			t.Errorf("%s: ladder not armed, want it to start at once", tt.name)
+		}
+	}
+}
+
+func TestMinHoldDefersSell(t *testing.T) {
+	tests := []struct {
+		name      string
+		heldFor   time.Duration
+		wantSells int
+	}{
+		{"within the hold", 25 * time.Minute, 0},
+		{"hold elapsed", 31 * time.Minute, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.MinHoldMinutes = 30
+			ts, fake, mock := newTestStrategy(t, cfg)
+			fake.fixture("POST /api/v3/order", "order_sell_new.json", http.StatusOK)
+
+			// The price is already above the 2% target, but the trade was opened heldFor ago
+			trade, buyOrder := newFilledTrade(29000.01)
+			trade.OpenedAt = time.Now().Add(-tt.heldFor)
+			mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows(trade))
+			mock.ExpectQuery("FROM orders").WithArgs(int64(28)).WillReturnRows(orderRows(buyOrder))
+			mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+			mock.ExpectExec("UPDATE trades").WillReturnResult(sqlmock.NewResult(0, 1))
+
+			if err := ts.checkAndPlaceSellOrders(context.Background(), 30000, true); err != nil {
+				t.Fatalf("checkAndPlaceSellOrders returned error: %v", err)
+			}
+			if calls := fake.calls("POST /api/v3/order"); len(calls) != tt.wantSells {
+				t.Errorf("placed %d sells after %s, want %d", len(calls), tt.heldFor, tt.wantSells)
+			}
+		})
+	}
+}
+
+func TestMinHoldUntil(t *testing.T) {
+	cfg := newCycleConfig()
+	cfg.MinHoldMinutes = 30
+	ts, _, _ := newTestStrategy(t, cfg)
+	trade, _ := newFilledTrade(29000.01)
+	trade.OpenedAt = time.Now().Add(-10 * time.Minute)
+
+	holdUntil, held := ts.minHoldUntil(trade)
+	if held || !holdUntil.Equal(trade.OpenedAt.Add(30*time.Minute)) {
+		t.Errorf("minHoldUntil = %v, %v, want %v and not held", holdUntil, held, trade.OpenedAt.Add(30*time.Minute))
+	}
+
+	ts.config.MinHoldMinutes = 0
+	if _, held := ts.minHoldUntil(trade); !held {
+		t.Error("minHoldUntil reported a hold with MIN_HOLD_MINUTES disabled")
+	}
+}
+
+func TestSnapshotDailyPriceOncePerDay(t *testing.T) {
+	ts, _, mock := newTestStrategy(t, newCycleConfig())
+
+	// The first cycle of the day records the price; later cycles the same day do not query again
+	mock.ExpectQuery("INSERT INTO price_snapshots").WithArgs("BTCUSDT", time.Now().UTC().Format(time.DateOnly), 30000.0, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	ts.snapshotDailyPrice(context.Background(), 30000)
+	ts.snapshotDailyPrice(context.Background(), 30100)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed save is retried on the next cycle
+	ts.lastSnapshotDate = ""
+	mock.ExpectQuery("INSERT INTO price_snapshots").WillReturnError(errors.New("connection refused"))
+	mock.ExpectQuery("INSERT INTO price_snapshots").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	ts.snapshotDailyPrice(context.Background(), 30000)
+	ts.snapshotDailyPrice(context.Background(), 30000)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEstimateLadderCapital(t *testing.T) {
+	ladder := newCycleConfig()
+	ladder.BuyPercentages = []float64{2, 4}
+
+	twap := newCycleConfig()
+	twap.Strategy = config.StrategyTWAP
+	twap.TWAPSlices = 4
+	twap.BuyPercentages = []float64{2}
+
+	percent := newCycleConfig()
+	percent.OrderAmountPercent = 10
+
+	tests := []struct {
+		name      string
+		cfg       *config.Config
+		wantRungs int
+		wantTotal float64
+	}{
+		{"ladder with additional rungs", ladder, 12, 240},
+		{"TWAP slices", twap, 5, 1020},
+		{"percentage sizing", percent, 10, 1000 * (1 - math.Pow(0.9, 10))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, _, _ := newTestStrategy(t, tt.cfg)
+			estimate := ts.EstimateLadderCapital(30000)
+			if len(estimate.Rungs) != tt.wantRungs {
+				t.Fatalf("got %d rungs, want %d", len(estimate.Rungs), tt.wantRungs)
+			}
+			if math.Abs(estimate.TotalUSDT-tt.wantTotal) > 1e-9 {
+				t.Errorf("TotalUSDT = %v, want %v", estimate.TotalUSDT, tt.wantTotal)
+			}
+			sum := 0.0
+			for _, rung := range estimate.Rungs {
+				sum += rung.USDT
+				if rung.Price > 0 && math.Abs(rung.Quantity*rung.Price-rung.USDT) > 1e-9 {
+					t.Errorf("rung %+v: quantity does not spend its USDT at its price", rung)
+				}
+			}
+			if math.Abs(sum-estimate.TotalUSDT) > 1e-9 {
+				t.Errorf("rungs sum to %v, want the total %v", sum, estimate.TotalUSDT)
+			}
+		})
+	}
+
+	ts, _, _ := newTestStrategy(t, ladder)
+	rungs := ts.EstimateLadderCapital(30000).Rungs
+	if first, last := rungs[0], rungs[len(rungs)-1]; first.Phase != "initial" || first.Price != 29700 ||
+		last.Phase != "additional" || last.Price != 28800 {
+		t.Errorf("first/last rung = %+v / %+v, want an initial rung at 29700 and an additional one at 28800", first, last)
+	}
+}
+
+func TestSellUsesRungProfitTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		rungTarget float64
+		wantPrice  string
+	}{
+		{"buy placed for a 4% rung", 4, "30160"},
+		{"buy without its own target", 0, "29580"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, fake, mock := newTestStrategy(t, newCycleConfig())
+			fake.fixture("POST /api/v3/order", "order_sell_new.json", http.StatusOK)
+
+			trade, buyOrder := newFilledTrade(29000)
+			buyOrder.SellProfitPercentage = tt.rungTarget
+			mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows(trade))
+			mock.ExpectQuery("FROM orders").WithArgs(int64(28)).WillReturnRows(orderRows(buyOrder))
+			mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+			mock.ExpectExec("UPDATE trades").WillReturnResult(sqlmock.NewResult(0, 1))
+
+			if err := ts.checkAndPlaceSellOrders(context.Background(), 29100, true); err != nil {
+				t.Fatalf("checkAndPlaceSellOrders returned error: %v", err)
+			}
+			calls := fake.calls("POST /api/v3/order")
+			if len(calls) != 1 || calls[0].Get("price") != tt.wantPrice {
+				t.Errorf("sell requests = %v, want one at %s", calls, tt.wantPrice)
+			}
+		})
+	}
+}
+
+func TestAdditionalBuyUsesItsRung(t *testing.T) {
+	tests := []struct {
+		openTrades int
+		wantPrice  string
+		wantTarget float64
+	}{
+		{0, "29700", 2},
+		{1, "29100", 5},
+		{3, "29100", 5}, // Past the last rung, the ladder stays on it
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d open trades", tt.openTrades), func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.BuyPercentages = []float64{1, 3}
+			cfg.SellProfitPercentages = []float64{2, 5}
+			cfg.MaxOpenTrades = 10
+			ts, fake, mock := newTestStrategy(t, cfg)
+			ts.stateManager.GetBotState().IsInitialBuyingComplete = true
+
+			var open []*models.Trade
+			for i := 0; i < tt.openTrades; i++ {
+				trade, _ := newFilledTrade(29000)
+				open = append(open, trade)
+			}
+			anyArg := sqlmock.AnyArg()
+			mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows(open...))
+			mock.ExpectQuery("INSERT INTO orders").
+				WithArgs(anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, tt.wantTarget).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+			if err := ts.placeAdditionalBuyOrders(context.Background(), 30000); err != nil {
+				t.Fatalf("placeAdditionalBuyOrders returned error: %v", err)
+			}
+			calls := fake.calls("POST /api/v3/order")
+			if len(calls) != 1 || calls[0].Get("price") != tt.wantPrice {
+				t.Errorf("buy requests = %v, want one at %s", calls, tt.wantPrice)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("buy not saved with the rung's %v%% target: %v", tt.wantTarget, err)
+			}
+		})
+	}
+}
+
+func TestSellOrderTTL(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      string
+		restedFor   time.Duration
+		wantCancels int
+		wantType    string // Type of the replacement sell, empty for none
+	}{
+		{"not yet expired", config.SellOrderTTLActionReprice, 50 * time.Minute, 0, ""},
+		{"expired, repriced", config.SellOrderTTLActionReprice, 70 * time.Minute, 1, "LIMIT"},
+		{"expired, sold at market", config.SellOrderTTLActionMarket, 70 * time.Minute, 1, "MARKET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.SellOrderTTLMinutes = 60
+			cfg.SellOrderTTLAction = tt.action
+			ts, fake, mock := newTestStrategy(t, cfg)
+			if tt.action == config.SellOrderTTLActionMarket {
+				fake.fixture("POST /api/v3/order", "order_market_sell_filled.json", http.StatusOK)
+				anyArg := sqlmock.AnyArg()
+				mock.ExpectExec("UPDATE trades").
+					WithArgs(anyArg, anyArg, models.TradeStatusSold, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, anyArg, int64(7)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			} else {
+				echoSellOrders(fake, 40)
+			}
+
+			trade, buyOrder := newFilledTrade(29000.01)
+			trade.SetSellOrder(29)
+			sellOrder := newBuyOrder(29, 29580.01, 0.00034)
+			sellOrder.Type = models.OrderTypeSell
+			sellOrder.PlacedAt = time.Now().Add(-tt.restedFor)
+			mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows(trade))
+			mock.ExpectQuery("FROM orders").WithArgs(int64(28)).WillReturnRows(orderRows(buyOrder))
+			mock.ExpectQuery("FROM orders").WithArgs(int64(29)).WillReturnRows(orderRows(sellOrder))
+
+			if err := ts.checkAndPlaceSellOrders(context.Background(), 29300, true); err != nil {
+				t.Fatalf("checkAndPlaceSellOrders returned error: %v", err)
+			}
+			if calls := fake.calls("DELETE /api/v3/order"); len(calls) != tt.wantCancels {
+				t.Errorf("got %d cancel requests after %s, want %d", len(calls), tt.restedFor, tt.wantCancels)
+			}
+			calls := fake.calls("POST /api/v3/order")
+			if tt.wantType == "" {
+				if len(calls) != 0 {
+					t.Errorf("placed %v, want the sell left resting", calls)
+				}
+				return
+			}
+			if len(calls) != 1 || calls[0].Get("side") != "SELL" || calls[0].Get("type") != tt.wantType {
+				t.Fatalf("order requests = %v, want one %s sell", calls, tt.wantType)
+			}
+			if tt.wantType == "LIMIT" {
+				if price, _ := strconv.ParseFloat(calls[0].Get("price"), 64); price >= 29580.01 {
+					t.Errorf("repriced sell at %v, want below the expired 29580.01", price)
+				}
+			} else if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("trade not marked SOLD at market: %v", err)
+			}
+		})
+	}
+}
+
+func TestAdditionalBuysTodayResetsAtMidnight(t *testing.T) {
+	ts, _, mock := newTestStrategy(t, newCycleConfig())
+	botState := ts.stateManager.GetBotState()
+	lastInitialBuy := int64(30)
+	botState.LastInitialBuyOrderID = &lastInitialBuy
+
+	beforeMidnight := time.Date(2024, 3, 5, 23, 59, 0, 0, time.UTC)
+	afterMidnight := beforeMidnight.Add(2 * time.Minute)
+	mock.ExpectQuery("SELECT COUNT").WithArgs("BTCUSDT", models.OrderTypeBuy, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), int64(30)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("SELECT COUNT").WithArgs("BTCUSDT", models.OrderTypeBuy, time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC), int64(30)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	if placed, err := ts.additionalBuysToday(context.Background(), botState, beforeMidnight); err != nil || placed != 3 {
+		t.Errorf("buys at 23:59 = %d, %v, want the day's 3", placed, err)
+	}
+	if placed, err := ts.additionalBuysToday(context.Background(), botState, afterMidnight); err != nil || placed != 0 {
+		t.Errorf("buys at 00:01 = %d, %v, want the count reset for the new day", placed, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMaxAdditionalBuysPerDay(t *testing.T) {
+	tests := []struct {
+		placedToday int
+		wantOrders  int
+	}{
+		{1, 1},
+		{2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d placed today", tt.placedToday), func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.MaxAdditionalBuysPerDay = 2
+			cfg.MaxOpenTrades = 10
+			cfg.BuyPercentages = []float64{2}
+			ts, fake, mock := newTestStrategy(t, cfg)
+			ts.stateManager.GetBotState().IsInitialBuyingComplete = true
+			mock.ExpectQuery("FROM trades").WillReturnRows(tradeRows())
+			mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.placedToday))
+			mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+			if err := ts.placeAdditionalBuyOrders(context.Background(), 30000); err != nil {
+				t.Fatalf("placeAdditionalBuyOrders returned error: %v", err)
+			}
+			if calls := fake.calls("POST /api/v3/order"); len(calls) != tt.wantOrders {
+				t.Errorf("placed %d buys with %d already placed today, want %d", len(calls), tt.placedToday, tt.wantOrders)
+			}
+		})
+	}
+}
+
+func TestInsufficientBalanceSkipsRung(t *testing.T) {
+	ts, fake, mock := newTestStrategy(t, newCycleConfig())
+	fake.fixture("POST /api/v3/order", "error_insufficient_balance.json", http.StatusBadRequest)
+	expectQuietCycle(mock)
+
+	// The rejected rung does not fail the cycle, which still checks open orders and saves the state
+	result, err := ts.runCycle(context.Background())
+	if err != nil {
+		t.Fatalf("runCycle returned error: %v", err)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("cycle errors = %v, want the rejected rung skipped", result.Errors)
+	}
+	if calls := fake.calls("GET /api/v3/openOrders"); len(calls) == 0 {
+		t.Error("open orders were not managed after the rejected rung")
+	}
+	botState := ts.stateManager.GetBotState()
+	if botState.InitialBuyOrdersPlacedCount != 0 || botState.ReservedUSDT != 0 {
+		t.Errorf("placed count/reserved = %d/%v, want the rung left for the next cycle", botState.InitialBuyOrdersPlacedCount, botState.ReservedUSDT)
+	}
+}
+
+func TestShrinkBuyToFunds(t *testing.T) {
+	tests := []struct {
+		name       string
+		shrink     bool
+		freeUSDT   string
+		wantOrders int
+		wantQty    string
+	}{
+		{"retried for the free USDT", true, "14.85", 2, "0.0005"},
+		{"free USDT below the minimum notional", true, "4.00", 1, ""},
+		{"shrinking disabled", false, "14.85", 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.ShrinkBuyToFunds = tt.shrink
+			ts, fake, _ := newTestStrategy(t, cfg)
+			fake.respond("GET /api/v3/account", http.StatusOK,
+				`{"balances":[{"asset":"BTC","free":"0","locked":"0"},{"asset":"USDT","free":"`+tt.freeUSDT+`","locked":"0"}]}`)
+			rejected := false
+			fake.handle("POST /api/v3/order", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if !rejected {
+					rejected = true
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"code":-2010,"msg":"Account has insufficient balance for requested action."}`))
+					return
+				}
+				w.Write([]byte(`{"symbol":"BTCUSDT","orderId":28,"price":"29700","origQty":"0.0005","status":"NEW","type":"LIMIT","side":"BUY"}`))
+			})
+
+			if err := ts.resolveAssets(context.Background()); err != nil {
+				t.Fatalf("resolveAssets returned error: %v", err)
+			}
+			_, err := ts.placeBuyOrder(context.Background(), config.OrderTypeLimit, config.TimeInForceGTC, 29700, 2)
+			calls := fake.calls("POST /api/v3/order")
+			if len(calls) != tt.wantOrders {
+				t.Fatalf("sent %d orders, want %d", len(calls), tt.wantOrders)
+			}
+			if tt.wantQty == "" {
+				if !IsInsufficientBalance(err) {
+					t.Errorf("placeBuyOrder error = %v, want the insufficient balance rejection", err)
+				}
+				return
+			}
+			if err != nil || calls[1].Get("quantity") != tt.wantQty {
+				t.Errorf("retry quantity = %s (%v), want %s for 14.85 USDT at 29700", calls[1].Get("quantity"), err, tt.wantQty)
+			}
+		})
+	}
+}
+
+func TestIsInsufficientBalance(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&common.APIError{Code: -2010, Message: "Account has insufficient balance for requested action."}, true},
+		{fmt.Errorf("failed to place order: %w", &common.APIError{Code: -2010, Message: "Account has insufficient balance for requested action."}), true},
+		{&common.APIError{Code: -2010, Message: "Order would immediately match and take."}, false},
+		{&common.APIError{Code: -1013, Message: "Filter failure: insufficient balance"}, false},
+		{errors.New("insufficient balance"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsInsufficientBalance(tt.err); got != tt.want {
+			t.Errorf("IsInsufficientBalance(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestResolveAssets(t *testing.T) {
+	tests := []struct {
+		name             string
+		base, quote      string
+		wantBase         string
+		wantQuote        string
+		wantExchangeInfo bool
+	}{
+		{"derived from exchange info", "", "", "BTC", "USDT", true},
+		{"quote override", "", "FDUSD", "BTC", "FDUSD", true},
+		{"both overridden", "WBTC", "USDC", "WBTC", "USDC", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.BaseAsset, cfg.QuoteAsset = tt.base, tt.quote
+			ts, fake, _ := newTestStrategy(t, cfg)
+
+			if err := ts.resolveAssets(context.Background()); err != nil {
+				t.Fatalf("resolveAssets returned error: %v", err)
+			}
+			if ts.baseAsset != tt.wantBase || ts.quoteAsset != tt.wantQuote {
+				t.Errorf("assets = %s/%s, want %s/%s", ts.baseAsset, ts.quoteAsset, tt.wantBase, tt.wantQuote)
+			}
+			if got := len(fake.calls("GET /api/v3/exchangeInfo")) > 0; got != tt.wantExchangeInfo {
+				t.Errorf("exchange info fetched = %v, want %v", got, tt.wantExchangeInfo)
+			}
+		})
+	}
+
+	t.Run("exchange info failure", func(t *testing.T) {
+		ts, fake, _ := newTestStrategy(t, newCycleConfig())
+		fake.respond("GET /api/v3/exchangeInfo", http.StatusInternalServerError, `{"code":-1000,"msg":"unknown"}`)
+		if err := ts.resolveAssets(context.Background()); err == nil {
+			t.Fatal("resolveAssets succeeded without exchange info")
+		}
+		if ts.baseAsset != "" || ts.quoteAsset != "" {
+			t.Errorf("assets = %s/%s after a failure, want them left unresolved", ts.baseAsset, ts.quoteAsset)
+		}
+	})
+}
+
+func TestCachedPriceWhenFetchFails(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAge      int
+		cachedAgo   time.Duration
+		wantRunning bool
+	}{
+		{"recent cached price", 60, 10 * time.Second, true},
+		{"cached price too old", 60, 2 * time.Minute, false},
+		{"caching disabled", 0, 10 * time.Second, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.CachedPriceMaxAgeSeconds = tt.maxAge
+			ts, fake, mock := newTestStrategy(t, cfg)
+			expectQuietCycle(mock)
+			fake.respond("GET /api/v3/ticker/price", http.StatusInternalServerError, `{"code":-1001,"msg":"Internal error; unable to process your request."}`)
+			ts.lastPrice, ts.lastPriceAt = 30000, time.Now().Add(-tt.cachedAgo)
+
+			result, err := ts.runCycle(context.Background())
+			if (err == nil) != tt.wantRunning {
+				t.Fatalf("runCycle error = %v, want the cycle to continue: %v", err, tt.wantRunning)
+			}
+			if tt.wantRunning && (len(result.Errors) == 0 || !strings.HasPrefix(result.Errors[0], "get current price")) {
+				t.Errorf("cycle errors = %v, want the failed price fetch recorded", result.Errors)
+			}
+			// Even on a usable cached price no new buys go out on a stale market
+			if calls := fake.calls("POST /api/v3/order"); len(calls) != 0 {
+				t.Errorf("placed %d orders without a fresh price, want none", len(calls))
+			}
+		})
+	}
+}
+
+func TestOrderAmountPercent(t *testing.T) {
+	cfg := newCycleConfig()
+	cfg.OrderAmount = 0
+	cfg.OrderAmountPercent = 5
+	ts, _, _ := newTestStrategy(t, cfg)
+	botState := ts.stateManager.GetBotState()
+
+	// Before the state is loaded INITIAL_USDT stands in for the balance
+	if got := ts.orderAmount(); got != 50 {
+		t.Errorf("orderAmount before initialization = %v, want 5%% of 1000", got)
+	}
+	botState.MarkInitialized()
+	botState.CurrentUSDTBalance = 400
+	if got := ts.orderAmount(); math.Abs(got-botState.AvailableUSDT()*0.05) > 1e-9 {
+		t.Errorf("orderAmount = %v, want 5%% of the %v USDT available", got, botState.AvailableUSDT())
+	}
+	if !ts.hasFundsForOrder(botState) {
+		t.Error("hasFundsForOrder = false, want a percentage of the balance to always fit")
+	}
+	botState.CurrentUSDTBalance = 0
+	if ts.hasFundsForOrder(botState) {
+		t.Error("hasFundsForOrder = true with no USDT, want false")
+	}
+
+	// Each estimated rung spends 5% of what the previous rungs left: 50, then 47.5
+	estimate := ts.EstimateLadderCapital(30000)
+	if len(estimate.Rungs) < 2 || estimate.Rungs[0].USDT != 50 || estimate.Rungs[1].USDT != 47.5 {
+		t.Errorf("rungs = %+v, want 50 then 47.5 USDT", estimate.Rungs)
+	}
+}
+
+func TestVerifyPlacedOrders(t *testing.T) {
+	tests := []struct {
+		name        string
+		verify      bool
+		fixture     string
+		status      int
+		wantLookups int
+		wantMissing bool
+	}{
+		{"order found", true, "order_new.json", http.StatusOK, 1, false},
+		{"order missing", true, "error_unknown_order.json", http.StatusBadRequest, 1, true},
+		{"verification disabled", false, "error_unknown_order.json", http.StatusBadRequest, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.VerifyPlacedOrders = tt.verify
+			ts, fake, mock := newTestStrategy(t, cfg)
+			fake.fixture("GET /api/v3/order", tt.fixture, tt.status)
+			if tt.wantMissing {
+				mock.ExpectQuery("INSERT INTO events").WithArgs(models.EventOrderMissing, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			}
+
+			ts.recordOrderPlaced(context.Background(), newBuyOrder(28, 29700, 0.00067))
+			if got := len(fake.calls("GET /api/v3/order")); got != tt.wantLookups {
+				t.Errorf("looked the order up %d times, want %d", got, tt.wantLookups)
+			}
+			if ts.missingOrders[28] != tt.wantMissing {
+				t.Errorf("order marked missing = %v, want %v", ts.missingOrders[28], tt.wantMissing)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSettleMissingOrderAsRejected(t *testing.T) {
+	ts, fake, mock := newTestStrategy(t, newCycleConfig())
+	fake.fixture("GET /api/v3/order", "error_unknown_order.json", http.StatusBadRequest)
+	mock.ExpectExec("UPDATE orders").WillReturnResult(sqlmock.NewResult(0, 1))
+	botState := ts.stateManager.GetBotState()
+
+	// An unmarked order Binance cannot find is only warned about
+	unmarked := newBuyOrder(27, 29700, 0.00067)
+	botState.ReserveUSDT(unmarked.QuoteQty)
+	ts.settleClosedOrder(context.Background(), unmarked)
+	if unmarked.Status != models.OrderStatusNew {
+		t.Errorf("unmarked order status = %s, want it left %s", unmarked.Status, models.OrderStatusNew)
+	}
+
+	missing := newBuyOrder(28, 29700, 0.00067)
+	botState.ReserveUSDT(missing.QuoteQty)
+	ts.missingOrders[28] = true
+	ts.settleClosedOrder(context.Background(), missing)
+	if missing.Status != models.OrderStatusRejected {
+		t.Errorf("missing order status = %s, want %s", missing.Status, models.OrderStatusRejected)
+	}
+	if math.Abs(botState.ReservedUSDT-unmarked.QuoteQty) > 1e-9 {
+		t.Errorf("ReservedUSDT = %v, want only the unmarked order's %v left", botState.ReservedUSDT, unmarked.QuoteQty)
+	}
+	if ts.missingOrders[28] {
+		t.Error("order still marked missing after settling it")
+	}
+}
+
+func TestBuyTimeInForce(t *testing.T) {
+	tests := []struct {
+		name         string
+		tif          string
+		response     string
+		wantReserved float64
+		wantPosition float64
+	}{
+		{"GTC rests and reserves", config.TimeInForceGTC,
+			`{"symbol":"BTCUSDT","orderId":28,"price":"29700","origQty":"0.00067","executedQty":"0","status":"NEW","timeInForce":"GTC","type":"LIMIT","side":"BUY"}`,
+			0.00067 * 29700, 0},
+		{"IOC books the immediate fill", config.TimeInForceIOC,
+			`{"symbol":"BTCUSDT","orderId":28,"price":"29700","origQty":"0.00067","executedQty":"0.0003","status":"EXPIRED","timeInForce":"IOC","type":"LIMIT","side":"BUY"}`,
+			0, 0.0003},
+		{"FOK that did not fill", config.TimeInForceFOK,
+			`{"symbol":"BTCUSDT","orderId":28,"price":"29700","origQty":"0.00067","executedQty":"0","status":"EXPIRED","timeInForce":"FOK","type":"LIMIT","side":"BUY"}`,
+			0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, fake, _ := newTestStrategy(t, newCycleConfig())
+			fake.respond("POST /api/v3/order", http.StatusOK, tt.response)
+			botState := ts.stateManager.GetBotState()
+			botState.MarkInitialized()
+
+			if _, err := ts.placeBuyOrder(context.Background(), config.OrderTypeLimit, tt.tif, 29700, 2); err != nil {
+				t.Fatalf("placeBuyOrder returned error: %v", err)
+			}
+			calls := fake.calls("POST /api/v3/order")
+			if len(calls) != 1 || calls[0].Get("timeInForce") != tt.tif {
+				t.Fatalf("order requests = %v, want one with timeInForce %s", calls, tt.tif)
+			}
+			if math.Abs(botState.ReservedUSDT-tt.wantReserved) > 1e-9 {
+				t.Errorf("ReservedUSDT = %v, want %v", botState.ReservedUSDT, tt.wantReserved)
+			}
+			if math.Abs(botState.OpenPositionQuantity-tt.wantPosition) > 1e-12 {
+				t.Errorf("position = %v, want %v", botState.OpenPositionQuantity, tt.wantPosition)
+			}
+		})
+	}
+}
+
+func TestHaltedSymbolSkipsPlacement(t *testing.T) {
+	exchangeInfo, err := os.ReadFile(filepath.Join("testdata", "exchange_info.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	ts, fake, mock := newTestStrategy(t, newCycleConfig())
+	fake.respond("GET /api/v3/exchangeInfo", http.StatusOK, strings.Replace(string(exchangeInfo), `"status": "TRADING"`, `"status": "BREAK"`, 1))
+	expectQuietCycle(mock)
+
+	if _, err := ts.runCycle(context.Background()); err != nil {
+		t.Fatalf("runCycle returned error: %v", err)
+	}
+	if calls := fake.calls("POST /api/v3/order"); len(calls) != 0 {
+		t.Fatalf("placed %d orders while the symbol is in BREAK, want none", len(calls))
+	}
+	if !ts.symbolHalted {
+		t.Error("symbolHalted = false during BREAK")
+	}
+
+	// Within symbolStatusMaxAge the cached status is reused; once it is older it is fetched again
+	fake.respond("GET /api/v3/exchangeInfo", http.StatusOK, string(exchangeInfo))
+	if !ts.symbolNotTrading(context.Background()) {
+		t.Error("symbolNotTrading = false before the cached BREAK status expired")
+	}
+	ts.binanceService.symbolInfoMu.Lock()
+	ts.binanceService.symbolInfoCache["BTCUSDT"].fetchedAt = time.Now().Add(-symbolStatusMaxAge)
+	ts.binanceService.symbolInfoMu.Unlock()
+	if ts.symbolNotTrading(context.Background()) || ts.symbolHalted {
+		t.Error("symbol still halted after exchange info reports TRADING again")
+	}
+
+	// A failed status check keeps the last known status
+	ts.symbolHalted = true
+	ts.binanceService.symbolInfoMu.Lock()
+	delete(ts.binanceService.symbolInfoCache, "BTCUSDT")
+	ts.binanceService.symbolInfoMu.Unlock()
+	fake.respond("GET /api/v3/exchangeInfo", http.StatusInternalServerError, `{"code":-1000,"msg":"unknown"}`)
+	if !ts.symbolNotTrading(context.Background()) {
+		t.Error("symbolNotTrading = false after a failed check while halted, want the last known status kept")
+	}
+}
+
+// klines returns n klines around 30000 whose true range is always spread, as /api/v3/klines answers them.
+func klines(n int, spread float64) string {
+	high := strconv.FormatFloat(30000+spread/2, 'f', -1, 64)
+	low := strconv.FormatFloat(30000-spread/2, 'f', -1, 64)
+	var rows []string
+	for i := 0; i < n; i++ {
+		rows = append(rows, `[1700000000000,"30000","`+high+`","`+low+`","30000","1",1700003599999,"30000",10,"0.5","15000","0"]`)
+	}
+	return "[" + strings.Join(rows, ",") + "]"
+}
+
+func TestATRProfitTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantTarget float64
+		wantOK     bool
+	}{
+		// Two ATRs of 300 above 30000
+		{"ATR target", http.StatusOK, klines(43, 300), 2, true},
+		{"below round-trip fees", http.StatusOK, klines(43, 10), 0, false},
+		{"too little history", http.StatusOK, klines(10, 300), 0, false},
+		{"klines unavailable", http.StatusInternalServerError, `{"code":-1000,"msg":"unknown"}`, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCycleConfig()
+			cfg.SellTargetATRMultiple = 2
+			cfg.ATRPeriod = 14
+			cfg.ATRInterval = "1h"
+			ts, fake, _ := newTestStrategy(t, cfg)
+			fake.respond("GET /api/v3/klines", tt.status, tt.body)
+
+			target, ok := ts.atrProfitTarget(context.Background(), 30000)
+			if ok != tt.wantOK || math.Abs(target-tt.wantTarget) > 1e-9 {
+				t.Errorf("atrProfitTarget = %v, %v, want %v, %v", target, ok, tt.wantTarget, tt.wantOK)
+			}
+			if calls := fake.calls("GET /api/v3/klines"); len(calls) != 1 || calls[0].Get("limit") != "43" || calls[0].Get("interval") != "1h" {
+				t.Errorf("klines requests = %v, want one for 43 1h bars", calls)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		ts, fake, _ := newTestStrategy(t, newCycleConfig())
+		if _, ok := ts.atrProfitTarget(context.Background(), 30000); ok {
+			t.Error("atrProfitTarget applied with SELL_TARGET_ATR_MULTIPLE unset")
+		}
+		if len(fake.calls("GET /api/v3/klines")) != 0 {
+			t.Error("fetched klines with SELL_TARGET_ATR_MULTIPLE unset")
+		}
+	})
+}
+
+func TestStopLossRefusesStalePrice(t *testing.T) {
+	cfg := newCycleConfig()
+	cfg.StopLossPercentage = 5 // Stop at 27550.01 for a buy at 29000.01
+	cfg.StopLossConfirmSeconds = 60
+	cfg.StopLossMaxPriceAgeSeconds = 30
+	ctx := context.Background()
+	lastTrade := func(ago time.Duration) string {
+		return `[{"id":1,"price":"27000.00000000","qty":"0.01","quoteQty":"270","time":` +
+			strconv.FormatInt(time.Now().Add(-ago).UnixMilli(), 10) + `,"isBuyerMaker":true,"isBestMatch":true}]`
+	}
+
+	t.Run("stale last trade", func(t *testing.T) {
+		ts, fake, _ := newTestStrategy(t, cfg)
+		fake.respond("GET /api/v1/trades", http.StatusOK, lastTrade(10*time.Minute))
+		trade, buyOrder := newFilledTrade(29000.01)
+		ts.stopLossTriggeredAt[trade.ID] = time.Now().Add(-2 * time.Minute)
+
+		closed, err := ts.checkStopLoss(ctx, trade, buyOrder, 27000)
+		if err == nil || closed {
+			t.Fatalf("checkStopLoss = %t, %v, want it refused on a 10 minute old price", closed, err)
+		}
+		if calls := fake.calls("POST /api/v3/order"); len(calls) != 0 {
+			t.Errorf("liquidated on a stale price: %v", calls)
+		}
+		if _, pending := ts.stopLossTriggeredAt[trade.ID]; !pending {
+			t.Error("stop-loss no longer pending, want it re-checked next cycle")
+		}
+		if len(fake.calls("GET /api/v3/ticker/price")) != 0 {
+			t.Error("fell back to the ticker price instead of refusing")
+		}
+	})
+
+	t.Run("fresh last trade", func(t *testing.T) {
+		ts, fake, mock := newTestStrategy(t, cfg)
+		fake.respond("GET /api/v1/trades", http.StatusOK, lastTrade(5*time.Second))
+		fake.fixture("POST /api/v3/order", "order_market_sell_filled.json", http.StatusOK)
+		mock.ExpectQuery("INSERT INTO orders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery("INSERT INTO events").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec("UPDATE trades").WillReturnResult(sqlmock.NewResult(0, 1))
+		trade, buyOrder := newFilledTrade(29000.01)
+		ts.stopLossTriggeredAt[trade.ID] = time.Now().Add(-2 * time.Minute)
+
+		closed, err := ts.checkStopLoss(ctx, trade, buyOrder, 27000)
+		if err != nil || !closed {
+			t.Fatalf("checkStopLoss = %t, %v, want the trade closed on a fresh price", closed, err)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"binance-trader-bot/models"
+
+	"github.com/adshao/go-binance/v2"
+)
+
+const (
+	listenKeyKeepaliveInterval = 30 * time.Minute // Binance expires a listen key after 60 minutes without keepalive
+	userStreamReconnectDelay   = 5 * time.Second
+)
+
+// OrderUpdate is a change in an order's status reported by Binance, from REST polling or the user data stream.
+type OrderUpdate struct {
+	BinanceID      int64
+	Symbol         string
+	Status         models.OrderStatus
+	ExecutedQty    float64 // Base asset filled so far
+	ExecutedQuote  float64 // Quote asset spent or received so far
+	FromUserStream bool
+}
+
+// RunUserDataStream subscribes to the account's user data stream and calls onOrderUpdate for every
+// execution report. It keeps the listen key alive and reconnects until ctx is cancelled.
+func (s *BinanceService) RunUserDataStream(ctx context.Context, onOrderUpdate func(context.Context, OrderUpdate)) {
+	binance.UseTestnet = s.testnet // Selects the websocket endpoint
+
+	for ctx.Err() == nil {
+		if err := s.serveUserDataStream(ctx, onOrderUpdate); err != nil {
+			s.logger.Errorf("User data stream failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(userStreamReconnectDelay):
+			s.logger.Info("Reconnecting user data stream...")
+		}
+	}
+}
+
+// serveUserDataStream runs one user data stream session until it disconnects or ctx is cancelled.
+func (s *BinanceService) serveUserDataStream(ctx context.Context, onOrderUpdate func(context.Context, OrderUpdate)) error {
+	listenKey, err := s.client.NewStartUserStreamService().Do(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.client.NewCloseUserStreamService().ListenKey(listenKey).Do(closeCtx); err != nil {
+			s.logger.Warnf("Failed to close user data stream listen key: %v", err)
+		}
+	}()
+
+	handler := func(event *binance.WsUserDataEvent) {
+		if event.Event != binance.UserDataEventTypeExecutionReport {
+			return
+		}
+		onOrderUpdate(ctx, toOrderUpdate(&event.OrderUpdate))
+	}
+	errHandler := func(err error) {
+		s.logger.Warnf("User data stream error: %v", err)
+	}
+	doneC, stopC, err := binance.WsUserDataServe(listenKey, handler, errHandler)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("User data stream connected.")
+
+	keepalive := time.NewTicker(listenKeyKeepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			close(stopC)
+			<-doneC
+			return nil
+		case <-doneC:
+			s.logger.Warn("User data stream disconnected.")
+			return nil
+		case <-keepalive.C:
+			if err := s.client.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(ctx); err != nil {
+				s.logger.Warnf("Failed to keep user data stream alive: %v", err)
+			}
+		}
+	}
+}
+
+func toOrderUpdate(u *binance.WsOrderUpdate) OrderUpdate {
+	executedQty, _ := strconv.ParseFloat(u.FilledVolume, 64)
+	executedQuote, _ := strconv.ParseFloat(u.FilledQuoteVolume, 64)
+	return OrderUpdate{
+		BinanceID:      u.Id,
+		Symbol:         u.Symbol,
+		Status:         models.OrderStatus(u.Status),
+		ExecutedQty:    executedQty,
+		ExecutedQuote:  executedQuote,
+		FromUserStream: true,
+	}
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"testing"
+
+	"binance-trader-bot/models"
+
+	"github.com/adshao/go-binance/v2"
+)
+
+func TestToOrderUpdate(t *testing.T) {
+	update := toOrderUpdate(&binance.WsOrderUpdate{
+		Id:                28,
+		Symbol:            "BTCUSDT",
+		Status:            "PARTIALLY_FILLED",
+		FilledVolume:      "0.00020000",
+		FilledQuoteVolume: "5.80000000",
+	})
+	want := OrderUpdate{
+		BinanceID:      28,
+		Symbol:         "BTCUSDT",
+		Status:         models.OrderStatusPartiallyFilled,
+		ExecutedQty:    0.0002,
+		ExecutedQuote:  5.8,
+		FromUserStream: true,
+	}
+	if update != want {
+		t.Errorf("toOrderUpdate = %+v, want %+v", update, want)
+	}
+}
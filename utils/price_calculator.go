@@ -2,6 +2,7 @@ package utils
 
 import (
 	"math"
+	"strconv"
 )
 
 // CalculateBuyPrice calculates the limit price for a buy order.
@@ -35,3 +36,10 @@ func RoundToDecimalPlaces(value float64, places int) float64 {
 	shift := math.Pow(10, float64(places))
 	return math.Round(value*shift) / shift
 }
+
+// FormatPrice renders a price for logs with the given number of decimals, e.g. the symbol's tick
+// precision, so float noise like 99.00000003 shows as 99.00. It only affects display: stored values
+// keep full precision. A negative decimals prints the shortest exact representation.
+func FormatPrice(price float64, decimals int) string {
+	return strconv.FormatFloat(price, 'f', decimals, 64)
+}
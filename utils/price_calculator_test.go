@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormatPrice(t *testing.T) {
+	tests := []struct {
+		price    float64
+		decimals int
+		want     string
+	}{
+		{99.00000003, 2, "99.00"},
+		{29000.016, 2, "29000.02"},
+		{0.000012346, 8, "0.00001235"},
+		{99.00000003, -1, "99.00000003"}, // Full precision
+	}
+	for _, tt := range tests {
+		if got := FormatPrice(tt.price, tt.decimals); got != tt.want {
+			t.Errorf("FormatPrice(%v, %d) = %q, want %q", tt.price, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+// fuzzablePrice reports whether price and percentages are in the range the bot trades with: positive
+// finite prices and percentages strictly between 0.0001 and 100.
+func fuzzablePrice(price float64, percentages ...float64) bool {
+	if math.IsNaN(price) || price < 1e-8 || price > 1e9 {
+		return false
+	}
+	for _, p := range percentages {
+		if math.IsNaN(p) || p < 1e-4 || p >= 100 {
+			return false
+		}
+	}
+	return true
+}
+
+func FuzzCalculateBuyPrice(f *testing.F) {
+	f.Add(30000.0, 1.0, 2.0)
+	f.Add(0.00001234, 0.5, 99.9)
+	f.Add(1.0, 50.0, 50.0)
+	f.Fuzz(func(t *testing.T, current, pct1, pct2 float64) {
+		if !fuzzablePrice(current, pct1, pct2) {
+			t.Skip()
+		}
+		buy1, buy2 := CalculateBuyPrice(current, pct1), CalculateBuyPrice(current, pct2)
+		if buy1 <= 0 || buy1 >= current {
+			t.Errorf("CalculateBuyPrice(%v, %v) = %v, want it between 0 and the current price", current, pct1, buy1)
+		}
+		// A larger discount never gives a higher price
+		if pct1 < pct2 && buy1 < buy2 {
+			t.Errorf("CalculateBuyPrice(%v, %v) = %v is below CalculateBuyPrice(%v, %v) = %v", current, pct1, buy1, current, pct2, buy2)
+		}
+	})
+}
+
+func FuzzCalculateSellPrice(f *testing.F) {
+	f.Add(30000.0, 1.0, 2.0)
+	f.Add(0.00001234, 0.5, 99.9)
+	f.Add(1.0, 50.0, 50.0)
+	f.Fuzz(func(t *testing.T, base, pct1, pct2 float64) {
+		if !fuzzablePrice(base, pct1, pct2) {
+			t.Skip()
+		}
+		sell1, sell2 := CalculateSellPrice(base, pct1), CalculateSellPrice(base, pct2)
+		if sell1 <= base {
+			t.Errorf("CalculateSellPrice(%v, %v) = %v, want it above the base price", base, pct1, sell1)
+		}
+		// A larger profit target never gives a lower price
+		if pct1 < pct2 && sell1 > sell2 {
+			t.Errorf("CalculateSellPrice(%v, %v) = %v is above CalculateSellPrice(%v, %v) = %v", base, pct1, sell1, base, pct2, sell2)
+		}
+	})
+}